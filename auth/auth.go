@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
 // AuthService handles authentication operations for Zalo Bot API
@@ -15,6 +17,12 @@ type AuthService struct {
 	httpClient   *http.Client
 	apiEndpoint  string
 	environment  types.Environment
+	logger       utils.Logger
+	credentials  types.CredentialProvider
+	userAgent    string
+	refreshMu    sync.Mutex
+	envMu        sync.RWMutex
+	configMu     sync.RWMutex
 }
 
 // NewAuthService creates a new authentication service
@@ -30,6 +38,9 @@ func NewAuthService(config *types.Config) (*AuthService, error) {
 		httpClient:   config.HTTPClient,
 		apiEndpoint:  config.BaseURL,
 		environment:  config.Environment,
+		logger:       config.Logger,
+		credentials:  config.Credentials,
+		userAgent:    config.UserAgent,
 	}, nil
 }
 
@@ -55,44 +66,58 @@ func (as *AuthService) ValidateCredentials(ctx context.Context) error {
 
 	// Bot token authentication doesn't use Authorization headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Go-Zalo-Bot-SDK/1.0")
+	req.Header.Set("User-Agent", as.userAgent)
 
 	// Add environment-specific headers if needed
-	if as.environment == types.Development {
+	if as.GetEnvironment() == types.Development {
 		req.Header.Set("X-Environment", "development")
 	}
 
-	resp, err := as.httpClient.Do(req)
+	resp, err := as.getHTTPClient().Do(req)
 	if err != nil {
 		return types.NewNetworkError(fmt.Sprintf("failed to make request: %v", err))
 	}
 	defer resp.Body.Close()
 
-	// Handle different response status codes
-	switch resp.StatusCode {
-	case http.StatusOK:
+	as.getLogger().Debug("Validated credentials",
+		utils.Field{Key: "url", Value: utils.RedactURL(url)},
+		utils.Field{Key: "status", Value: resp.StatusCode},
+	)
+
+	// Parse the standard ok/error_code/description envelope; the error code
+	// in the body takes precedence over the HTTP status
+	var apiResp struct {
+		OK          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code,omitempty"`
+		Description string `json:"description,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.OK {
 		return nil
+	}
+
+	code := apiResp.ErrorCode
+	if code == 0 {
+		code = resp.StatusCode
+	}
+
+	switch code {
 	case http.StatusUnauthorized:
-		return types.NewAuthError("invalid credentials or token")
+		return types.NewAuthError(descriptionOr(apiResp.Description, "invalid credentials or token"))
 	case http.StatusForbidden:
-		return types.NewAuthError("access forbidden - check token permissions")
+		return types.NewAuthError(descriptionOr(apiResp.Description, "access forbidden - check token permissions"))
 	case http.StatusTooManyRequests:
-		return types.NewRateLimitError("rate limit exceeded")
+		return types.NewRateLimitError(descriptionOr(apiResp.Description, "rate limit exceeded"))
 	default:
-		// Try to parse error response
-		var apiResp struct {
-			Error struct {
-				Code    int    `json:"code"`
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-
-		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.Error.Message != "" {
-			return types.NewAPIError(apiResp.Error.Code, apiResp.Error.Message, "credential validation failed")
-		}
+		return types.NewAPIError(code, descriptionOr(apiResp.Description, "credential validation failed"), fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+}
 
-		return types.NewAPIError(resp.StatusCode, "credential validation failed", fmt.Sprintf("HTTP %d", resp.StatusCode))
+// descriptionOr returns the API error description, or fallback if it is empty
+func descriptionOr(description, fallback string) string {
+	if description == "" {
+		return fallback
 	}
+	return description
 }
 
 // SetToken updates the authentication token
@@ -118,7 +143,7 @@ func (as *AuthService) GetAPIEndpoint(method string) string {
 
 // ValidateEnvironmentConfig validates environment-specific configuration
 func (as *AuthService) ValidateEnvironmentConfig() error {
-	switch as.environment {
+	switch as.GetEnvironment() {
 	case types.Development:
 		// In development, we might have more lenient validation
 		if as.apiEndpoint == "" {
@@ -128,7 +153,9 @@ func (as *AuthService) ValidateEnvironmentConfig() error {
 		// Check if using development API endpoint
 		if as.apiEndpoint == "https://bot-api.zapps.me" {
 			// This is the production endpoint, warn but don't fail
-			// In a real implementation, you might want to log a warning here
+			as.getLogger().Warn("Development environment is using the production API endpoint",
+				utils.Field{Key: "endpoint", Value: as.apiEndpoint},
+			)
 		}
 
 	case types.Production:
@@ -171,10 +198,10 @@ func (as *AuthService) CreateAuthenticatedRequest(ctx context.Context, httpMetho
 
 	// Bot token authentication doesn't use Authorization headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Go-Zalo-Bot-SDK/1.0")
+	req.Header.Set("User-Agent", as.userAgent)
 
 	// Add environment-specific headers
-	if as.environment == types.Development {
+	if as.GetEnvironment() == types.Development {
 		req.Header.Set("X-Environment", "development")
 	}
 
@@ -192,21 +219,86 @@ func (as *AuthService) HandleAuthError(err error) error {
 		return err
 	}
 
-	switch zaloBotErr.Type {
-	case types.ErrorTypeAuth:
-		// Clear the token if we get an auth error
-		as.tokenManager.Clear()
-		return zaloBotErr
-	case types.ErrorTypeRateLimit:
-		// Don't clear token for rate limit errors
-		return zaloBotErr
-	default:
-		return zaloBotErr
+	// The token is kept on auth errors: clearing it would leave the bot
+	// unable to make any further request. Use RefreshToken to fetch a
+	// rotated token from the credential provider instead.
+	if zaloBotErr.IsAuthError() {
+		as.getLogger().Warn("Bot token was rejected",
+			utils.Field{Key: "error", Value: zaloBotErr},
+		)
+	}
+	return zaloBotErr
+}
+
+// HasCredentialProvider returns true if the token can be re-fetched
+func (as *AuthService) HasCredentialProvider() bool {
+	return as.credentials != nil
+}
+
+// RefreshToken fetches the token from the credential provider and, if it
+// differs from the current one, installs it atomically. It returns true when
+// the token changed. Concurrent callers are serialized so that a burst of auth
+// failures results in one fetch at a time.
+func (as *AuthService) RefreshToken(ctx context.Context) (bool, error) {
+	if as.credentials == nil {
+		return false, types.NewAuthError("no credential provider configured")
+	}
+
+	as.refreshMu.Lock()
+	defer as.refreshMu.Unlock()
+
+	return as.refreshLocked(ctx)
+}
+
+// refreshLocked fetches and installs the token; refreshMu must be held
+func (as *AuthService) refreshLocked(ctx context.Context) (bool, error) {
+	token, err := as.credentials.Token(ctx)
+	if err != nil {
+		return false, err
+	}
+	if token == as.tokenManager.GetToken() {
+		return false, nil
+	}
+
+	if err := as.tokenManager.SetToken(token); err != nil {
+		return false, err
 	}
+
+	as.getLogger().Info("Bot token rotated from credential provider")
+	return true, nil
+}
+
+// RefreshAfterAuthError re-fetches the token once after err reported that
+// failedToken, the token the request was sent with, was rejected. It returns
+// true if the failed request should be retried: either a new token was
+// installed, or another caller has already replaced failedToken, so every
+// request in a burst of auth failures retries with the rotated token.
+func (as *AuthService) RefreshAfterAuthError(ctx context.Context, err error, failedToken string) bool {
+	if as.credentials == nil || !types.IsAuthError(err) {
+		return false
+	}
+
+	as.refreshMu.Lock()
+	defer as.refreshMu.Unlock()
+
+	if as.tokenManager.GetToken() != failedToken {
+		return true
+	}
+
+	changed, refreshErr := as.refreshLocked(ctx)
+	if refreshErr != nil {
+		as.getLogger().Warn("Failed to refresh bot token after authentication error",
+			utils.Field{Key: "error", Value: refreshErr},
+		)
+		return false
+	}
+	return changed
 }
 
 // GetEnvironment returns the current environment
 func (as *AuthService) GetEnvironment() types.Environment {
+	as.envMu.RLock()
+	defer as.envMu.RUnlock()
 	return as.environment
 }
 
@@ -216,6 +308,35 @@ func (as *AuthService) SetEnvironment(env types.Environment) error {
 		return types.NewValidationError("invalid environment")
 	}
 
+	as.envMu.Lock()
 	as.environment = env
+	as.envMu.Unlock()
+
 	return as.ValidateEnvironmentConfig()
 }
+
+// SetConfig applies the HTTP client and logger of a new configuration
+// snapshot, so credential checks honour a changed timeout
+func (as *AuthService) SetConfig(config *types.Config) {
+	as.configMu.Lock()
+	defer as.configMu.Unlock()
+	as.httpClient = config.HTTPClient
+	as.logger = config.Logger
+}
+
+// getHTTPClient returns the HTTP client credential checks are made with
+func (as *AuthService) getHTTPClient() *http.Client {
+	as.configMu.RLock()
+	defer as.configMu.RUnlock()
+	return as.httpClient
+}
+
+// getLogger returns the configured logger, or a no-op logger
+func (as *AuthService) getLogger() utils.Logger {
+	as.configMu.RLock()
+	defer as.configMu.RUnlock()
+	if as.logger == nil {
+		return utils.NewNoOpLogger()
+	}
+	return as.logger
+}
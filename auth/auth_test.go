@@ -4,10 +4,13 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
 func TestNewAuthService(t *testing.T) {
@@ -62,34 +65,41 @@ func TestAuthService_ValidateCredentials(t *testing.T) {
 		{
 			name:          "valid credentials",
 			statusCode:    http.StatusOK,
-			responseBody:  `{"data": {"id": "123", "name": "Test Bot"}}`,
+			responseBody:  `{"ok": true, "result": {"id": "123", "account_name": "bot.test"}}`,
 			expectedError: false,
 		},
+		{
+			name:            "rejected in envelope",
+			statusCode:      http.StatusOK,
+			responseBody:    `{"ok": false, "error_code": 401, "description": "Unauthorized"}`,
+			expectedError:   true,
+			expectedErrType: types.ErrorTypeAuth,
+		},
 		{
 			name:            "unauthorized",
 			statusCode:      http.StatusUnauthorized,
-			responseBody:    `{"error": {"code": 401, "message": "Unauthorized"}}`,
+			responseBody:    `{"ok": false, "error_code": 401, "description": "Unauthorized"}`,
 			expectedError:   true,
 			expectedErrType: types.ErrorTypeAuth,
 		},
 		{
 			name:            "forbidden",
 			statusCode:      http.StatusForbidden,
-			responseBody:    `{"error": {"code": 403, "message": "Forbidden"}}`,
+			responseBody:    `{"ok": false, "error_code": 403, "description": "Forbidden"}`,
 			expectedError:   true,
 			expectedErrType: types.ErrorTypeAuth,
 		},
 		{
 			name:            "rate limited",
 			statusCode:      http.StatusTooManyRequests,
-			responseBody:    `{"error": {"code": 429, "message": "Too Many Requests"}}`,
+			responseBody:    `{"ok": false, "error_code": 429, "description": "Too Many Requests"}`,
 			expectedError:   true,
 			expectedErrType: types.ErrorTypeRateLimit,
 		},
 		{
 			name:            "server error",
 			statusCode:      http.StatusInternalServerError,
-			responseBody:    `{"error": {"code": 500, "message": "Internal Server Error"}}`,
+			responseBody:    `{"ok": false, "error_code": 500, "description": "Internal Server Error"}`,
 			expectedError:   true,
 			expectedErrType: types.ErrorTypeAPI,
 		},
@@ -331,10 +341,10 @@ func TestAuthService_CreateAuthenticatedRequest(t *testing.T) {
 		t.Errorf("Expected content type application/json, got %s", contentType)
 	}
 
-	// Check User-Agent header
+	// Check User-Agent header is the SDK-wide one
 	userAgent := req.Header.Get("User-Agent")
-	if userAgent != "Go-Zalo-Bot-SDK/1.0" {
-		t.Errorf("Expected User-Agent Go-Zalo-Bot-SDK/1.0, got %s", userAgent)
+	if userAgent != utils.UserAgent("") {
+		t.Errorf("Expected User-Agent %s, got %s", utils.UserAgent(""), userAgent)
 	}
 
 	// Check environment header for development
@@ -455,7 +465,7 @@ func TestAuthService_HandleAuthError(t *testing.T) {
 		t.Fatalf("NewAuthService() error = %v", err)
 	}
 
-	// Test with auth error - should clear token
+	// Test with auth error - should keep token so the bot can recover
 	authErr := types.NewAuthError("invalid token")
 	handledErr := authService.HandleAuthError(authErr)
 
@@ -463,13 +473,10 @@ func TestAuthService_HandleAuthError(t *testing.T) {
 		t.Error("Expected same error to be returned")
 	}
 
-	if authService.GetToken() != "" {
-		t.Error("Expected token to be cleared after auth error")
+	if authService.GetToken() != config.BotToken {
+		t.Error("Expected token to be kept after auth error")
 	}
 
-	// Reset token for next test with valid bot token format
-	authService.SetToken("789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89")
-
 	// Test with rate limit error - should not clear token
 	rateLimitErr := types.NewRateLimitError("rate limited")
 	handledErr = authService.HandleAuthError(rateLimitErr)
@@ -512,3 +519,168 @@ func TestAuthService_SetEnvironment(t *testing.T) {
 		t.Error("Expected error for invalid environment")
 	}
 }
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	current := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	config := &types.Config{
+		BotToken:    current,
+		BaseURL:     "https://bot-api.zapps.me",
+		Environment: types.Production,
+		HTTPClient:  &http.Client{},
+		Credentials: CredentialFunc(func(ctx context.Context) (string, error) {
+			return current, nil
+		}),
+	}
+
+	authService, err := NewAuthService(config)
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+
+	// Unchanged token
+	changed, err := authService.RefreshToken(context.Background())
+	if err != nil || changed {
+		t.Errorf("RefreshToken() = %v, %v, want false, nil", changed, err)
+	}
+
+	// Rotated token
+	current = "789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89"
+	changed, err = authService.RefreshToken(context.Background())
+	if err != nil || !changed {
+		t.Errorf("RefreshToken() = %v, %v, want true, nil", changed, err)
+	}
+	if authService.GetToken() != current {
+		t.Errorf("Expected token %s, got %s", current, authService.GetToken())
+	}
+
+	// Invalid token from provider is rejected and the current one kept
+	current = "bad"
+	if _, err := authService.RefreshToken(context.Background()); err == nil {
+		t.Error("Expected error for invalid token from provider")
+	}
+	if authService.GetToken() != "789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89" {
+		t.Error("Expected token to be kept after failed refresh")
+	}
+}
+
+func TestAuthService_RefreshAfterAuthError(t *testing.T) {
+	oldToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	newToken := "789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89"
+	config := &types.Config{
+		BotToken:    oldToken,
+		BaseURL:     "https://bot-api.zapps.me",
+		Environment: types.Production,
+		HTTPClient:  &http.Client{},
+		Credentials: StaticCredentials(newToken),
+	}
+
+	authService, err := NewAuthService(config)
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+
+	if authService.RefreshAfterAuthError(context.Background(), types.NewNetworkError("timeout"), oldToken) {
+		t.Error("Expected no refresh for non-auth error")
+	}
+	if !authService.RefreshAfterAuthError(context.Background(), types.NewAPIError(401, "Unauthorized", ""), oldToken) {
+		t.Error("Expected refresh after 401")
+	}
+	// A request that failed with the old token retries with the one
+	// another caller already installed
+	if !authService.RefreshAfterAuthError(context.Background(), types.NewAuthError("invalid token"), oldToken) {
+		t.Error("Expected retry when the failed token was already replaced")
+	}
+	if authService.RefreshAfterAuthError(context.Background(), types.NewAuthError("invalid token"), newToken) {
+		t.Error("Expected no retry when provider returns the same token")
+	}
+
+	config.Credentials = nil
+	noProvider, err := NewAuthService(config)
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+	if noProvider.RefreshAfterAuthError(context.Background(), types.NewAuthError("invalid token"), oldToken) {
+		t.Error("Expected no refresh without a credential provider")
+	}
+}
+
+func TestAuthService_RefreshAfterAuthError_Concurrent(t *testing.T) {
+	var fetches atomic.Int32
+	oldToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	config := &types.Config{
+		BotToken:    oldToken,
+		BaseURL:     "https://bot-api.zapps.me",
+		Environment: types.Production,
+		HTTPClient:  &http.Client{},
+		Credentials: CredentialFunc(func(ctx context.Context) (string, error) {
+			fetches.Add(1)
+			return "789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89", nil
+		}),
+	}
+
+	authService, err := NewAuthService(config)
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	var retries atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if authService.RefreshAfterAuthError(context.Background(), types.NewAuthError("invalid token"), oldToken) {
+				retries.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if retries.Load() != callers {
+		t.Errorf("retries = %d, want %d", retries.Load(), callers)
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("credential fetches = %d, want 1", fetches.Load())
+	}
+}
+
+func TestAuthService_SetEnvironment_Concurrent(t *testing.T) {
+	config := &types.Config{
+		BotToken:    "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+		BaseURL:     "https://bot-api.zapps.me",
+		Environment: types.Production,
+		HTTPClient:  &http.Client{},
+		Logger:      utils.NewNoOpLogger(),
+	}
+
+	authService, err := NewAuthService(config)
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				env := types.Production
+				if (i+j)%2 == 0 {
+					env = types.Development
+				}
+				authService.SetEnvironment(env)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := authService.CreateAuthenticatedRequest(context.Background(), "GET", "getMe"); err != nil {
+					t.Errorf("CreateAuthenticatedRequest() error = %v", err)
+				}
+				authService.GetEnvironment()
+			}
+		}()
+	}
+	wg.Wait()
+}
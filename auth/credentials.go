@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+// StaticCredentials is a CredentialProvider that always returns one token
+type StaticCredentials string
+
+// Token returns the static token
+func (c StaticCredentials) Token(ctx context.Context) (string, error) {
+	if c == "" {
+		return "", types.NewAuthError("static bot token is empty")
+	}
+	return string(c), nil
+}
+
+// EnvCredentials is a CredentialProvider that reads the token from an
+// environment variable on every fetch
+type EnvCredentials struct {
+	Name string // Variable name (default: ZALO_BOT_TOKEN)
+}
+
+// NewEnvCredentials creates a provider reading the named environment variable
+func NewEnvCredentials(name string) *EnvCredentials {
+	return &EnvCredentials{Name: name}
+}
+
+// Token returns the current value of the environment variable
+func (c *EnvCredentials) Token(ctx context.Context) (string, error) {
+	name := c.Name
+	if name == "" {
+		name = types.EnvPrefix + "TOKEN"
+	}
+
+	token := strings.TrimSpace(os.Getenv(name))
+	if token == "" {
+		return "", types.NewAuthError(fmt.Sprintf("environment variable %s is not set", name))
+	}
+	return token, nil
+}
+
+// FileCredentials is a CredentialProvider that reads the token from a file,
+// such as a mounted secret. The file is re-read only when its size or
+// modification time changes.
+type FileCredentials struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	size    int64
+	modTime time.Time
+}
+
+// NewFileCredentials creates a provider reading the token from path
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{path: path}
+}
+
+// Token returns the token in the file, re-reading it if it changed
+func (c *FileCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return "", types.NewAuthError(fmt.Sprintf("failed to read bot token file: %v", err))
+	}
+
+	if c.token != "" && info.Size() == c.size && info.ModTime().Equal(c.modTime) {
+		return c.token, nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return "", types.NewAuthError(fmt.Sprintf("failed to read bot token file: %v", err))
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", types.NewAuthError("bot token file is empty")
+	}
+
+	c.token = token
+	c.size = info.Size()
+	c.modTime = info.ModTime()
+	return token, nil
+}
+
+// CredentialFunc adapts a function to a CredentialProvider, for tokens kept
+// in a secrets manager or fetched from another service
+type CredentialFunc func(ctx context.Context) (string, error)
+
+// Token calls the function
+func (f CredentialFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+func TestStaticCredentials(t *testing.T) {
+	token, err := StaticCredentials("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11").Token(context.Background())
+	if err != nil || token != "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11" {
+		t.Errorf("Token() = %q, %v", token, err)
+	}
+
+	if _, err := StaticCredentials("").Token(context.Background()); err == nil {
+		t.Error("Expected error for empty static token")
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv(types.EnvPrefix+"TOKEN", " 123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11\n")
+	t.Setenv("CUSTOM_BOT_TOKEN", "")
+
+	token, err := NewEnvCredentials("").Token(context.Background())
+	if err != nil || token != "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11" {
+		t.Errorf("Token() = %q, %v", token, err)
+	}
+
+	if _, err := NewEnvCredentials("CUSTOM_BOT_TOKEN").Token(context.Background()); err == nil {
+		t.Error("Expected error for unset variable")
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewFileCredentials(path)
+	token, err := provider.Token(context.Background())
+	if err != nil || token != "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+
+	// Rewrite the file with a new token and a later modification time
+	if err := os.WriteFile(path, []byte("789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = provider.Token(context.Background())
+	if err != nil || token != "789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89" {
+		t.Errorf("Token() after rewrite = %q, %v", token, err)
+	}
+
+	if _, err := NewFileCredentials(filepath.Join(t.TempDir(), "missing")).Token(context.Background()); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
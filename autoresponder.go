@@ -0,0 +1,469 @@
+package zalobot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMinMatchScore is the similarity a fuzzy phrase needs to match
+const DefaultMinMatchScore = 0.8
+
+// DefaultWatchInterval is the time between file checks used by Watch when
+// its interval is not positive
+const DefaultWatchInterval = 5 * time.Second
+
+// Most cooldown entries kept before expired ones are swept
+const maxCooldownEntries = 1024
+
+// AutoResponderConfig configures an AutoResponder
+type AutoResponderConfig struct {
+	// Templates renders rules that reply with a template. It is required
+	// if any rule does.
+	Templates *TemplateRegistry
+	// Fallback handles messages no rule answers. Without one they are
+	// ignored.
+	Fallback ContextHandler
+	// MinScore is the similarity, from 0 to 1, a fuzzy phrase needs to
+	// match unless its rule sets one (default: DefaultMinMatchScore)
+	MinScore float64
+}
+
+// AutoResponseRule is one rule of an auto-responder file. A message matches
+// the rule through any of its keywords, patterns or phrases; the rule
+// replies with exactly one of text, template, message_template or message.
+type AutoResponseRule struct {
+	// Name identifies the rule in cooldowns and matches (default: rule-N)
+	Name string `yaml:"name"`
+	// Keywords match when they occur as whole words, ignoring case,
+	// accents and punctuation
+	Keywords []string `yaml:"keywords"`
+	// Patterns are regular expressions matched against the text as typed
+	// and normalized
+	Patterns []string `yaml:"patterns"`
+	// Phrases match fuzzily, tolerating typos and extra words
+	Phrases []string `yaml:"phrases"`
+	// MinScore overrides AutoResponderConfig.MinScore for the phrases
+	MinScore float64 `yaml:"min_score"`
+	// Cooldown is how long the rule stays silent in a chat after replying
+	// there; later messages fall through to other rules
+	Cooldown time.Duration `yaml:"cooldown"`
+
+	// Text is sent as is
+	Text string `yaml:"text"`
+	// Template names a text template of AutoResponderConfig.Templates,
+	// rendered with the Context as data
+	Template string `yaml:"template"`
+	// MessageTemplate names a StructuredMessage template, rendered with the
+	// Context as data
+	MessageTemplate string `yaml:"message_template"`
+	// Message is a StructuredMessage with the field names of the API
+	Message interface{} `yaml:"message"`
+}
+
+// autoResponseFile is the layout of an auto-responder file
+type autoResponseFile struct {
+	MinScore float64            `yaml:"min_score"`
+	Rules    []AutoResponseRule `yaml:"rules"`
+}
+
+// autoRule is a loaded rule, ready for matching
+type autoRule struct {
+	AutoResponseRule
+
+	keywords []string
+	phrases  []string
+	patterns []*regexp.Regexp
+	minScore float64
+	message  *types.StructuredMessage
+}
+
+// AutoResponseMatch is a rule that matched a message
+type AutoResponseMatch struct {
+	// Rule is the name of the rule
+	Rule string
+	// Score is how well the message matched, from 0 to 1; keywords and
+	// patterns score 1
+	Score float64
+}
+
+// AutoResponder answers frequent questions from rules loaded from YAML:
+//
+//	min_score: 0.8
+//	rules:
+//	  - name: opening_hours
+//	    keywords: [giờ mở cửa]
+//	    phrases: [cửa hàng mở cửa lúc mấy giờ]
+//	    cooldown: 10m
+//	    text: Cửa hàng mở cửa từ 8:00 đến 21:00 hằng ngày.
+//	  - name: shipping
+//	    patterns: ['(?i)\bship(ping)?\b']
+//	    template: shipping_fee
+//
+// Rules can be reloaded while the bot is running.
+type AutoResponder struct {
+	bot    *BotAPI
+	config AutoResponderConfig
+
+	mu      sync.RWMutex
+	rules   []*autoRule
+	path    string
+	modTime time.Time
+
+	cooldownMu sync.Mutex
+	cooldowns  map[string]time.Time // end of cooldown by rule and chat
+	now        func() time.Time
+}
+
+// NewAutoResponder creates an AutoResponder without rules
+func NewAutoResponder(bot *BotAPI, config AutoResponderConfig) *AutoResponder {
+	if config.MinScore <= 0 {
+		config.MinScore = DefaultMinMatchScore
+	}
+	return &AutoResponder{
+		bot:       bot,
+		config:    config,
+		cooldowns: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// Load replaces the rules with those in data; empty data removes them. If
+// any rule is invalid the current rules are kept. Cooldowns carry over to
+// rules with the same name.
+func (a *AutoResponder) Load(data []byte) error {
+	var file autoResponseFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return fmt.Errorf("auto-responder rules: %w", err)
+	}
+
+	minScore := a.config.MinScore
+	if file.MinScore != 0 {
+		minScore = file.MinScore
+	}
+	rules, err := a.compile(file.Rules, minScore)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.rules = rules
+	a.mu.Unlock()
+	return nil
+}
+
+// LoadFile loads rules from the YAML file at path, which Reload and Watch
+// read again later
+func (a *AutoResponder) LoadFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := a.Load(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	a.mu.Lock()
+	a.path = path
+	a.modTime = info.ModTime()
+	a.mu.Unlock()
+	return nil
+}
+
+// Reload reads the file given to LoadFile again
+func (a *AutoResponder) Reload() error {
+	a.mu.RLock()
+	path := a.path
+	a.mu.RUnlock()
+
+	if path == "" {
+		return types.NewValidationError("no auto-responder file loaded")
+	}
+	return a.LoadFile(path)
+}
+
+// Watch reloads the file given to LoadFile whenever it changes, checking
+// every interval (DefaultWatchInterval if interval <= 0) until ctx is
+// cancelled. Failed reloads are logged and keep the current rules.
+func (a *AutoResponder) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		a.mu.RLock()
+		path, modTime := a.path, a.modTime
+		a.mu.RUnlock()
+		if path == "" {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err == nil && info.ModTime().Equal(modTime) {
+			continue
+		}
+		if err == nil {
+			err = a.LoadFile(path)
+		}
+		if err != nil {
+			a.bot.logger().Error("Failed to reload auto-responder rules",
+				utils.Field{Key: "path", Value: path},
+				utils.Field{Key: "error", Value: err},
+			)
+			continue
+		}
+		a.bot.logger().Info("Reloaded auto-responder rules", utils.Field{Key: "path", Value: path})
+	}
+}
+
+// compile validates rules and prepares them for matching
+func (a *AutoResponder) compile(rules []AutoResponseRule, minScore float64) ([]*autoRule, error) {
+	compiled := make([]*autoRule, 0, len(rules))
+	names := make(map[string]bool)
+
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("auto-responder rule %s: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+
+		r := &autoRule{
+			AutoResponseRule: rule,
+			keywords:         matchKeys(rule.Keywords),
+			phrases:          matchKeys(rule.Phrases),
+			minScore:         minScore,
+		}
+		if rule.MinScore != 0 {
+			r.minScore = rule.MinScore
+		}
+		if err := a.validate(r); err != nil {
+			return nil, fmt.Errorf("auto-responder rule %s: %w", rule.Name, err)
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// validate checks a rule and compiles its patterns and message
+func (a *AutoResponder) validate(r *autoRule) error {
+	if r.minScore <= 0 || r.minScore > 1 {
+		return fmt.Errorf("min_score must be between 0 and 1")
+	}
+	if r.Cooldown < 0 {
+		return fmt.Errorf("cooldown must not be negative")
+	}
+	for _, pattern := range r.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	if len(r.keywords)+len(r.phrases)+len(r.patterns) == 0 {
+		return fmt.Errorf("no keywords, patterns or phrases")
+	}
+
+	replies := 0
+	for _, set := range []bool{r.Text != "", r.Template != "", r.MessageTemplate != "", r.Message != nil} {
+		if set {
+			replies++
+		}
+	}
+	if replies != 1 {
+		return fmt.Errorf("needs exactly one of text, template, message_template or message")
+	}
+	if (r.Template != "" || r.MessageTemplate != "") && a.config.Templates == nil {
+		return fmt.Errorf("replies with a template but no TemplateRegistry is configured")
+	}
+	if r.Message != nil {
+		message, err := decodeStructuredMessage(r.Message)
+		if err != nil {
+			return err
+		}
+		if err := message.Validate(); err != nil {
+			return err
+		}
+		r.message = &message
+	}
+	return nil
+}
+
+// Match returns the rules text matches, best first, regardless of cooldowns
+func (a *AutoResponder) Match(text string) []AutoResponseMatch {
+	a.mu.RLock()
+	rules := a.rules
+	a.mu.RUnlock()
+
+	forms := matchForms(text)
+	var matches []AutoResponseMatch
+	for _, rule := range rules {
+		if score := rule.score(text, forms); score > 0 {
+			matches = append(matches, AutoResponseMatch{Rule: rule.Name, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// score returns how well the rule matches text, or 0
+func (r *autoRule) score(text string, forms []string) float64 {
+	for _, form := range forms {
+		for _, key := range r.keywords {
+			if containsPhrase(form, key) {
+				return 1
+			}
+		}
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(text) {
+			return 1
+		}
+		for _, form := range forms {
+			if re.MatchString(form) {
+				return 1
+			}
+		}
+	}
+
+	best := 0.0
+	for _, form := range forms {
+		for _, phrase := range r.phrases {
+			if score := utils.PhraseSimilarity(form, phrase); score > best {
+				best = score
+			}
+		}
+	}
+	if best < r.minScore {
+		return 0
+	}
+	return best
+}
+
+// Respond replies to the message in c with the best matching rule that is
+// not cooling down in its chat. It reports whether a rule replied.
+func (a *AutoResponder) Respond(c *Context) (bool, error) {
+	text := c.Text()
+	chatID := c.ChatID()
+	if text == "" || chatID == "" {
+		return false, nil
+	}
+
+	for _, match := range a.Match(text) {
+		rule := a.rule(match.Rule)
+		if rule == nil || !a.acquire(rule, chatID) {
+			continue
+		}
+		if err := a.reply(c, rule); err != nil {
+			a.release(rule, chatID)
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// Handle is a ContextHandler that responds to the message in c, or passes
+// it to the fallback handler if no rule replies
+func (a *AutoResponder) Handle(c *Context) error {
+	replied, err := a.Respond(c)
+	if err != nil || replied {
+		return err
+	}
+	if a.config.Fallback != nil {
+		return a.config.Fallback(c)
+	}
+	return nil
+}
+
+// rule returns the loaded rule with name, or nil after a reload removed it
+func (a *AutoResponder) rule(name string) *autoRule {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, rule := range a.rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// reply sends the reply of rule
+func (a *AutoResponder) reply(c *Context, rule *autoRule) error {
+	var err error
+	switch {
+	case rule.Text != "":
+		_, err = c.Reply(rule.Text)
+	case rule.Template != "":
+		_, err = a.config.Templates.Reply(c, rule.Template, c)
+	case rule.MessageTemplate != "":
+		_, err = a.config.Templates.ReplyMessage(c, rule.MessageTemplate, c)
+	default:
+		_, err = c.ReplyTemplate(*rule.message)
+	}
+	return err
+}
+
+// acquire starts the cooldown of rule in chatID, reporting false if it is
+// still cooling down from an earlier reply
+func (a *AutoResponder) acquire(rule *autoRule, chatID string) bool {
+	if rule.Cooldown <= 0 {
+		return true
+	}
+
+	a.cooldownMu.Lock()
+	defer a.cooldownMu.Unlock()
+
+	now := a.now()
+	key := rule.Name + "\x00" + chatID
+	if until, ok := a.cooldowns[key]; ok && now.Before(until) {
+		return false
+	}
+	if len(a.cooldowns) >= maxCooldownEntries {
+		for k, until := range a.cooldowns {
+			if !now.Before(until) {
+				delete(a.cooldowns, k)
+			}
+		}
+	}
+	a.cooldowns[key] = now.Add(rule.Cooldown)
+	return true
+}
+
+// release ends the cooldown of a reply that failed to send
+func (a *AutoResponder) release(rule *autoRule, chatID string) {
+	a.cooldownMu.Lock()
+	defer a.cooldownMu.Unlock()
+
+	delete(a.cooldowns, rule.Name+"\x00"+chatID)
+}
@@ -0,0 +1,247 @@
+package zalobot
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+const testAutoResponderRules = `
+min_score: 0.8
+rules:
+  - name: opening_hours
+    keywords: [giờ mở cửa]
+    phrases: [cửa hàng mở cửa lúc mấy giờ]
+    cooldown: 10m
+    text: Cửa hàng mở cửa từ 8:00 đến 21:00.
+  - name: shipping
+    patterns: ['(?i)\bship(ping)?\b', 'phi giao hang']
+    template: shipping
+  - name: menu
+    keywords: [thực đơn, menu]
+    message:
+      type: template
+      elements:
+        - title: Thực đơn hôm nay
+      quick_replies:
+        - content_type: text
+          title: Xem thêm
+          payload: MENU_MORE
+  - name: address
+    phrases: [địa chỉ cửa hàng ở đâu]
+    min_score: 0.9
+    text: 12 Lý Thái Tổ, Hà Nội.
+`
+
+func newTestAutoResponder(t *testing.T, config AutoResponderConfig) (*AutoResponder, *fakeReplyServer) {
+	t.Helper()
+
+	fake := &fakeReplyServer{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { bot.Close() })
+
+	if config.Templates == nil {
+		config.Templates = NewTemplateRegistry(TemplateConfig{})
+		if err := config.Templates.Add("vi", "shipping", "Phí giao hàng cho {{.Text}}: {{vnd 30000}}"); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	responder := NewAutoResponder(bot, config)
+	if err := responder.Load([]byte(testAutoResponderRules)); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return responder, fake
+}
+
+func TestAutoResponder_Match(t *testing.T) {
+	responder, _ := newTestAutoResponder(t, AutoResponderConfig{})
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Giờ mở cửa?", "opening_hours"},
+		{"cho hoi gio mo cua", "opening_hours"},
+		{"cho hoir giowf mowr cuwar", "opening_hours"},
+		{"Cửa hàng mở cửa lúc mấy giờ vậy ạ", "opening_hours"},
+		{"cua hag mo cua luc may gio", "opening_hours"},
+		{"Có SHIP không?", "shipping"},
+		{"Phí giao hàng bao nhiêu", "shipping"},
+		{"xem thuc don", "menu"},
+		{"địa chỉ cửa hàng ở đâu", "address"},
+		{"dia chi cua hang o dau", "address"},
+		{"shipper", ""},
+		{"địa chỉ ở đâu", ""},
+		{"xin chào", ""},
+	}
+
+	for _, tt := range tests {
+		matches := responder.Match(tt.text)
+		got := ""
+		if len(matches) > 0 {
+			got = matches[0].Rule
+		}
+		if got != tt.want {
+			t.Errorf("Match(%q) = %v, want %q", tt.text, matches, tt.want)
+		}
+	}
+}
+
+func TestAutoResponder_Replies(t *testing.T) {
+	responder, fake := newTestAutoResponder(t, AutoResponderConfig{})
+	handler := responder.bot.Handler(responder.Handle)
+
+	for _, text := range []string{"giờ mở cửa", "ship không", "menu"} {
+		if err := handler(context.Background(), textUpdate(text)); err != nil {
+			t.Fatalf("Handle(%q) error = %v", text, err)
+		}
+	}
+
+	want := []string{"sendMessage", "sendMessage", "sendTemplate"}
+	if strings.Join(fake.calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("calls = %v, want %v", fake.calls, want)
+	}
+	if text := fake.bodies[0]["text"]; text != "Cửa hàng mở cửa từ 8:00 đến 21:00." {
+		t.Errorf("text reply = %v", text)
+	}
+	if text := fake.bodies[1]["text"]; text != "Phí giao hàng cho ship không: 30.000 ₫" {
+		t.Errorf("template reply = %v", text)
+	}
+}
+
+func TestAutoResponder_Cooldown(t *testing.T) {
+	var fallbacks []string
+	responder, fake := newTestAutoResponder(t, AutoResponderConfig{
+		Fallback: func(c *Context) error {
+			fallbacks = append(fallbacks, c.ChatID())
+			return nil
+		},
+	})
+	now := time.Date(2024, 9, 2, 9, 0, 0, 0, time.UTC)
+	responder.now = func() time.Time { return now }
+
+	send := func(chatID string) {
+		t.Helper()
+		update := textUpdate("giờ mở cửa")
+		update.Message.Chat.ID = chatID
+		if err := responder.bot.Handler(responder.Handle)(context.Background(), update); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	send("chat-1")
+	send("chat-1")
+	send("chat-2")
+	now = now.Add(11 * time.Minute)
+	send("chat-1")
+
+	if len(fake.calls) != 3 {
+		t.Errorf("replies = %d, want 3", len(fake.calls))
+	}
+	if len(fallbacks) != 1 || fallbacks[0] != "chat-1" {
+		t.Errorf("fallbacks = %v, want [chat-1]", fallbacks)
+	}
+}
+
+func TestAutoResponder_LoadErrors(t *testing.T) {
+	responder, _ := newTestAutoResponder(t, AutoResponderConfig{})
+
+	tests := map[string]string{
+		"duplicate name":    "rules:\n  - {name: a, keywords: [x], text: a}\n  - {name: a, keywords: [y], text: b}\n",
+		"no matchers":       "rules:\n  - {name: a, text: a}\n",
+		"two replies":       "rules:\n  - {name: a, keywords: [x], text: a, template: b}\n",
+		"no reply":          "rules:\n  - {name: a, keywords: [x]}\n",
+		"bad pattern":       "rules:\n  - {name: a, patterns: ['('], text: a}\n",
+		"bad score":         "rules:\n  - {name: a, phrases: [x], min_score: 2, text: a}\n",
+		"bad message":       "rules:\n  - {name: a, keywords: [x], message: {type: bogus}}\n",
+		"unknown field":     "rules:\n  - {name: a, keyword: [x], text: a}\n",
+		"invalid yaml":      "rules: [",
+		"negative cooldown": "rules:\n  - {name: a, keywords: [x], cooldown: -1m, text: a}\n",
+	}
+
+	for name, data := range tests {
+		if err := responder.Load([]byte(data)); err == nil {
+			t.Errorf("%s: Load() error = nil", name)
+		}
+	}
+	if matches := responder.Match("menu"); len(matches) == 0 {
+		t.Error("failed loads replaced the rules")
+	}
+
+	noTemplates := NewAutoResponder(responder.bot, AutoResponderConfig{})
+	if err := noTemplates.Load([]byte("rules:\n  - {name: a, keywords: [x], template: t}\n")); err == nil {
+		t.Error("Load() with a template reply and no registry error = nil")
+	}
+	if err := noTemplates.Reload(); err == nil {
+		t.Error("Reload() without a file error = nil")
+	}
+}
+
+func TestAutoResponder_Watch(t *testing.T) {
+	responder, _ := newTestAutoResponder(t, AutoResponderConfig{})
+
+	path := filepath.Join(t.TempDir(), "faq.yaml")
+	if err := os.WriteFile(path, []byte("rules:\n  - {name: hello, keywords: [xin chào], text: Chào bạn}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := responder.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if matches := responder.Match("menu"); len(matches) != 0 {
+		t.Fatalf("Match(menu) = %v after loading file", matches)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go responder.Watch(ctx, 10*time.Millisecond)
+
+	updated := "rules:\n  - {name: bye, keywords: [tạm biệt], text: Hẹn gặp lại}\n"
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Make the change visible on filesystems with coarse timestamps
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(responder.Match("tam biet")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("rules were not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if matches := responder.Match("xin chào"); len(matches) != 0 {
+		t.Errorf("Match(xin chào) = %v after reload", matches)
+	}
+}
+
+func TestAutoResponder_WatchDefaultInterval(t *testing.T) {
+	responder, _ := newTestAutoResponder(t, AutoResponderConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		responder.Watch(ctx, 0)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Watch() did not return after cancel")
+	}
+}
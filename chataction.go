@@ -0,0 +1,147 @@
+package zalobot
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// DefaultChatActionInterval is how often WithChatAction re-sends the chat
+// action. Clients stop showing an action after about five seconds.
+const DefaultChatActionInterval = 4 * time.Second
+
+// Bounds of TypingDelay
+const (
+	typingDelayPerChar = 50 * time.Millisecond
+	minTypingDelay     = 500 * time.Millisecond
+	maxTypingDelay     = 6 * time.Second
+)
+
+// chatActionOptions holds the settings of one WithChatAction call
+type chatActionOptions struct {
+	interval time.Duration
+	reply    *string
+}
+
+// ChatActionOption configures WithChatAction
+type ChatActionOption func(*chatActionOptions)
+
+// ActionInterval sets how often the chat action is re-sent (default:
+// DefaultChatActionInterval)
+func ActionInterval(interval time.Duration) ChatActionOption {
+	return func(o *chatActionOptions) { o.interval = interval }
+}
+
+// HumanDelay keeps the chat action showing after fn returns until
+// TypingDelay(*reply) has passed since it started, so a reply sent next
+// looks typed by a person. fn may set *reply to the text it prepared.
+func HumanDelay(reply *string) ChatActionOption {
+	return func(o *chatActionOptions) { o.reply = reply }
+}
+
+// TypingDelay returns roughly how long a person takes to type text, between
+// half a second and six seconds
+func TypingDelay(text string) time.Duration {
+	delay := time.Duration(utf8.RuneCountInString(text)) * typingDelayPerChar
+	if delay < minTypingDelay {
+		return minTypingDelay
+	}
+	if delay > maxTypingDelay {
+		return maxTypingDelay
+	}
+	return delay
+}
+
+// SendChatAction shows a chat action such as typing to the recipient
+// Delegates to the message service
+func (b *BotAPI) SendChatAction(chatID string, action types.ChatActionType) error {
+	return b.SendChatActionWithContext(b.ctx, chatID, action)
+}
+
+// SendChatActionWithContext is like SendChatAction but uses ctx for cancellation and tracing
+func (b *BotAPI) SendChatActionWithContext(ctx context.Context, chatID string, action types.ChatActionType) error {
+	return b.messageService.SendChatAction(ctx, chatID, action)
+}
+
+// WithChatAction shows action in chatID while fn runs. The action is sent
+// right away and re-sent on an interval until fn returns or ctx is
+// cancelled; failures to send it are logged and do not affect fn. It
+// returns the error of fn, or ctx.Err() if ctx is cancelled during a
+// HumanDelay.
+func (b *BotAPI) WithChatAction(ctx context.Context, chatID string, action types.ChatActionType, fn func(ctx context.Context) error, options ...ChatActionOption) error {
+	opts := chatActionOptions{interval: DefaultChatActionInterval}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.interval <= 0 {
+		opts.interval = DefaultChatActionInterval
+	}
+	if !action.IsValid() {
+		return types.NewValidationError("Invalid chat action type")
+	}
+
+	actionCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.refreshChatAction(actionCtx, chatID, action, opts.interval)
+	}()
+	// No action may be sent after returning, or it would show after the reply
+	defer func() {
+		stop()
+		<-done
+	}()
+
+	start := time.Now()
+	if err := fn(ctx); err != nil {
+		return err
+	}
+
+	if opts.reply != nil {
+		if wait := TypingDelay(*opts.reply) - time.Since(start); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return nil
+}
+
+// refreshChatAction sends action every interval until ctx is cancelled
+func (b *BotAPI) refreshChatAction(ctx context.Context, chatID string, action types.ChatActionType, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.SendChatActionWithContext(ctx, chatID, action); err != nil && ctx.Err() == nil {
+			b.logger().Debug("Failed to send chat action",
+				utils.Field{Key: "chat_id", Value: chatID},
+				utils.Field{Key: "action", Value: action},
+				utils.Field{Key: "error", Value: err},
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WithTyping shows the typing indicator in the chat the update came from
+// while fn runs, as WithChatAction
+func (c *Context) WithTyping(fn func(ctx context.Context) error, options ...ChatActionOption) error {
+	chatID, err := c.chatID()
+	if err != nil {
+		return err
+	}
+	return c.Bot.WithChatAction(c, chatID, types.ChatActionTyping, fn, options...)
+}
@@ -0,0 +1,117 @@
+package zalobot
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+func (f *fakeReplyServer) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, call := range f.calls {
+		if call == method {
+			n++
+		}
+	}
+	return n
+}
+
+func TestBotAPI_WithChatAction(t *testing.T) {
+	fake := &fakeReplyServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	t.Run("refreshes until fn returns", func(t *testing.T) {
+		errWork := errors.New("work failed")
+		err := bot.WithChatAction(context.Background(), "chat-1", types.ChatActionTyping, func(ctx context.Context) error {
+			time.Sleep(110 * time.Millisecond)
+			return errWork
+		}, ActionInterval(20*time.Millisecond))
+		if err != errWork {
+			t.Errorf("WithChatAction() error = %v, want fn error", err)
+		}
+
+		sent := fake.count("sendChatAction")
+		if sent < 4 {
+			t.Errorf("chat action sent %d times, want at least 4", sent)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if after := fake.count("sendChatAction"); after != sent {
+			t.Errorf("chat action sent %d more times after fn returned", after-sent)
+		}
+	})
+
+	t.Run("stops on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(30*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := bot.WithChatAction(ctx, "chat-1", types.ChatActionUploadDocument, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if err != context.Canceled {
+			t.Errorf("WithChatAction() error = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("WithChatAction() returned after %s, want right after cancellation", elapsed)
+		}
+	})
+
+	t.Run("human delay", func(t *testing.T) {
+		var reply string
+		start := time.Now()
+		err := bot.WithChatAction(context.Background(), "chat-1", types.ChatActionTyping, func(ctx context.Context) error {
+			reply = "ok"
+			return nil
+		}, HumanDelay(&reply))
+		if err != nil {
+			t.Fatalf("WithChatAction() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < TypingDelay(reply) {
+			t.Errorf("WithChatAction() returned after %s, want at least %s", elapsed, TypingDelay(reply))
+		}
+	})
+
+	t.Run("invalid action", func(t *testing.T) {
+		called := false
+		err := bot.WithChatAction(context.Background(), "chat-1", types.ChatActionType("dancing"), func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+		if err == nil || called {
+			t.Errorf("WithChatAction() error = %v, fn called = %v, want validation error without running fn", err, called)
+		}
+	})
+}
+
+func TestTypingDelay(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+	}{
+		{"", 500 * time.Millisecond},
+		{strings.Repeat("a", 20), time.Second},
+		{strings.Repeat("đ", 40), 2 * time.Second},
+		{strings.Repeat("a", 1000), 6 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := TypingDelay(tt.text); got != tt.want {
+			t.Errorf("TypingDelay(%d chars) = %s, want %s", len([]rune(tt.text)), got, tt.want)
+		}
+	}
+}
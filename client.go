@@ -113,8 +113,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -122,12 +126,16 @@ import (
 	"github.com/vkhangstack/go-zalo-bot/auth"
 	"github.com/vkhangstack/go-zalo-bot/services"
 	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
 // BotAPI represents the main Zalo Bot API client
 type BotAPI struct {
 	// Configuration
 	config *types.Config
+	// ownLogger is set when config.Logger is the SDK's default logger
+	// rather than one passed with WithLogger
+	ownLogger bool
 
 	// Core components
 	client      *http.Client
@@ -139,7 +147,24 @@ type BotAPI struct {
 	webhookService *services.WebhookService
 
 	// Internal state
-	mu sync.RWMutex
+	mu            sync.RWMutex
+	reconfigureMu sync.Mutex
+	stats         *services.RequestStats
+	state         runtimeState
+
+	// Cached getMe result
+	meMu        sync.Mutex
+	me          *types.BotInfo
+	meFetchedAt time.Time
+
+	// Running webhook watchdog, nil if none
+	watchdog *WebhookWatchdog
+
+	// Update deduplication, nil if disabled
+	dedup *updateDeduplicator
+
+	// Session store for handler contexts, nil if sessions are disabled
+	sessions types.SessionStore
 
 	// Lifecycle
 	ctx    context.Context
@@ -149,8 +174,11 @@ type BotAPI struct {
 	isPolling     bool
 	pollingMu     sync.RWMutex
 	stopPollingCh chan struct{}
-	updatesChan   chan types.Update
 	pollingWg     sync.WaitGroup
+
+	// Consumers of polled updates; primary is the one returned by GetUpdatesChan
+	subscriptions []*UpdateSubscription
+	primary       *UpdateSubscription
 }
 
 // New creates a new BotAPI instance with bot token authentication
@@ -170,7 +198,17 @@ func New(botToken string, options ...types.BotOption) (*BotAPI, error) {
 		opt(config)
 	}
 
+	// Fetch the initial token from the credential provider if none was given
+	if config.BotToken == "" && config.Credentials != nil {
+		token, err := config.Credentials.Token(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		config.BotToken = token
+	}
+
 	// Validate config (includes bot token validation)
+	ownLogger := config.Logger == nil
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
@@ -185,19 +223,34 @@ func New(botToken string, options ...types.BotOption) (*BotAPI, error) {
 
 	bot := &BotAPI{
 		config:      config,
+		ownLogger:   ownLogger,
 		client:      config.HTTPClient,
 		authService: authService,
+		stats:       services.NewRequestStats(),
+		dedup:       newUpdateDeduplicator(config.Dedup),
 		ctx:         ctx,
 		cancel:      cancel,
 	}
 
+	if config.Sessions != nil {
+		bot.sessions = config.Sessions.Store
+		if bot.sessions == nil {
+			bot.sessions = utils.NewMemorySessionStore()
+		}
+	}
+
 	// Initialize services
 	bot.messageService = services.NewMessageService(authService, config.HTTPClient, config)
 	bot.userService = services.NewUserService(authService, config.HTTPClient, config)
 
-	// Initialize webhook service with empty secret token (can be set later)
+	// Initialize webhook service with the configured secret token (can be set later)
 	baseService := services.NewBaseService(authService, config.HTTPClient, config)
-	bot.webhookService = services.NewWebhookService(baseService, "")
+	bot.webhookService = services.NewWebhookService(baseService, config.WebhookSecret)
+
+	// Share one request stats tracker so health checks see every service
+	bot.messageService.SetRequestStats(bot.stats)
+	bot.userService.SetRequestStats(bot.stats)
+	bot.webhookService.SetRequestStats(bot.stats)
 
 	return bot, nil
 }
@@ -207,14 +260,49 @@ func (b *BotAPI) GetBotToken() string {
 	return b.authService.GetToken()
 }
 
+// RotateToken atomically replaces the bot token. Requests already in flight
+// finish with the old token; every later request uses the new one.
+func (b *BotAPI) RotateToken(token string) error {
+	if err := b.authService.SetToken(token); err != nil {
+		return err
+	}
+	b.forgetMe()
+	b.logger().Info("Bot token rotated")
+	return nil
+}
+
+// RefreshCredentials fetches the token from the configured credential provider
+// and installs it if it changed
+func (b *BotAPI) RefreshCredentials(ctx context.Context) error {
+	_, err := b.authService.RefreshToken(ctx)
+	return err
+}
+
 // GetAPIEndpoint returns the full API endpoint URL for a specific method
 // Pattern: https://bot-api.zapps.me/bot${BOT_TOKEN}/method
 func (b *BotAPI) GetAPIEndpoint(method string) string {
 	return b.authService.GetAPIEndpoint(method)
 }
 
-// GetConfig returns the bot configuration
+// GetConfig returns a copy of the bot configuration. Changing it has no
+// effect on the bot; use Reconfigure for runtime changes.
 func (b *BotAPI) GetConfig() *types.Config {
+	config := *b.currentConfig()
+	if config.RetryConfig != nil {
+		retryConfig := *config.RetryConfig
+		retryConfig.RetryableErrors = append([]types.ErrorType(nil), retryConfig.RetryableErrors...)
+		config.RetryConfig = &retryConfig
+	}
+	if config.Health != nil {
+		health := *config.Health
+		config.Health = &health
+	}
+	return &config
+}
+
+// currentConfig returns the configuration snapshot shared with the
+// services; it must not be modified
+func (b *BotAPI) currentConfig() *types.Config {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	return b.config
@@ -227,6 +315,8 @@ func (b *BotAPI) GetAuthService() *auth.AuthService {
 
 // GetHTTPClient returns the HTTP client
 func (b *BotAPI) GetHTTPClient() *http.Client {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 	return b.client
 }
 
@@ -261,6 +351,7 @@ func (b *BotAPI) SetWebhookSecretToken(token string) {
 func (b *BotAPI) Close() {
 	// Stop polling if active
 	b.StopPolling()
+	b.stopUpdates()
 
 	if b.cancel != nil {
 		b.cancel()
@@ -270,37 +361,57 @@ func (b *BotAPI) Close() {
 // SendMessage sends a text message to a chat
 // Delegates to the message service
 func (b *BotAPI) SendMessage(config types.MessageConfig) (*types.Message, error) {
-	return b.messageService.Send(b.ctx, config)
+	return b.SendMessageWithContext(b.ctx, config)
+}
+
+// SendMessageWithContext is like SendMessage but uses ctx for cancellation and tracing
+func (b *BotAPI) SendMessageWithContext(ctx context.Context, config types.MessageConfig) (*types.Message, error) {
+	return b.withSender(b.messageService.Send(ctx, config))
 }
 
 // SendImage sends an image message
 // Delegates to the message service
 func (b *BotAPI) SendImage(config types.ImageMessageConfig) (*types.Message, error) {
-	return b.messageService.SendImage(b.ctx, config)
+	return b.SendImageWithContext(b.ctx, config)
+}
+
+// SendImageWithContext is like SendImage but uses ctx for cancellation and tracing
+func (b *BotAPI) SendImageWithContext(ctx context.Context, config types.ImageMessageConfig) (*types.Message, error) {
+	return b.withSender(b.messageService.SendImage(ctx, config))
 }
 
 // SendFile sends a file message
 // Delegates to the message service
 func (b *BotAPI) SendFile(config types.FileMessageConfig) (*types.Message, error) {
-	return b.messageService.SendFile(b.ctx, config)
+	return b.SendFileWithContext(b.ctx, config)
+}
+
+// SendFileWithContext is like SendFile but uses ctx for cancellation and tracing
+func (b *BotAPI) SendFileWithContext(ctx context.Context, config types.FileMessageConfig) (*types.Message, error) {
+	return b.withSender(b.messageService.SendFile(ctx, config))
 }
 
 // SendVideo sends a video message
 // Delegates to the message service
 func (b *BotAPI) SendVideo(chatID, videoURL, mimeType string) (*types.Message, error) {
-	return b.messageService.SendVideo(b.ctx, chatID, videoURL, mimeType)
+	return b.withSender(b.messageService.SendVideo(b.ctx, chatID, videoURL, mimeType))
 }
 
 // SendAudio sends an audio message
 // Delegates to the message service
 func (b *BotAPI) SendAudio(chatID, audioURL, mimeType string) (*types.Message, error) {
-	return b.messageService.SendAudio(b.ctx, chatID, audioURL, mimeType)
+	return b.withSender(b.messageService.SendAudio(b.ctx, chatID, audioURL, mimeType))
 }
 
 // SendTemplate sends a structured message with buttons and quick replies
 // Delegates to the message service
 func (b *BotAPI) SendTemplate(config types.StructuredMessageConfig) (*types.Message, error) {
-	return b.messageService.SendTemplate(b.ctx, config)
+	return b.SendTemplateWithContext(b.ctx, config)
+}
+
+// SendTemplateWithContext is like SendTemplate but uses ctx for cancellation and tracing
+func (b *BotAPI) SendTemplateWithContext(ctx context.Context, config types.StructuredMessageConfig) (*types.Message, error) {
+	return b.withSender(b.messageService.SendTemplate(ctx, config))
 }
 
 // SendStructuredMessage sends a structured message (alias for SendTemplate)
@@ -312,7 +423,67 @@ func (b *BotAPI) SendStructuredMessage(config types.StructuredMessageConfig) (*t
 // GetUserProfile retrieves user profile information
 // Delegates to the user service
 func (b *BotAPI) GetUserProfile(userID string) (*types.UserProfile, error) {
-	return b.userService.GetUserProfile(b.ctx, userID)
+	return b.GetUserProfileWithContext(b.ctx, userID)
+}
+
+// GetUserProfileWithContext is like GetUserProfile but uses ctx for cancellation and tracing
+func (b *BotAPI) GetUserProfileWithContext(ctx context.Context, userID string) (*types.UserProfile, error) {
+	return b.userService.GetUserProfile(ctx, userID)
+}
+
+// GetMe returns the identity of the bot. The result is cached for the
+// configured BotInfoTTL, so repeated calls do not hit the API.
+func (b *BotAPI) GetMe(ctx context.Context) (*types.BotInfo, error) {
+	return b.getMe(ctx, b.currentConfig().BotInfoTTL)
+}
+
+// getMe returns the cached bot identity if it is younger than ttl, and
+// fetches it otherwise
+func (b *BotAPI) getMe(ctx context.Context, ttl time.Duration) (*types.BotInfo, error) {
+	b.meMu.Lock()
+	defer b.meMu.Unlock()
+
+	if b.me != nil && time.Since(b.meFetchedAt) < ttl {
+		return b.me, nil
+	}
+
+	info, err := b.userService.GetMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.me = info
+	b.meFetchedAt = time.Now()
+	return info, nil
+}
+
+// cachedMe returns the cached bot identity without fetching it, or nil
+func (b *BotAPI) cachedMe() *types.BotInfo {
+	b.meMu.Lock()
+	defer b.meMu.Unlock()
+	return b.me
+}
+
+// forgetMe drops the cached bot identity
+func (b *BotAPI) forgetMe() {
+	b.meMu.Lock()
+	b.me = nil
+	b.meMu.Unlock()
+}
+
+// withSender sets the sender of a sent message to the bot. Only a cached
+// identity is used, so sending never waits on an extra getMe call; the sender
+// is filled in once GetMe or a health check has run.
+func (b *BotAPI) withSender(message *types.Message, err error) (*types.Message, error) {
+	if err != nil || message == nil || message.From != nil {
+		return message, err
+	}
+	if me := b.cachedMe(); me != nil {
+		message.From = me.User()
+	} else {
+		message.From = &types.User{IsBot: true}
+	}
+	return message, nil
 }
 
 // ProcessWebhook processes a webhook request, validating the
@@ -320,7 +491,36 @@ func (b *BotAPI) GetUserProfile(userID string) (*types.UserProfile, error) {
 // before parsing the payload.
 // Delegates to the webhook service
 func (b *BotAPI) ProcessWebhook(payload []byte, secretToken string) (*types.Update, error) {
-	return b.webhookService.ProcessWebhook(payload, secretToken)
+	_, update, err := b.ProcessWebhookWithContext(b.ctx, payload, secretToken)
+	return update, err
+}
+
+// ProcessWebhookWithContext processes a webhook request like ProcessWebhook
+// inside a receive span. The returned context carries that span; pass it to
+// HandleUpdate so the handler and its replies join the same trace. With
+// deduplication enabled, an update already received is returned together
+// with ErrDuplicateUpdate.
+func (b *BotAPI) ProcessWebhookWithContext(ctx context.Context, payload []byte, secretToken string) (context.Context, *types.Update, error) {
+	ctx, span := b.tracer().Start(ctx, "zalobot.webhook.receive")
+	if b.ValidateWebhookSecretToken(secretToken) == nil {
+		b.recordPayload(UpdateSourceWebhook, payload)
+	}
+	update, err := b.webhookService.ProcessWebhook(payload, secretToken)
+	if err != nil {
+		endSpan(span, err)
+		return ctx, nil, err
+	}
+
+	span.SetAttributes(updateSpanFields(*update)...)
+	endSpan(span, nil)
+
+	b.state.markUpdateReceived()
+	b.state.setWebhookActive(true)
+	if !b.claimUpdate(ctx, UpdateSourceWebhook, *update) {
+		return ctx, update, ErrDuplicateUpdate
+	}
+	b.observeUpdate(UpdateSourceWebhook, *update)
+	return ctx, update, nil
 }
 
 // ValidateWebhookSecretToken validates a webhook secret token
@@ -338,38 +538,63 @@ func (b *BotAPI) ParseWebhookUpdate(payload []byte) (*types.Update, error) {
 // SetWebhook sets the webhook URL for receiving updates
 // The webhook URL must be a valid HTTPS URL
 func (b *BotAPI) SetWebhook(config types.WebhookConfig) error {
+	return b.SetWebhookWithContext(b.ctx, config)
+}
+
+// SetWebhookWithContext is like SetWebhook but uses ctx for cancellation and tracing
+func (b *BotAPI) SetWebhookWithContext(ctx context.Context, config types.WebhookConfig) (err error) {
+	start := time.Now()
+	ctx, span := b.startAPISpan(ctx, "setWebhook", http.MethodPost)
+	defer func() {
+		b.observeAPIRequest("setWebhook", start, err)
+		endSpan(span, err)
+	}()
+
+	err = b.withTokenRefresh(ctx, func() error {
+		return b.setWebhook(ctx, config)
+	})
+	if err == nil {
+		b.state.setWebhookActive(true)
+	}
+	return err
+}
+
+// setWebhook performs a single setWebhook request
+func (b *BotAPI) setWebhook(ctx context.Context, config types.WebhookConfig) error {
 	// Validate webhook URL
 	if err := validateWebhookURL(config.URL); err != nil {
 		return types.NewValidationError(err.Error())
 	}
 
-	// Prepare request body
-	requestBody := map[string]interface{}{
-		"url":          config.URL,
-		"secret_token": config.SecretToken,
+	if err := config.Validate(); err != nil {
+		return err
 	}
 
-	// Construct URL with bot token embedded
-	url := b.authService.GetAPIEndpoint("setWebhook")
-
-	// Prepare request body
-	bodyBytes, err := json.Marshal(requestBody)
+	// Prepare request body; a certificate is uploaded as multipart form data
+	bodyBytes, contentType, err := webhookRequestBody(config)
 	if err != nil {
-		return types.NewValidationError("failed to marshal request body")
+		return err
 	}
 
+	// Construct URL with bot token embedded
+	url := b.authService.GetAPIEndpoint("setWebhook")
+
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(b.ctx, "POST", url, bytes.NewReader(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return types.NewNetworkError("failed to create request")
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", services.UserAgent())
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", b.userAgent())
+
+	if err := b.waitRateLimit(req.Context()); err != nil {
+		return types.NewNetworkError(fmt.Sprintf("request cancelled: %v", err))
+	}
 
 	// Execute request
-	resp, err := b.client.Do(req)
+	resp, err := b.GetHTTPClient().Do(req)
 	if err != nil {
 		return types.NewNetworkError("request failed")
 	}
@@ -403,23 +628,163 @@ func (b *BotAPI) SetWebhook(config types.WebhookConfig) error {
 	return nil
 }
 
+// webhookRequestBody encodes config as the setWebhook request body and
+// returns it with its content type. Without a certificate the body is JSON;
+// with one it is multipart form data carrying the certificate as a file.
+func webhookRequestBody(config types.WebhookConfig) ([]byte, string, error) {
+	if config.Certificate == "" && len(config.CertificateData) == 0 {
+		requestBody := map[string]interface{}{
+			"url":          config.URL,
+			"secret_token": config.SecretToken,
+		}
+		if config.MaxConnections > 0 {
+			requestBody["max_connections"] = config.MaxConnections
+		}
+		if len(config.AllowedUpdates) > 0 {
+			requestBody["allowed_updates"] = config.AllowedUpdates
+		}
+		if config.DropPendingUpdates {
+			requestBody["drop_pending_updates"] = true
+		}
+
+		bodyBytes, err := json.Marshal(requestBody)
+		if err != nil {
+			return nil, "", types.NewValidationError("failed to marshal request body")
+		}
+		return bodyBytes, "application/json", nil
+	}
+
+	certificate, certificateName := config.CertificateData, "certificate.pem"
+	if config.Certificate != "" {
+		data, err := os.ReadFile(config.Certificate)
+		if err != nil {
+			return nil, "", types.NewValidationError(fmt.Sprintf("failed to read webhook certificate: %v", err))
+		}
+		certificate, certificateName = data, filepath.Base(config.Certificate)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fields := [][2]string{{"url", config.URL}, {"secret_token", config.SecretToken}}
+	if config.MaxConnections > 0 {
+		fields = append(fields, [2]string{"max_connections", strconv.Itoa(config.MaxConnections)})
+	}
+	if len(config.AllowedUpdates) > 0 {
+		allowed, err := json.Marshal(config.AllowedUpdates)
+		if err != nil {
+			return nil, "", types.NewValidationError("failed to marshal allowed updates")
+		}
+		fields = append(fields, [2]string{"allowed_updates", string(allowed)})
+	}
+	if config.DropPendingUpdates {
+		fields = append(fields, [2]string{"drop_pending_updates", "true"})
+	}
+	for _, field := range fields {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return nil, "", types.NewValidationError("failed to encode request body")
+		}
+	}
+
+	part, err := writer.CreateFormFile("certificate", certificateName)
+	if err == nil {
+		_, err = part.Write(certificate)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		return nil, "", types.NewValidationError("failed to encode webhook certificate")
+	}
+
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// RotateWebhookSecret generates a new webhook secret token and registers it
+// with SetWebhook for webhookURL, or for the currently registered URL if
+// webhookURL is empty. The new secret is accepted before Zalo is told about
+// it, and the old one stays valid for overlap (services.DefaultSecretOverlap
+// if overlap <= 0) so deliveries in flight are not rejected. It returns the
+// new secret token.
+func (b *BotAPI) RotateWebhookSecret(webhookURL string, overlap time.Duration) (string, error) {
+	return b.RotateWebhookSecretWithContext(b.ctx, webhookURL, overlap)
+}
+
+// RotateWebhookSecretWithContext is like RotateWebhookSecret but uses ctx for
+// the getWebhookInfo and setWebhook requests
+func (b *BotAPI) RotateWebhookSecretWithContext(ctx context.Context, webhookURL string, overlap time.Duration) (string, error) {
+	b.reconfigureMu.Lock()
+	defer b.reconfigureMu.Unlock()
+
+	if webhookURL == "" {
+		info, err := b.GetWebhookInfoWithContext(ctx)
+		if err != nil {
+			return "", err
+		}
+		if info == nil || info.URL == "" {
+			return "", types.NewValidationError("no webhook is registered")
+		}
+		webhookURL = info.URL
+	}
+
+	secretToken, err := utils.GenerateSecretToken()
+	if err != nil {
+		return "", err
+	}
+
+	oldSecretToken := b.webhookService.GetSecretToken()
+	b.webhookService.RotateSecretToken(secretToken, overlap)
+
+	if err := b.SetWebhookWithContext(ctx, types.WebhookConfig{URL: webhookURL, SecretToken: secretToken}); err != nil {
+		// Zalo still signs with the old secret
+		b.webhookService.SetSecretToken(oldSecretToken)
+		return "", err
+	}
+
+	b.logger().Info("Webhook secret token rotated",
+		utils.Field{Key: "previous_valid_until", Value: b.webhookService.PreviousSecretTokenExpiry()},
+	)
+	return secretToken, nil
+}
+
 // DeleteWebhook removes the webhook configuration
-func (b *BotAPI) DeleteWebhook() error {
+func (b *BotAPI) DeleteWebhook() (err error) {
+	start := time.Now()
+	ctx, span := b.startAPISpan(b.ctx, "deleteWebhook", http.MethodPost)
+	defer func() {
+		b.observeAPIRequest("deleteWebhook", start, err)
+		endSpan(span, err)
+	}()
+
+	err = b.withTokenRefresh(ctx, func() error {
+		return b.deleteWebhook(ctx)
+	})
+	if err == nil {
+		b.state.setWebhookActive(false)
+	}
+	return err
+}
+
+// deleteWebhook performs a single deleteWebhook request
+func (b *BotAPI) deleteWebhook(ctx context.Context) error {
 	// Construct URL with bot token embedded
 	url := b.authService.GetAPIEndpoint("deleteWebhook")
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(b.ctx, "POST", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
 	if err != nil {
 		return types.NewNetworkError("failed to create request")
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", services.UserAgent())
+	req.Header.Set("User-Agent", b.userAgent())
+
+	if err := b.waitRateLimit(req.Context()); err != nil {
+		return types.NewNetworkError(fmt.Sprintf("request cancelled: %v", err))
+	}
 
 	// Execute request
-	resp, err := b.client.Do(req)
+	resp, err := b.GetHTTPClient().Do(req)
 	if err != nil {
 		return types.NewNetworkError("request failed")
 	}
@@ -450,21 +815,46 @@ func (b *BotAPI) DeleteWebhook() error {
 
 // GetWebhookInfo retrieves information about the current webhook configuration
 func (b *BotAPI) GetWebhookInfo() (*types.WebhookInfo, error) {
+	return b.GetWebhookInfoWithContext(b.ctx)
+}
+
+// GetWebhookInfoWithContext is like GetWebhookInfo but uses ctx for cancellation and tracing
+func (b *BotAPI) GetWebhookInfoWithContext(ctx context.Context) (info *types.WebhookInfo, err error) {
+	start := time.Now()
+	ctx, span := b.startAPISpan(ctx, "getWebhookInfo", http.MethodGet)
+	defer func() {
+		b.observeAPIRequest("getWebhookInfo", start, err)
+		endSpan(span, err)
+	}()
+
+	err = b.withTokenRefresh(ctx, func() error {
+		info, err = b.getWebhookInfo(ctx)
+		return err
+	})
+	return info, err
+}
+
+// getWebhookInfo performs a single getWebhookInfo request
+func (b *BotAPI) getWebhookInfo(ctx context.Context) (*types.WebhookInfo, error) {
 	// Construct URL with bot token embedded
 	url := b.authService.GetAPIEndpoint("getWebhookInfo")
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(b.ctx, "GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, types.NewNetworkError("failed to create request")
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", services.UserAgent())
+	req.Header.Set("User-Agent", b.userAgent())
+
+	if err := b.waitRateLimit(req.Context()); err != nil {
+		return nil, types.NewNetworkError(fmt.Sprintf("request cancelled: %v", err))
+	}
 
 	// Execute request
-	resp, err := b.client.Do(req)
+	resp, err := b.GetHTTPClient().Do(req)
 	if err != nil {
 		return nil, types.NewNetworkError("request failed")
 	}
@@ -533,6 +923,34 @@ func (b *BotAPI) GetUpdates(config types.UpdateConfig) ([]types.Update, error) {
 
 // GetUpdatesWithContext retrieves updates with a custom context
 func (b *BotAPI) GetUpdatesWithContext(ctx context.Context, config types.UpdateConfig) ([]types.Update, error) {
+	start := time.Now()
+	spanCtx, span := b.startAPISpan(ctx, "getUpdates", http.MethodGet)
+	var updates []types.Update
+	err := b.withTokenRefresh(spanCtx, func() (err error) {
+		updates, err = b.getUpdates(spanCtx, config)
+		return err
+	})
+	endSpan(span, err)
+	if err != nil {
+		// A cancelled long poll is a shutdown, not an API failure
+		if ctx.Err() == nil {
+			b.stats.RecordFailure(err)
+			b.state.markPolled()
+			b.observeAPIRequest("getUpdates", start, err)
+		}
+		return nil, err
+	}
+
+	b.observeAPIRequest("getUpdates", start, nil)
+
+	b.stats.RecordSuccess()
+	b.state.markPolled()
+	b.state.markUpdateReceived()
+	return updates, nil
+}
+
+// getUpdates performs a single getUpdates request
+func (b *BotAPI) getUpdates(ctx context.Context, config types.UpdateConfig) ([]types.Update, error) {
 	// Validate config
 	if err := config.Validate(); err != nil {
 		return nil, err
@@ -565,10 +983,14 @@ func (b *BotAPI) GetUpdatesWithContext(ctx context.Context, config types.UpdateC
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", services.UserAgent())
+	req.Header.Set("User-Agent", b.userAgent())
+
+	if err := b.waitRateLimit(req.Context()); err != nil {
+		return nil, types.NewNetworkError(fmt.Sprintf("request cancelled: %v", err))
+	}
 
 	// Execute request
-	resp, err := b.client.Do(req)
+	resp, err := b.GetHTTPClient().Do(req)
 	if err != nil {
 		return nil, types.NewNetworkError("request failed")
 	}
@@ -588,8 +1010,12 @@ func (b *BotAPI) GetUpdatesWithContext(ctx context.Context, config types.UpdateC
 		Description string         `json:"description,omitempty"`
 	}
 	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		b.recordPayload(UpdateSourcePolling, respBody)
 		return nil, types.NewAPIError(resp.StatusCode, "failed to parse response", err.Error())
 	}
+	if len(apiResp.Result) > 0 {
+		b.recordPayload(UpdateSourcePolling, respBody)
+	}
 
 	if !apiResp.OK {
 		return nil, types.NewAPIError(apiResp.ErrorCode, apiResp.Description, "getUpdates failed")
@@ -599,43 +1025,60 @@ func (b *BotAPI) GetUpdatesWithContext(ctx context.Context, config types.UpdateC
 }
 
 // GetUpdatesChan returns a channel that receives updates via polling
-// The polling loop runs in a background goroutine with configurable intervals and error handling
+// The polling loop runs in a background goroutine with configurable intervals and error handling.
+// config.Buffer sets the channel size and what happens when the consumer falls behind;
+// further consumers can be added with SubscribeUpdates.
 // Implements Requirements 3.1, 5.3
 func (b *BotAPI) GetUpdatesChan(config types.UpdateConfig) <-chan types.Update {
 	// Validate config
 	if err := config.Validate(); err != nil {
-		// Return a closed channel if config is invalid
-		ch := make(chan types.Update)
-		close(ch)
-		return ch
+		b.logger().Error("Invalid update config", utils.Field{Key: "error", Value: err})
+		return closedUpdatesChan()
 	}
 
 	b.pollingMu.Lock()
 	defer b.pollingMu.Unlock()
 
-	// If already polling, return existing channel
-	if b.isPolling && b.updatesChan != nil {
-		return b.updatesChan
+	if b.primary == nil {
+		buffer := config.Buffer
+		if buffer.Name == "" {
+			buffer.Name = "default"
+		}
+		primary, err := b.subscribeLocked(buffer)
+		if err != nil {
+			b.logger().Error("Failed to create update buffer", utils.Field{Key: "error", Value: err})
+			return closedUpdatesChan()
+		}
+		b.primary = primary
 	}
 
-	// Create updates channel
-	if b.updatesChan == nil {
-		b.updatesChan = make(chan types.Update, 100)
-	}
-	if b.stopPollingCh == nil {
-		b.stopPollingCh = make(chan struct{})
+	// If already polling, return existing channel
+	if b.isPolling {
+		return b.primary.ch
 	}
+
+	// The stop channel is created here, under the lock, so a StopPolling
+	// that runs before the goroutine starts still reaches it
+	stopCh := make(chan struct{})
+	b.stopPollingCh = stopCh
 	b.isPolling = true
 
 	// Start polling in background goroutine
 	b.pollingWg.Add(1)
-	go b.pollUpdates(config)
+	go b.pollUpdates(config, stopCh)
 
-	return b.updatesChan
+	return b.primary.ch
 }
 
-// pollUpdates runs the polling loop
-func (b *BotAPI) pollUpdates(config types.UpdateConfig) {
+// closedUpdatesChan returns a closed update channel
+func closedUpdatesChan() <-chan types.Update {
+	ch := make(chan types.Update)
+	close(ch)
+	return ch
+}
+
+// pollUpdates runs the polling loop until stopCh is closed
+func (b *BotAPI) pollUpdates(config types.UpdateConfig, stopCh <-chan struct{}) {
 	defer b.pollingWg.Done()
 
 	offset := config.Offset
@@ -653,7 +1096,7 @@ func (b *BotAPI) pollUpdates(config types.UpdateConfig) {
 	// Monitor stop signal in a separate goroutine
 	go func() {
 		select {
-		case <-b.stopPollingCh:
+		case <-stopCh:
 			pollCancel()
 		case <-b.ctx.Done():
 			pollCancel()
@@ -664,13 +1107,7 @@ func (b *BotAPI) pollUpdates(config types.UpdateConfig) {
 		select {
 		case <-pollCtx.Done():
 			// Stop polling
-			b.pollingMu.Lock()
-			if b.updatesChan != nil {
-				close(b.updatesChan)
-				b.updatesChan = nil
-			}
-			b.isPolling = false
-			b.pollingMu.Unlock()
+			b.stopUpdates()
 			return
 
 		default:
@@ -685,31 +1122,19 @@ func (b *BotAPI) pollUpdates(config types.UpdateConfig) {
 			if err != nil {
 				// Check if context was cancelled
 				if pollCtx.Err() != nil {
-					b.pollingMu.Lock()
-					if b.updatesChan != nil {
-						close(b.updatesChan)
-						b.updatesChan = nil
-					}
-					b.isPolling = false
-					b.pollingMu.Unlock()
+					b.stopUpdates()
 					return
 				}
 
-				// Log error if debug mode is enabled
-				if b.config.Debug {
-					fmt.Printf("Error getting updates: %v\n", err)
-				}
+				b.logger().Debug("Polling will retry after error",
+					utils.Field{Key: "retry_in", Value: pollInterval},
+					utils.Field{Key: "error", Value: err},
+				)
 
 				// Wait before retrying
 				select {
 				case <-pollCtx.Done():
-					b.pollingMu.Lock()
-					if b.updatesChan != nil {
-						close(b.updatesChan)
-						b.updatesChan = nil
-					}
-					b.isPolling = false
-					b.pollingMu.Unlock()
+					b.stopUpdates()
 					return
 				case <-time.After(pollInterval):
 					continue
@@ -718,37 +1143,32 @@ func (b *BotAPI) pollUpdates(config types.UpdateConfig) {
 
 			// Send updates to channel
 			for _, update := range updates {
-				select {
-				case b.updatesChan <- update:
-					// Update sent successfully
-					// Update offset to acknowledge this update
+				if !b.claimUpdate(pollCtx, UpdateSourcePolling, update) {
 					if update.UpdateID >= offset {
 						offset = update.UpdateID + 1
 					}
-				case <-pollCtx.Done():
-					// Stop polling
-					b.pollingMu.Lock()
-					if b.updatesChan != nil {
-						close(b.updatesChan)
-						b.updatesChan = nil
-					}
-					b.isPolling = false
-					b.pollingMu.Unlock()
+					continue
+				}
+				b.observeUpdate(UpdateSourcePolling, update)
+				if !b.fanOut(pollCtx, update) {
+					// Stopped while a consumer was blocking; the update is
+					// not acknowledged, so let it through when redelivered
+					b.releaseUpdate(context.Background(), update)
+					b.stopUpdates()
 					return
 				}
+				// Update offset to acknowledge this update
+				if update.UpdateID >= offset {
+					offset = update.UpdateID + 1
+				}
 			}
+			b.reportQueueDepths()
 
 			// If no updates received and not using long polling, wait before next poll
 			if len(updates) == 0 && config.Timeout == 0 {
 				select {
 				case <-pollCtx.Done():
-					b.pollingMu.Lock()
-					if b.updatesChan != nil {
-						close(b.updatesChan)
-						b.updatesChan = nil
-					}
-					b.isPolling = false
-					b.pollingMu.Unlock()
+					b.stopUpdates()
 					return
 				case <-time.After(pollInterval):
 					// Continue polling
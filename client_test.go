@@ -1,11 +1,20 @@
 package zalobot
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/vkhangstack/go-zalo-bot/auth"
+	"github.com/vkhangstack/go-zalo-bot/services"
 	"github.com/vkhangstack/go-zalo-bot/types"
 )
 
@@ -511,6 +520,18 @@ func TestBotAPI_WebhookIntegration(t *testing.T) {
 		}
 	})
 
+	t.Run("webhook secret token can be configured", func(t *testing.T) {
+		configured, err := New(botToken, types.WithWebhookSecretToken("configured-secret"))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer configured.Close()
+
+		if got := configured.GetWebhookService().GetSecretToken(); got != "configured-secret" {
+			t.Errorf("GetSecretToken() = %v, want configured-secret", got)
+		}
+	})
+
 	t.Run("ProcessWebhook method exists", func(t *testing.T) {
 		_ = bot.ProcessWebhook
 	})
@@ -967,3 +988,385 @@ func TestBotAPI_HTTPWrapperMethods(t *testing.T) {
 		}
 	})
 }
+
+func TestBotAPI_TokenRotation(t *testing.T) {
+	oldToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	newToken := "789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89"
+
+	var (
+		mu      sync.Mutex
+		current = oldToken
+		revoked = ""
+	)
+	provider := auth.CredentialFunc(func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return current, nil
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		rejected := revoked != "" && strings.Contains(r.URL.Path, revoked)
+		mu.Unlock()
+		if rejected {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{"url":"https://example.com/webhook"}}`))
+	}))
+	defer server.Close()
+
+	bot, err := New("", types.WithBaseURL(server.URL), types.WithCredentialProvider(provider))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	if bot.GetBotToken() != oldToken {
+		t.Fatalf("GetBotToken() = %s, want token from provider", bot.GetBotToken())
+	}
+
+	t.Run("auth failure re-fetches the token once", func(t *testing.T) {
+		mu.Lock()
+		current, revoked = newToken, oldToken
+		mu.Unlock()
+
+		if _, err := bot.GetWebhookInfo(); err != nil {
+			t.Fatalf("GetWebhookInfo() error = %v", err)
+		}
+		if bot.GetBotToken() != newToken {
+			t.Errorf("GetBotToken() = %s, want %s", bot.GetBotToken(), newToken)
+		}
+	})
+
+	t.Run("auth failure without a new token is returned", func(t *testing.T) {
+		mu.Lock()
+		revoked = newToken
+		mu.Unlock()
+
+		err := bot.DeleteWebhook()
+		if !types.IsAuthError(err) {
+			t.Fatalf("DeleteWebhook() error = %v, want auth error", err)
+		}
+		if bot.GetBotToken() != newToken {
+			t.Error("Expected token to be kept after auth failure")
+		}
+	})
+
+	t.Run("RotateToken", func(t *testing.T) {
+		if err := bot.RotateToken(oldToken); err != nil {
+			t.Fatalf("RotateToken() error = %v", err)
+		}
+		if bot.GetBotToken() != oldToken {
+			t.Errorf("GetBotToken() = %s, want %s", bot.GetBotToken(), oldToken)
+		}
+		if err := bot.RotateToken("bad"); err == nil {
+			t.Error("Expected error for invalid token")
+		}
+	})
+}
+
+func TestBotAPI_GetMe(t *testing.T) {
+	var getMeCalls, sendCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			getMeCalls++
+			w.Write([]byte(`{"ok":true,"result":{"id":"bot1","account_name":"bot.test","account_type":"BASIC"}}`))
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			sendCalls++
+			w.Write([]byte(`{"ok":true,"result":{"message_id":"m1","date":1750316131602}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	// Before GetMe the sender is only known to be the bot
+	msg, err := bot.SendMessage(types.MessageConfig{ChatID: "c1", Text: "hi"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if msg.From == nil || !msg.From.IsBot || msg.From.ID != "" {
+		t.Errorf("Message.From = %+v, want anonymous bot user", msg.From)
+	}
+
+	for i := 0; i < 3; i++ {
+		info, err := bot.GetMe(context.Background())
+		if err != nil {
+			t.Fatalf("GetMe() error = %v", err)
+		}
+		if info.ID != "bot1" {
+			t.Errorf("GetMe().ID = %v, want bot1", info.ID)
+		}
+	}
+	if getMeCalls != 1 {
+		t.Errorf("getMe called %d times, want 1", getMeCalls)
+	}
+
+	msg, err = bot.SendMessage(types.MessageConfig{ChatID: "c1", Text: "hi"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if msg.From == nil || msg.From.ID != "bot1" || msg.From.Name != "bot.test" || !msg.From.IsBot {
+		t.Errorf("Message.From = %+v, want bot identity", msg.From)
+	}
+
+	// Rotating the token drops the cached identity
+	if err := bot.RotateToken("789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89"); err != nil {
+		t.Fatalf("RotateToken() error = %v", err)
+	}
+	if _, err := bot.GetMe(context.Background()); err != nil {
+		t.Fatalf("GetMe() error = %v", err)
+	}
+	if getMeCalls != 2 {
+		t.Errorf("getMe called %d times after rotation, want 2", getMeCalls)
+	}
+}
+
+func TestBotAPI_UserAgent(t *testing.T) {
+	var mu sync.Mutex
+	agents := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents[r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]] = r.UserAgent()
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"result":{"message_id":"m1","id":"bot1"}}`))
+	}))
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+		types.WithBaseURL(server.URL),
+		types.WithAppIdentifier("shop-bot/2.1"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	bot.SendMessage(types.MessageConfig{ChatID: "c1", Text: "hi"})
+	bot.GetWebhookInfo()
+	bot.GetAuthService().ValidateCredentials(context.Background())
+
+	want := "Go-Zalo-Bot-SDK/" + services.Version() + " shop-bot/2.1"
+	for _, method := range []string{"sendMessage", "getWebhookInfo", "getMe"} {
+		if got := agents[method]; got != want {
+			t.Errorf("%s User-Agent = %q, want %q", method, got, want)
+		}
+	}
+}
+
+func TestBotAPI_RotateWebhookSecret(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		registered string
+		failSet    bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getWebhookInfo"):
+			w.Write([]byte(`{"ok":true,"result":{"url":"https://example.com/webhook"}}`))
+		case strings.HasSuffix(r.URL.Path, "/setWebhook"):
+			if failSet {
+				w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request"}`))
+				return
+			}
+			var body struct {
+				URL         string `json:"url"`
+				SecretToken string `json:"secret_token"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.URL != "https://example.com/webhook" {
+				t.Errorf("setWebhook url = %q", body.URL)
+			}
+			registered = body.SecretToken
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+	bot.SetWebhookSecretToken("old-secret")
+
+	secret, err := bot.RotateWebhookSecret("", time.Minute)
+	if err != nil {
+		t.Fatalf("RotateWebhookSecret() error = %v", err)
+	}
+	if secret == "" || secret != registered {
+		t.Errorf("RotateWebhookSecret() = %q, registered %q", secret, registered)
+	}
+	for _, token := range []string{secret, "old-secret"} {
+		if err := bot.ValidateWebhookSecretToken(token); err != nil {
+			t.Errorf("ValidateWebhookSecretToken(%q) error = %v", token, err)
+		}
+	}
+
+	// A failed registration keeps the secret Zalo still uses
+	mu.Lock()
+	failSet = true
+	mu.Unlock()
+	if _, err := bot.RotateWebhookSecret("https://example.com/webhook", time.Minute); err == nil {
+		t.Fatal("RotateWebhookSecret() expected error")
+	}
+	if got := bot.GetWebhookService().GetSecretToken(); got != secret {
+		t.Errorf("secret token after failed rotation = %q, want %q", got, secret)
+	}
+}
+
+func TestBotAPI_SetWebhookOptions(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		contentType string
+		fields      map[string]string
+		certificate string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		contentType = r.Header.Get("Content-Type")
+		fields = make(map[string]string)
+		certificate = ""
+
+		if strings.HasPrefix(contentType, "multipart/form-data") {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("ParseMultipartForm() error = %v", err)
+			}
+			for name, values := range r.MultipartForm.Value {
+				fields[name] = values[0]
+			}
+			if file, header, err := r.FormFile("certificate"); err == nil {
+				data, _ := io.ReadAll(file)
+				file.Close()
+				certificate = header.Filename + ":" + string(data)
+			}
+		} else {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			for name, value := range body {
+				encoded, _ := json.Marshal(value)
+				fields[name] = string(encoded)
+			}
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	t.Run("JSON options", func(t *testing.T) {
+		err := bot.SetWebhook(types.WebhookConfig{
+			URL:                "https://example.com/webhook",
+			SecretToken:        "secret",
+			MaxConnections:     40,
+			AllowedUpdates:     []string{types.EventMessageText},
+			DropPendingUpdates: true,
+		})
+		if err != nil {
+			t.Fatalf("SetWebhook() error = %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if contentType != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", contentType)
+		}
+		want := map[string]string{
+			"url":                  `"https://example.com/webhook"`,
+			"secret_token":         `"secret"`,
+			"max_connections":      `40`,
+			"allowed_updates":      `["message.text.received"]`,
+			"drop_pending_updates": `true`,
+		}
+		for name, value := range want {
+			if fields[name] != value {
+				t.Errorf("%s = %s, want %s", name, fields[name], value)
+			}
+		}
+	})
+
+	t.Run("unset options are omitted", func(t *testing.T) {
+		if err := bot.SetWebhook(types.WebhookConfig{URL: "https://example.com/webhook", SecretToken: "secret"}); err != nil {
+			t.Fatalf("SetWebhook() error = %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, name := range []string{"max_connections", "allowed_updates", "drop_pending_updates"} {
+			if _, ok := fields[name]; ok {
+				t.Errorf("%s sent although unset", name)
+			}
+		}
+	})
+
+	t.Run("certificate upload", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bot.pem")
+		if err := os.WriteFile(path, []byte("PEM DATA"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		err := bot.SetWebhook(types.WebhookConfig{
+			URL:            "https://example.com/webhook",
+			SecretToken:    "secret",
+			Certificate:    path,
+			MaxConnections: 10,
+			AllowedUpdates: []string{types.EventMessageText, types.EventMessageImage},
+		})
+		if err != nil {
+			t.Fatalf("SetWebhook() error = %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !strings.HasPrefix(contentType, "multipart/form-data") {
+			t.Errorf("Content-Type = %q, want multipart/form-data", contentType)
+		}
+		if certificate != "bot.pem:PEM DATA" {
+			t.Errorf("certificate = %q, want bot.pem:PEM DATA", certificate)
+		}
+		want := map[string]string{
+			"url":             "https://example.com/webhook",
+			"secret_token":    "secret",
+			"max_connections": "10",
+			"allowed_updates": `["message.text.received","message.image.received"]`,
+		}
+		for name, value := range want {
+			if fields[name] != value {
+				t.Errorf("%s = %q, want %q", name, fields[name], value)
+			}
+		}
+	})
+
+	t.Run("missing certificate file", func(t *testing.T) {
+		err := bot.SetWebhook(types.WebhookConfig{
+			URL:         "https://example.com/webhook",
+			Certificate: filepath.Join(t.TempDir(), "missing.pem"),
+		})
+		if zaloBotErr, ok := err.(*types.ZaloBotError); !ok || zaloBotErr.Type != types.ErrorTypeValidation {
+			t.Errorf("SetWebhook() error = %v, want validation error", err)
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		err := bot.SetWebhook(types.WebhookConfig{URL: "https://example.com/webhook", MaxConnections: 101})
+		if zaloBotErr, ok := err.(*types.ZaloBotError); !ok || zaloBotErr.Type != types.ErrorTypeValidation {
+			t.Errorf("SetWebhook() error = %v, want validation error", err)
+		}
+	})
+}
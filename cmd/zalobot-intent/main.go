@@ -0,0 +1,75 @@
+// Command zalobot-intent trains and tries out intent models for
+// Dispatcher.OnIntent.
+//
+// With -train it trains a model on labeled utterances from a YAML file and
+// saves it to -model. Any arguments are then classified with the model and
+// printed as JSON lines, one per text:
+//
+//	zalobot-intent -train intents.yaml -model intents.json
+//	zalobot-intent -model intents.json "đơn hàng của tôi đâu rồi" "how much is this"
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+func main() {
+	model := flag.String("model", "", "model file to save to with -train, or to load")
+	train := flag.String("train", "", "YAML file of example utterances by intent to train on")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -model intents.json [-train intents.yaml] [text...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *model == "" || (*train == "" && flag.NArg() == 0) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	classifier, err := loadOrTrain(*model, *train)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	for _, text := range flag.Args() {
+		encoder.Encode(struct {
+			Text        string                   `json:"text"`
+			Predictions []utils.IntentPrediction `json:"predictions"`
+		}{text, classifier.Classify(text)})
+	}
+}
+
+// loadOrTrain trains and saves a model if examples is set, or loads it
+func loadOrTrain(model, examples string) (*utils.IntentClassifier, error) {
+	if examples == "" {
+		return utils.LoadIntentClassifier(model)
+	}
+
+	labeled, err := utils.LoadIntentExamples(examples)
+	if err != nil {
+		return nil, err
+	}
+	classifier, err := utils.TrainIntentClassifier(labeled)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", examples, err)
+	}
+	if err := classifier.Save(model); err != nil {
+		return nil, err
+	}
+
+	count := 0
+	for _, utterances := range labeled {
+		count += len(utterances)
+	}
+	fmt.Fprintf(os.Stderr, "trained %d intents on %d examples, saved to %s\n", len(labeled), count, model)
+	return classifier, nil
+}
@@ -0,0 +1,340 @@
+package zalobot
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// ContextHandler handles an update through a Context
+type ContextHandler func(c *Context) error
+
+// Middleware wraps a ContextHandler, running code before or after it. It
+// can share data with later handlers through Context.Set.
+type Middleware func(next ContextHandler) ContextHandler
+
+// Context carries one update to a handler, with helpers for replying to the
+// chat it came from. It is a context.Context that is cancelled when the
+// handler returns or the bot is closed, and can be passed to any SDK call.
+type Context struct {
+	context.Context
+
+	// Bot is the bot that received the update
+	Bot *BotAPI
+	// Update is the update being handled
+	Update types.Update
+
+	mu      sync.Mutex
+	values  map[string]interface{}
+	profile *types.UserProfile
+	session *Session
+	forms   []string
+
+	predictions  []utils.IntentPrediction
+	classifiedBy *utils.IntentClassifier
+}
+
+// Handler returns an UpdateHandler that runs handler with a Context for
+// each update, after the middleware in the order given. Pass it to Run,
+// HandleUpdate or Replay.
+func (b *BotAPI) Handler(handler ContextHandler, middleware ...Middleware) UpdateHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return func(ctx context.Context, update types.Update) error {
+		c, cancel := b.NewContext(ctx, update)
+		defer cancel()
+
+		err := handler(c)
+		if saveErr := c.saveSession(); err == nil {
+			err = saveErr
+		}
+		return err
+	}
+}
+
+// NewContext creates a Context for update, derived from ctx and also
+// cancelled when the bot is closed. Call cancel when done with it. Session
+// changes are only saved by handlers run through Handler.
+func (b *BotAPI) NewContext(ctx context.Context, update types.Update) (*Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(b.ctx, cancel)
+
+	c := &Context{Context: ctx, Bot: b, Update: update}
+	return c, func() {
+		stop()
+		cancel()
+	}
+}
+
+// ChatID returns the ID of the chat the update came from, or "" for updates
+// without a chat
+func (c *Context) ChatID() string {
+	if message := c.Update.Message; message != nil {
+		if message.Chat != nil && message.Chat.ID != "" {
+			return message.Chat.ID
+		}
+		if message.From != nil {
+			return message.From.ID
+		}
+	}
+	if c.Update.UserAction != nil {
+		return c.Update.UserAction.UserID
+	}
+	return ""
+}
+
+// UserID returns the ID of the user who sent the update, or "" if unknown
+func (c *Context) UserID() string {
+	if message := c.Update.Message; message != nil && message.From != nil {
+		return message.From.ID
+	}
+	if c.Update.UserAction != nil {
+		return c.Update.UserAction.UserID
+	}
+	return ""
+}
+
+// Text returns the text of the update's message, or ""
+func (c *Context) Text() string {
+	if c.Update.Message == nil {
+		return ""
+	}
+	return c.Update.Message.Text
+}
+
+// chatID returns the chat to reply to, or an error for updates without one
+func (c *Context) chatID() (string, error) {
+	chatID := c.ChatID()
+	if chatID == "" {
+		return "", types.NewValidationError("update has no chat to reply to")
+	}
+	return chatID, nil
+}
+
+// Reply sends a text message to the chat the update came from
+func (c *Context) Reply(text string) (*types.Message, error) {
+	chatID, err := c.chatID()
+	if err != nil {
+		return nil, err
+	}
+	return c.Bot.SendMessageWithContext(c, types.MessageConfig{
+		ChatID:      chatID,
+		Text:        text,
+		MessageType: types.MessageTypeText,
+	})
+}
+
+// ReplyImage sends an image with an optional caption to the chat the update
+// came from
+func (c *Context) ReplyImage(imageURL, caption string) (*types.Message, error) {
+	chatID, err := c.chatID()
+	if err != nil {
+		return nil, err
+	}
+	return c.Bot.SendImageWithContext(c, types.ImageMessageConfig{
+		ChatID:   chatID,
+		ImageURL: imageURL,
+		Caption:  caption,
+	})
+}
+
+// ReplyTemplate sends a structured message with buttons or quick replies to
+// the chat the update came from
+func (c *Context) ReplyTemplate(message types.StructuredMessage) (*types.Message, error) {
+	chatID, err := c.chatID()
+	if err != nil {
+		return nil, err
+	}
+	return c.Bot.SendTemplateWithContext(c, types.StructuredMessageConfig{
+		ChatID:            chatID,
+		StructuredMessage: message,
+	})
+}
+
+// Typing shows the typing indicator in the chat the update came from
+func (c *Context) Typing() error {
+	chatID, err := c.chatID()
+	if err != nil {
+		return err
+	}
+	return c.Bot.SendChatActionWithContext(c, chatID, types.ChatActionTyping)
+}
+
+// Profile returns the profile of the user who sent the update. It is
+// fetched on first use and cached for the rest of the update.
+func (c *Context) Profile() (*types.UserProfile, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.profile != nil {
+		return c.profile, nil
+	}
+
+	userID := c.UserID()
+	if userID == "" {
+		return nil, types.NewValidationError("update has no sender")
+	}
+	profile, err := c.Bot.GetUserProfileWithContext(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	c.profile = profile
+	return profile, nil
+}
+
+// Set stores a value for the rest of the update, for middleware to pass
+// data to later handlers
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.values == nil {
+		c.values = make(map[string]interface{})
+	}
+	c.values[key] = value
+}
+
+// Get returns a value stored with Set
+func (c *Context) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.values[key]
+	return value, ok
+}
+
+// GetString returns a string stored with Set, or "" if there is none
+func (c *Context) GetString(key string) string {
+	value, _ := c.Get(key)
+	s, _ := value.(string)
+	return s
+}
+
+// Session returns the session of the chat the update came from, loading it
+// on first use. Changes are saved when the handler returns. Sessions must
+// be enabled with types.WithSessions.
+func (c *Context) Session() (*Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session != nil {
+		return c.session, nil
+	}
+
+	store := c.Bot.sessions
+	if store == nil {
+		return nil, types.NewValidationError("sessions are not enabled")
+	}
+	chatID, err := c.chatID()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := store.Load(c, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	c.session = &Session{key: chatID, data: data}
+	return c.session, nil
+}
+
+// saveSession writes back a session changed by the handler. It uses a
+// context that is not cancelled, so changes made before shutdown are kept.
+func (c *Context) saveSession() error {
+	c.mu.Lock()
+	session := c.session
+	c.mu.Unlock()
+	if session == nil {
+		return nil
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if !session.dirty {
+		return nil
+	}
+
+	ctx := context.WithoutCancel(c)
+	var err error
+	if session.cleared {
+		err = c.Bot.sessions.Delete(ctx, session.key)
+	} else {
+		err = c.Bot.sessions.Save(ctx, session.key, session.data, c.Bot.currentConfig().Sessions.TTL)
+	}
+	if err != nil {
+		c.Bot.logger().Error("Failed to save session",
+			utils.Field{Key: "chat_id", Value: session.key},
+			utils.Field{Key: "error", Value: err},
+		)
+		return err
+	}
+	session.dirty = false
+	return nil
+}
+
+// Session is the data a handler keeps for one chat between updates
+type Session struct {
+	key string
+
+	mu      sync.Mutex
+	data    map[string]interface{}
+	dirty   bool
+	cleared bool
+}
+
+// ID returns the chat ID the session belongs to
+func (s *Session) ID() string {
+	return s.key
+}
+
+// Get returns a session value
+func (s *Session) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.data[key]
+	return value, ok
+}
+
+// GetString returns a string session value, or "" if there is none
+func (s *Session) GetString(key string) string {
+	value, _ := s.Get(key)
+	str, _ := value.(string)
+	return str
+}
+
+// Set stores a session value
+func (s *Session) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key] = value
+	s.dirty = true
+	s.cleared = false
+}
+
+// Delete removes a session value
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, key)
+	s.dirty = true
+}
+
+// Clear removes all session values, deleting the session from the store
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = make(map[string]interface{})
+	s.dirty = true
+	s.cleared = true
+}
@@ -0,0 +1,201 @@
+package zalobot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// fakeReplyServer records the API methods called and their request bodies
+type fakeReplyServer struct {
+	mu       sync.Mutex
+	calls    []string
+	bodies   []map[string]interface{}
+	profiles int
+}
+
+func (f *fakeReplyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, method)
+	f.bodies = append(f.bodies, body)
+	if method == "getUserProfile" {
+		f.profiles++
+	}
+	f.mu.Unlock()
+
+	switch method {
+	case "getUserProfile":
+		w.Write([]byte(`{"ok":true,"result":{"id":"` + r.URL.Query().Get("user_id") + `","name":"Lan"}}`))
+	default:
+		w.Write([]byte(`{"ok":true,"result":{"message_id":"sent","date":1700000000000}}`))
+	}
+}
+
+func TestBotAPI_Handler_Context(t *testing.T) {
+	fake := &fakeReplyServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next ContextHandler) ContextHandler {
+			return func(c *Context) error {
+				order = append(order, name)
+				c.Set("request_id", "req-1")
+				return next(c)
+			}
+		}
+	}
+
+	var handlerCtx *Context
+	handler := bot.Handler(func(c *Context) error {
+		handlerCtx = c
+		order = append(order, "handler")
+
+		if c.GetString("request_id") != "req-1" {
+			t.Errorf("GetString(request_id) = %q, want req-1", c.GetString("request_id"))
+		}
+		if err := c.Typing(); err != nil {
+			return err
+		}
+		for i := 0; i < 2; i++ {
+			profile, err := c.Profile()
+			if err != nil {
+				return err
+			}
+			if profile.Name != "Lan" {
+				t.Errorf("Profile().Name = %q, want Lan", profile.Name)
+			}
+		}
+		if _, err := c.Reply("Xin chào " + c.Text()); err != nil {
+			return err
+		}
+		_, err := c.ReplyImage("https://example.com/a.png", "caption")
+		return err
+	}, trace("first"), trace("second"))
+
+	update := types.Update{Message: &types.Message{
+		MessageID: "m1",
+		Text:      "hi",
+		From:      &types.User{ID: "user-1"},
+		Chat:      &types.Chat{ID: "chat-1"},
+	}}
+	if err := bot.HandleUpdate(context.Background(), update, handler); err != nil {
+		t.Fatalf("HandleUpdate() error = %v", err)
+	}
+
+	if strings.Join(order, ",") != "first,second,handler" {
+		t.Errorf("order = %v, want [first second handler]", order)
+	}
+	if want := "sendChatAction,getUserProfile,sendMessage,sendMessage"; strings.Join(fake.calls, ",") != want {
+		t.Errorf("calls = %v, want %s", fake.calls, want)
+	}
+	if fake.profiles != 1 {
+		t.Errorf("getUserProfile called %d times, want 1", fake.profiles)
+	}
+	if chatID := fake.bodies[2]["chat_id"]; chatID != "chat-1" {
+		t.Errorf("reply chat_id = %v, want chat-1", chatID)
+	}
+	if text := fake.bodies[2]["text"]; text != "Xin chào hi" {
+		t.Errorf("reply text = %v, want Xin chào hi", text)
+	}
+	if handlerCtx.Err() == nil {
+		t.Error("Context not cancelled after the handler returned")
+	}
+
+	t.Run("no chat", func(t *testing.T) {
+		err := bot.HandleUpdate(context.Background(), types.Update{PostbackEvent: &types.PostbackEvent{Payload: "p"}}, bot.Handler(func(c *Context) error {
+			_, err := c.Reply("hello")
+			return err
+		}))
+		if zerr, ok := err.(*types.ZaloBotError); !ok || zerr.Type != types.ErrorTypeValidation {
+			t.Errorf("Reply() without chat error = %v, want validation error", err)
+		}
+	})
+}
+
+func TestContext_CancelledOnClose(t *testing.T) {
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	c, cancel := bot.NewContext(context.Background(), types.Update{})
+	defer cancel()
+
+	bot.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Error("Context not cancelled when the bot was closed")
+	}
+}
+
+func TestContext_Session(t *testing.T) {
+	store := utils.NewMemorySessionStore()
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithSessions(&types.SessionConfig{Store: store}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	update := types.Update{Message: &types.Message{From: &types.User{ID: "u1"}, Chat: &types.Chat{ID: "chat-1"}}}
+	count := func(c *Context) error {
+		session, err := c.Session()
+		if err != nil {
+			return err
+		}
+		n, _ := session.Get("count")
+		next, _ := n.(int)
+		session.Set("count", next+1)
+		return nil
+	}
+
+	handler := bot.Handler(count)
+	for i := 0; i < 3; i++ {
+		if err := handler(context.Background(), update); err != nil {
+			t.Fatalf("handler error = %v", err)
+		}
+	}
+
+	data, _ := store.Load(context.Background(), "chat-1")
+	if data["count"] != 3 {
+		t.Errorf("stored count = %v, want 3", data["count"])
+	}
+
+	bot.Handler(func(c *Context) error {
+		session, _ := c.Session()
+		session.Clear()
+		return nil
+	})(context.Background(), update)
+	if store.Len() != 0 {
+		t.Errorf("store holds %d sessions after Clear, want 0", store.Len())
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		plain, _ := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+		defer plain.Close()
+		if err := plain.Handler(count)(context.Background(), update); err == nil {
+			t.Error("Session() without WithSessions error = nil")
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package zalobot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// ErrDuplicateUpdate is returned by ProcessWebhook for an update already
+// received within the deduplication TTL. The delivery should be
+// acknowledged without handling the update again.
+var ErrDuplicateUpdate = errors.New("duplicate update")
+
+// updateDeduplicator remembers the keys of received updates
+type updateDeduplicator struct {
+	config *types.DedupConfig
+	seen   *utils.ExpiringSet
+}
+
+// newUpdateDeduplicator creates a deduplicator, or returns nil when
+// deduplication is disabled
+func newUpdateDeduplicator(config *types.DedupConfig) *updateDeduplicator {
+	if config == nil {
+		return nil
+	}
+	return &updateDeduplicator{
+		config: config,
+		seen:   utils.NewExpiringSet(config.MaxEntries, config.TTL),
+	}
+}
+
+// UpdateKey returns the idempotency key of an update: its message ID, its
+// update ID, or a hash of its content for events that carry neither
+func UpdateKey(update types.Update) string {
+	if update.Message != nil && update.Message.MessageID != "" {
+		return "message:" + update.Message.MessageID
+	}
+	if update.UpdateID != 0 {
+		return "update:" + strconv.Itoa(update.UpdateID)
+	}
+
+	data, _ := json.Marshal(update)
+	sum := sha256.Sum256(data)
+	return "hash:" + hex.EncodeToString(sum[:16])
+}
+
+// claimUpdate records update as received and reports whether it is new.
+// Duplicates are counted in metrics. A failing store is logged and ignored,
+// so an outage never drops updates.
+func (b *BotAPI) claimUpdate(ctx context.Context, source string, update types.Update) bool {
+	if b.dedup == nil {
+		return true
+	}
+
+	key := b.dedup.config.KeyPrefix + UpdateKey(update)
+	isNew := b.dedup.seen.Add(key)
+	if isNew && b.dedup.config.Store != nil {
+		added, err := b.dedup.config.Store.Add(ctx, key, b.dedup.config.TTL)
+		if err != nil {
+			b.logger().Warn("Deduplication store failed",
+				utils.Field{Key: "key", Value: key},
+				utils.Field{Key: "error", Value: err},
+			)
+		} else {
+			isNew = added
+		}
+	}
+	if isNew {
+		return true
+	}
+
+	eventName := UpdateEventName(update)
+	if metrics, ok := b.metrics().(utils.DedupMetrics); ok {
+		metrics.IncDuplicateUpdate(source, eventName)
+	}
+	b.logger().Debug("Dropped duplicate update",
+		utils.Field{Key: "source", Value: source},
+		utils.Field{Key: "event_name", Value: eventName},
+		utils.Field{Key: "key", Value: key},
+	)
+	return false
+}
+
+// releaseUpdate forgets update after its handler failed, so a redelivery
+// is handled again
+func (b *BotAPI) releaseUpdate(ctx context.Context, update types.Update) {
+	if b.dedup == nil {
+		return
+	}
+
+	key := b.dedup.config.KeyPrefix + UpdateKey(update)
+	b.dedup.seen.Remove(key)
+	if b.dedup.config.Store != nil {
+		if err := b.dedup.config.Store.Remove(ctx, key); err != nil {
+			b.logger().Warn("Deduplication store failed",
+				utils.Field{Key: "key", Value: key},
+				utils.Field{Key: "error", Value: err},
+			)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package zalobot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// memoryDedupStore is a DedupStore shared by several bots in a test
+type memoryDedupStore struct {
+	mu   sync.Mutex
+	keys map[string]bool
+	err  error
+}
+
+func (s *memoryDedupStore) Add(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return false, s.err
+	}
+	if s.keys[key] {
+		return false, nil
+	}
+	s.keys[key] = true
+	return true, nil
+}
+
+func (s *memoryDedupStore) Remove(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key)
+	return s.err
+}
+
+func TestUpdateKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		update types.Update
+		want   string
+	}{
+		{"message ID", types.Update{UpdateID: 7, Message: &types.Message{MessageID: "m1"}}, "message:m1"},
+		{"update ID", types.Update{UpdateID: 7, Message: &types.Message{}}, "update:7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UpdateKey(tt.update); got != tt.want {
+				t.Errorf("UpdateKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("content hash", func(t *testing.T) {
+		a := UpdateKey(types.Update{PostbackEvent: &types.PostbackEvent{Payload: "a"}})
+		b := UpdateKey(types.Update{PostbackEvent: &types.PostbackEvent{Payload: "b"}})
+		if !strings.HasPrefix(a, "hash:") || a == b {
+			t.Errorf("UpdateKey() = %q, %q, want distinct content hashes", a, b)
+		}
+		if again := UpdateKey(types.Update{PostbackEvent: &types.PostbackEvent{Payload: "a"}}); again != a {
+			t.Errorf("UpdateKey() = %q, want stable hash %q", again, a)
+		}
+	})
+}
+
+func TestBotAPI_WebhookHandler_Deduplication(t *testing.T) {
+	metrics := utils.NewPrometheusMetrics("")
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+		types.WithMetrics(metrics),
+		types.WithDeduplication(nil),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+	bot.SetWebhookSecretToken("webhook-secret")
+
+	var calls atomic.Int64
+	var fail atomic.Bool
+	handler := bot.WebhookHandler(func(ctx context.Context, update types.Update) error {
+		calls.Add(1)
+		if fail.Load() {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	deliver := func(messageID string) int {
+		payload := `{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"` + messageID + `","text":"hi"}}}`
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+		req.Header.Set(bot.GetFieldSecretToken(), "webhook-secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("concurrent redeliveries run the handler once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if code := deliver("m1"); code != http.StatusOK {
+					t.Errorf("status = %d, want 200", code)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("handler ran %d times, want 1", calls.Load())
+		}
+
+		var b strings.Builder
+		metrics.WriteTo(&b)
+		want := `zalobot_duplicate_updates_total{source="webhook",event_name="message.text.received"} 9`
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	})
+
+	t.Run("failed update is handled on redelivery", func(t *testing.T) {
+		calls.Store(0)
+		fail.Store(true)
+		if code := deliver("m2"); code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want 500", code)
+		}
+		fail.Store(false)
+		if code := deliver("m2"); code != http.StatusOK {
+			t.Errorf("status = %d, want 200", code)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("handler ran %d times, want 2", calls.Load())
+		}
+	})
+}
+
+func TestBotAPI_Deduplication_Store(t *testing.T) {
+	store := &memoryDedupStore{keys: make(map[string]bool)}
+	payload := []byte(`{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"hi"}}}`)
+
+	var bots []*BotAPI
+	for i := 0; i < 2; i++ {
+		bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+			types.WithDeduplication(&types.DedupConfig{Store: store}),
+			types.WithLogger(utils.NewNoOpLogger()),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer bot.Close()
+		bot.SetWebhookSecretToken("webhook-secret")
+		bots = append(bots, bot)
+	}
+
+	if _, err := bots[0].ProcessWebhook(payload, "webhook-secret"); err != nil {
+		t.Fatalf("ProcessWebhook() error = %v", err)
+	}
+	if _, err := bots[1].ProcessWebhook(payload, "webhook-secret"); !errors.Is(err, ErrDuplicateUpdate) {
+		t.Errorf("ProcessWebhook() on another replica error = %v, want ErrDuplicateUpdate", err)
+	}
+
+	// Another bot sharing the store has keys of its own
+	otherBot, err := New("654321:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+		types.WithDeduplication(&types.DedupConfig{Store: store}),
+		types.WithLogger(utils.NewNoOpLogger()),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer otherBot.Close()
+	otherBot.SetWebhookSecretToken("webhook-secret")
+	if _, err := otherBot.ProcessWebhook(payload, "webhook-secret"); err != nil {
+		t.Errorf("ProcessWebhook() on another bot error = %v, want the update handled", err)
+	}
+
+	// A store outage never drops updates
+	store.mu.Lock()
+	store.err = errors.New("store unavailable")
+	store.mu.Unlock()
+	other := []byte(strings.Replace(string(payload), "m1", "m2", 1))
+	if _, err := bots[1].ProcessWebhook(other, "webhook-secret"); err != nil {
+		t.Errorf("ProcessWebhook() with failing store error = %v", err)
+	}
+}
+
+func TestBotAPI_Deduplication_Disabled(t *testing.T) {
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+	bot.SetWebhookSecretToken("webhook-secret")
+
+	payload := []byte(`{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"hi"}}}`)
+	for i := 0; i < 2; i++ {
+		if _, err := bot.ProcessWebhook(payload, "webhook-secret"); err != nil {
+			t.Errorf("ProcessWebhook() error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package zalobot
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// CommandArgsKey is the Context key under which a Command filter stores the
+// text typed after the command
+const CommandArgsKey = "command_args"
+
+// Filter reports whether a route applies to the update in c. Filters may
+// store what they matched with c.Set for the handler.
+type Filter func(c *Context) bool
+
+// dispatchRoute is a handler with the filters that select it
+type dispatchRoute struct {
+	filters []Filter
+	handler ContextHandler
+}
+
+// Dispatcher routes each update to the first handler registered for it, in
+// registration order. Routes may be added while the bot is running.
+type Dispatcher struct {
+	bot *BotAPI
+
+	mu         sync.RWMutex
+	routes     []dispatchRoute
+	middleware []Middleware
+	fallback   ContextHandler
+
+	classifier    *utils.IntentClassifier
+	minConfidence float64
+}
+
+// NewDispatcher creates a Dispatcher for bot
+func NewDispatcher(bot *BotAPI) *Dispatcher {
+	return &Dispatcher{bot: bot}
+}
+
+// Use adds middleware run around every handler, including the fallback.
+// It applies to handlers returned by Handler afterwards.
+func (d *Dispatcher) Use(middleware ...Middleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.middleware = append(d.middleware, middleware...)
+}
+
+// Handle registers handler for updates that pass all filters. A route
+// without filters matches every update.
+func (d *Dispatcher) Handle(handler ContextHandler, filters ...Filter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.routes = append(d.routes, dispatchRoute{filters: filters, handler: handler})
+}
+
+// OnCommand registers handler for messages starting with command, as
+// matched by Command
+func (d *Dispatcher) OnCommand(command string, handler ContextHandler) {
+	d.Handle(handler, Command(command))
+}
+
+// OnKeyword registers handler for messages containing keyword, as matched
+// by Keyword
+func (d *Dispatcher) OnKeyword(keyword string, handler ContextHandler) {
+	d.Handle(handler, Keyword(keyword))
+}
+
+// OnPostback registers handler for postbacks with payload
+func (d *Dispatcher) OnPostback(payload string, handler ContextHandler) {
+	d.Handle(handler, Postback(payload))
+}
+
+// Fallback sets the handler for updates no route matches. Without one,
+// such updates are ignored.
+func (d *Dispatcher) Fallback(handler ContextHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.fallback = handler
+}
+
+// Handler returns an UpdateHandler that dispatches updates through the
+// dispatcher's middleware. Pass it to Run, HandleUpdate or Replay.
+func (d *Dispatcher) Handler() UpdateHandler {
+	d.mu.RLock()
+	middleware := append([]Middleware(nil), d.middleware...)
+	d.mu.RUnlock()
+
+	return d.bot.Handler(d.Dispatch, middleware...)
+}
+
+// Dispatch runs the handler of the first route matching c, or the fallback
+func (d *Dispatcher) Dispatch(c *Context) error {
+	d.mu.RLock()
+	routes := d.routes
+	fallback := d.fallback
+	d.mu.RUnlock()
+
+	for _, route := range routes {
+		if route.matches(c) {
+			return route.handler(c)
+		}
+	}
+	if fallback != nil {
+		return fallback(c)
+	}
+
+	d.bot.logger().Debug("No handler matched update",
+		utils.Field{Key: "event", Value: UpdateEventName(c.Update)},
+	)
+	return nil
+}
+
+// matches reports whether all filters of the route pass
+func (r dispatchRoute) matches(c *Context) bool {
+	for _, filter := range r.filters {
+		if !filter(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Command matches messages that start with one of names, with or without a
+// leading slash. Matching ignores case, accents and punctuation, and also
+// accepts text typed in Telex or VNI without an IME, so "/Đơn hàng 42",
+// "don hang 42" and "ddown hangf 42" all match "đơn hàng". The text after
+// the command is stored under CommandArgsKey.
+func Command(names ...string) Filter {
+	keys := matchKeys(names)
+
+	return func(c *Context) bool {
+		for _, form := range c.matchForms() {
+			for _, key := range keys {
+				if form == key || strings.HasPrefix(form, key+" ") {
+					c.Set(CommandArgsKey, commandArgs(c.Text(), key))
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// Keyword matches messages containing one of keywords as whole words,
+// ignoring case, accents and punctuation as Command does: "giá" matches
+// "Cho hỏi GIÁ bao nhiêu?" but not "giáo viên"
+func Keyword(keywords ...string) Filter {
+	keys := matchKeys(keywords)
+
+	return func(c *Context) bool {
+		for _, form := range c.matchForms() {
+			for _, key := range keys {
+				if containsPhrase(form, key) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// Postback matches postback events with payload
+func Postback(payload string) Filter {
+	return func(c *Context) bool {
+		return c.Update.PostbackEvent != nil && c.Update.PostbackEvent.Payload == payload
+	}
+}
+
+// Event matches updates with one of the event names, as returned by
+// UpdateEventName
+func Event(names ...string) Filter {
+	return func(c *Context) bool {
+		event := UpdateEventName(c.Update)
+		for _, name := range names {
+			if event == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchKeys normalizes the phrases a filter matches, dropping empty ones
+func matchKeys(phrases []string) []string {
+	keys := make([]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		if key := utils.NormalizeForMatch(phrase); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// matchForms returns the normalized forms of the message text that filters
+// match against, computed once per update
+func (c *Context) matchForms() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.forms == nil {
+		c.forms = matchForms(c.Text())
+	}
+	return c.forms
+}
+
+// matchForms returns the distinct normalized forms of text: as typed, and
+// decoded from Telex and from VNI. Decoded forms are only added for text
+// typed without diacritics whose decoded words are all Vietnamese, so
+// English such as "cart" or "is this free" is not read as Telex.
+func matchForms(text string) []string {
+	forms := []string{}
+	if text == "" {
+		return forms
+	}
+	candidates := []string{text}
+	if utils.RemoveDiacritics(text) == text {
+		for _, decoded := range []string{utils.DecodeTelex(text), utils.DecodeVNI(text)} {
+			if decoded != text && decodesToVietnamese(text, decoded) {
+				candidates = append(candidates, decoded)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, form := range candidates {
+		form = utils.NormalizeForMatch(form)
+		if form != "" && !seen[form] {
+			seen[form] = true
+			forms = append(forms, form)
+		}
+	}
+	return forms
+}
+
+// decodesToVietnamese reports whether every word that decoding changed is a
+// Vietnamese syllable. Words left as typed, such as "shop" or "A1", are not
+// checked.
+func decodesToVietnamese(text, decoded string) bool {
+	typedWords := strings.FieldsFunc(text, notWordRune)
+	decodedWords := strings.FieldsFunc(decoded, notWordRune)
+	if len(typedWords) != len(decodedWords) {
+		return false
+	}
+	for i, word := range decodedWords {
+		if word != typedWords[i] && !utils.IsVietnameseSyllable(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// notWordRune reports whether r separates words
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// containsPhrase reports whether the normalized form contains key as whole
+// words
+func containsPhrase(form, key string) bool {
+	return strings.Contains(" "+form+" ", " "+key+" ")
+}
+
+// commandArgs returns the text after the words of a matched command key
+func commandArgs(text, key string) string {
+	remaining := len(strings.Fields(key))
+	fields := strings.Fields(text)
+	for i, field := range fields {
+		remaining -= len(strings.Fields(utils.NormalizeForMatch(field)))
+		if remaining <= 0 {
+			return strings.Join(fields[i+1:], " ")
+		}
+	}
+	return ""
+}
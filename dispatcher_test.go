@@ -0,0 +1,177 @@
+package zalobot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+func newDispatcherTestBot(t *testing.T) *BotAPI {
+	t.Helper()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { bot.Close() })
+	return bot
+}
+
+func textUpdate(text string) types.Update {
+	return types.Update{Message: &types.Message{
+		Text: text,
+		From: &types.User{ID: "user-1"},
+		Chat: &types.Chat{ID: "chat-1"},
+	}}
+}
+
+func TestCommand(t *testing.T) {
+	bot := newDispatcherTestBot(t)
+
+	tests := []struct {
+		command string
+		text    string
+		match   bool
+		args    string
+	}{
+		{"start", "/start", true, ""},
+		{"start", "start", true, ""},
+		{"/start", "START now", true, "now"},
+		{"start", "/started", false, ""},
+		{"start", "please start", false, ""},
+		{"đơn hàng", "/Đơn hàng 42", true, "42"},
+		{"đơn hàng", "don hang 42", true, "42"},
+		{"đơn hàng", "DON HANG", true, ""},
+		{"đơn hàng", "ddown hangf 42", true, "42"},
+		{"đơn hàng", "d9o7n ha2ng 42", true, "42"},
+		{"đơn hàng", "đơn   hàng,  số 42", true, "số 42"},
+		{"đơn hàng", "đơn", false, ""},
+		{"hủy", "Hủy đơn 7", true, "đơn 7"},
+		{"order-status", "/order-status A1", true, "A1"},
+		// English is not read as Telex: "start" would decode to "stảt"
+		{"stat", "/start", false, ""},
+		{"fi", "fix it", false, ""},
+	}
+
+	for _, tt := range tests {
+		c, cancel := bot.NewContext(context.Background(), textUpdate(tt.text))
+		got := Command(tt.command)(c)
+		cancel()
+
+		if got != tt.match {
+			t.Errorf("Command(%q) on %q = %v, want %v", tt.command, tt.text, got, tt.match)
+			continue
+		}
+		if got && c.GetString(CommandArgsKey) != tt.args {
+			t.Errorf("Command(%q) on %q args = %q, want %q", tt.command, tt.text, c.GetString(CommandArgsKey), tt.args)
+		}
+	}
+}
+
+func TestKeyword(t *testing.T) {
+	bot := newDispatcherTestBot(t)
+
+	tests := []struct {
+		keyword string
+		text    string
+		match   bool
+	}{
+		{"giá", "Cho hỏi GIÁ bao nhiêu?", true},
+		{"giá", "cho hoi gia bao nhieu", true},
+		{"giá", "cho hoir gias bao nhieeu", true},
+		{"giá", "giáo viên", false},
+		{"giao hàng", "Bao giờ giao hàng vậy?", true},
+		{"giao hàng", "giao hang", true},
+		{"giao hàng", "hàng giao", false},
+		{"giá", "", false},
+		// English is not read as Telex or VNI
+		{"cat", "add to cart", false},
+		{"thi", "is this free", false},
+		{"a", "as soon as possible", false},
+		{"chào", "chaof shop", true},
+		{"giá", "gias shop", true},
+	}
+
+	for _, tt := range tests {
+		c, cancel := bot.NewContext(context.Background(), textUpdate(tt.text))
+		if got := Keyword(tt.keyword)(c); got != tt.match {
+			t.Errorf("Keyword(%q) on %q = %v, want %v", tt.keyword, tt.text, got, tt.match)
+		}
+		cancel()
+	}
+}
+
+func TestDispatcher_Routes(t *testing.T) {
+	bot := newDispatcherTestBot(t)
+	dispatcher := NewDispatcher(bot)
+
+	var got []string
+	route := func(name string) ContextHandler {
+		return func(c *Context) error {
+			got = append(got, name)
+			return nil
+		}
+	}
+	dispatcher.OnCommand("start", route("start"))
+	dispatcher.OnKeyword("giá", route("price"))
+	dispatcher.OnKeyword("giá cả", route("unreachable"))
+	dispatcher.OnPostback("MENU", route("menu"))
+	dispatcher.Handle(route("join"), Event("user_action"))
+
+	var order []string
+	dispatcher.Use(func(next ContextHandler) ContextHandler {
+		return func(c *Context) error {
+			order = append(order, "middleware")
+			return next(c)
+		}
+	})
+	handler := dispatcher.Handler()
+
+	updates := []types.Update{
+		textUpdate("/start"),
+		textUpdate("giá cả thế nào"),
+		{PostbackEvent: &types.PostbackEvent{Payload: "MENU"}},
+		{UserAction: &types.UserAction{Type: types.UserActionTypeJoin, UserID: "user-1"}},
+		textUpdate("không khớp"),
+	}
+	for _, update := range updates {
+		if err := handler(context.Background(), update); err != nil {
+			t.Fatalf("handler() error = %v", err)
+		}
+	}
+
+	want := []string{"start", "price", "menu", "join"}
+	if len(got) != len(want) {
+		t.Fatalf("routes = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("routes = %v, want %v", got, want)
+			break
+		}
+	}
+	if len(order) != len(updates) {
+		t.Errorf("middleware ran %d times, want %d", len(order), len(updates))
+	}
+}
+
+func TestDispatcher_Fallback(t *testing.T) {
+	bot := newDispatcherTestBot(t)
+	dispatcher := NewDispatcher(bot)
+	dispatcher.OnCommand("start", func(c *Context) error { return nil })
+
+	c, cancel := bot.NewContext(context.Background(), textUpdate("hello"))
+	defer cancel()
+
+	if err := dispatcher.Dispatch(c); err != nil {
+		t.Errorf("Dispatch() without fallback error = %v", err)
+	}
+
+	errHelp := errors.New("help")
+	dispatcher.Fallback(func(c *Context) error { return errHelp })
+	if err := dispatcher.Dispatch(c); !errors.Is(err, errHelp) {
+		t.Errorf("Dispatch() error = %v, want fallback error", err)
+	}
+}
@@ -68,6 +68,18 @@
 //
 // No additional Authorization headers are required.
 //
+// Instead of a fixed token, a CredentialProvider can supply it. When the API
+// rejects the token, the provider is asked once for a new one and the request
+// is repeated; the token is swapped atomically without recreating the bot:
+//
+//	bot, err := zalobot.New("",
+//		types.WithCredentialProvider(auth.NewFileCredentials("/run/secrets/zalo_token")),
+//	)
+//
+// The auth package provides StaticCredentials, EnvCredentials, FileCredentials
+// (re-read when the file changes) and CredentialFunc for custom sources. A
+// token can also be replaced directly with bot.RotateToken.
+//
 // # Polling for Updates
 //
 // Use polling to receive updates from Zalo:
@@ -89,6 +101,29 @@
 //	    }
 //	}
 //
+// The channel holds 100 updates by default. When it is full, polling waits
+// for the consumer; UpdateConfig.Buffer can instead drop the oldest or the
+// newest update, or spill updates to a file until the consumer catches up:
+//
+//	updateConfig.Buffer = types.BufferConfig{
+//	    Size:     500,
+//	    Overflow: types.OverflowSpill,
+//	    SpillDir: "/var/lib/bot/spill",
+//	}
+//
+// SubscribeUpdates adds further consumers, each receiving every update
+// through its own buffer. Subscription channels close when polling stops:
+//
+//	audit, err := bot.SubscribeUpdates(types.BufferConfig{
+//	    Name:     "audit",
+//	    Overflow: types.OverflowDropOldest,
+//	})
+//	go func() {
+//	    for update := range audit.C() {
+//	        auditLog.Write(update)
+//	    }
+//	}()
+//
 // # Webhooks
 //
 // Set up webhooks for real-time event processing:
@@ -103,6 +138,51 @@
 //	    SecretToken: "your-webhook-secret",
 //	})
 //
+// WebhookConfig also limits concurrent deliveries, filters the delivered
+// events and discards queued updates. A self-signed certificate is uploaded
+// from a file (Certificate) or from memory (CertificateData):
+//
+//	err := bot.SetWebhook(types.WebhookConfig{
+//	    URL:                "https://your-domain.com/webhook",
+//	    SecretToken:        "your-webhook-secret",
+//	    Certificate:        "/etc/ssl/bot.pem",
+//	    MaxConnections:     40,
+//	    AllowedUpdates:     []string{types.EventMessageText, types.EventMessageImage},
+//	    DropPendingUpdates: true,
+//	})
+//
+// Rotate the secret without dropping deliveries: RotateWebhookSecret
+// generates a strong secret, registers it with SetWebhook, and keeps accepting
+// the old one until the overlap window ends. Secrets are compared in constant
+// time.
+//
+//	secret, err := bot.RotateWebhookSecret("", 10*time.Minute)
+//
+// Zalo delivers updates at least once and retries webhooks acknowledged
+// slowly. With deduplication, an update seen within the TTL, keyed by message
+// ID, update ID or content hash, is acknowledged without running the handler
+// again. A DedupStore shares the keys across replicas and restarts; keys are
+// prefixed with the bot ID, so different bots can share one store:
+//
+//	bot, err := zalobot.New(token, types.WithDeduplication(&types.DedupConfig{
+//	    TTL:   10 * time.Minute,
+//	    Store: redisStore,
+//	}))
+//
+// A webhook watchdog checks the registration with GetWebhookInfo, registers
+// the webhook again when its URL drifted or it disappeared, and reports
+// delivery errors and update backlogs. Its status is part of Health:
+//
+//	watchdog, err := bot.StartWebhookWatchdog(zalobot.WatchdogConfig{
+//	    Webhook:           types.WebhookConfig{URL: "https://your-domain.com/webhook"},
+//	    Interval:          time.Minute,
+//	    MaxPendingUpdates: 100,
+//	    OnEvent: func(event zalobot.WatchdogEvent) {
+//	        alert(event.Type, event.Message)
+//	    },
+//	})
+//	defer watchdog.Stop()
+//
 // Process webhook requests in your HTTP handler:
 //
 //	func webhookHandler(w http.ResponseWriter, r *http.Request) {
@@ -126,6 +206,197 @@
 //	    json.NewEncoder(w).Encode(map[string]bool{"ok": true})
 //	}
 //
+// # Running a Bot
+//
+// Run passes updates from an UpdateSource to a handler until the context is
+// cancelled or the process receives SIGINT or SIGTERM. PollingSource deletes
+// a registered webhook first; WebhookSource registers the webhook and serves
+// it, shutting the server down gracefully. The handler is the same in both
+// modes, so the mode can follow configuration:
+//
+//	source := zalobot.NewUpdateSource(settings.WebhookConfig(), ":8443")
+//	err := bot.Run(context.Background(), source, func(ctx context.Context, update types.Update) error {
+//	    return handle(ctx, update)
+//	})
+//
+// # Handler Context
+//
+// Handler wraps a ContextHandler into an UpdateHandler. The Context replies
+// to the chat the update came from, fetches the sender's profile on first
+// use, and is cancelled when the handler returns or the bot is closed.
+// Middleware runs before the handler and passes data on with Set and Get:
+//
+//	bot, err := zalobot.New(botToken, types.WithSessions(nil))
+//
+//	auth := func(next zalobot.ContextHandler) zalobot.ContextHandler {
+//	    return func(c *zalobot.Context) error {
+//	        c.Set("customer", lookupCustomer(c.UserID()))
+//	        return next(c)
+//	    }
+//	}
+//
+//	handler := bot.Handler(func(c *zalobot.Context) error {
+//	    session, err := c.Session()
+//	    if err != nil {
+//	        return err
+//	    }
+//	    session.Set("last_text", c.Text())
+//	    _, err = c.Reply("Xin chào!")
+//	    return err
+//	}, auth)
+//
+//	err = bot.Run(ctx, source, handler)
+//
+// Sessions hold per-chat data between updates and are saved when the
+// handler returns. They live in memory unless SessionConfig.Store is set.
+//
+// WithChatAction keeps a chat action such as typing visible during slow
+// work, re-sending it every four seconds until the work is done.
+// HumanDelay also holds it long enough for the reply to look typed:
+//
+//	var reply string
+//	err := c.WithTyping(func(ctx context.Context) (err error) {
+//	    reply, err = lookupOrder(ctx, c.Text())
+//	    return err
+//	}, zalobot.HumanDelay(&reply))
+//	if err == nil {
+//	    c.Reply(reply)
+//	}
+//
+// # Routing
+//
+// A Dispatcher sends each update to the first route that matches it.
+// Commands and keywords match regardless of case, accents and punctuation,
+// and also when typed in Telex or VNI without an input method, so users
+// who write "don hang 42" or "ddown hangf 42" reach the same handler. Text
+// is only decoded when the decoded words are Vietnamese, so English such as
+// "add to cart" is left alone:
+//
+//	dispatcher := zalobot.NewDispatcher(bot)
+//	dispatcher.OnCommand("đơn hàng", func(c *zalobot.Context) error {
+//	    _, err := c.Reply("Đang tra cứu đơn " + c.GetString(zalobot.CommandArgsKey))
+//	    return err
+//	})
+//	dispatcher.OnKeyword("giá", showPrices)
+//	dispatcher.OnPostback("MENU", showMenu)
+//	dispatcher.Fallback(showHelp)
+//
+//	err = bot.Run(ctx, source, dispatcher.Handler())
+//
+// The normalization is available in utils as NormalizeNFC, NormalizeNFD,
+// RemoveDiacritics, FoldCase, DecodeTelex, DecodeVNI, IsVietnameseSyllable
+// and NormalizeForMatch.
+//
+// An AutoResponder answers frequent questions from rules in a YAML file.
+// Rules match keywords, regular expressions or fuzzy phrases that tolerate
+// typos, and reply with text, a template or a structured message. A rule
+// with a cooldown stays silent in a chat for a while after replying there:
+//
+//	faq := zalobot.NewAutoResponder(bot, zalobot.AutoResponderConfig{
+//	    Templates: templates,
+//	    Fallback:  forwardToAgent,
+//	})
+//	if err := faq.LoadFile("faq.yaml"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	go faq.Watch(ctx, 5*time.Second) // reload when the file changes
+//
+//	dispatcher.Fallback(faq.Handle)
+//
+// For free-text messages that keywords miss, OnIntent routes on the intent
+// a local naive Bayes classifier finds in the message. It trains in-process
+// on example utterances, in Vietnamese or English, from a YAML file of
+// intent: [utterances] lists, and is saved as a model file. A message
+// whose words mostly never appear in the examples matches no intent. The
+// cmd/zalobot-intent tool trains and tests models from the command line:
+//
+//	examples, err := utils.LoadIntentExamples("intents.yaml")
+//	classifier, err := utils.TrainIntentClassifier(examples)
+//	err = classifier.Save("intents.json")
+//
+//	classifier, err := utils.LoadIntentClassifier("intents.json")
+//	dispatcher.UseIntents(classifier, 0.6)
+//	dispatcher.OnIntent("order_status", func(c *zalobot.Context) error {
+//	    prediction, _ := c.Get(zalobot.IntentKey)
+//	    ...
+//	})
+//
+// # Message Templates
+//
+// A TemplateRegistry keeps customer-facing copy per locale, in files named
+// <locale>/<name>.tmpl or in <locale>.yaml bundles of name: template
+// pairs. Templates use text/template with vnd, number, date, datetime and
+// longdate functions that format the Vietnamese way:
+//
+//	templates := zalobot.NewTemplateRegistry(zalobot.TemplateConfig{DefaultLocale: "vi"})
+//	if err := templates.LoadDir("templates"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := templates.Check(); err != nil {
+//	    log.Fatal(err) // a template is missing from a locale
+//	}
+//
+//	// templates/vi/order_total.tmpl: Tổng cộng {{vnd .Total}}
+//	templates.Reply(c, "order_total", order)
+//
+// Reply renders in the locale set under LocaleKey on the Context, falling
+// back to the default locale. Text output is tidied with utils.FormatMessage
+// and checked with utils.ValidateMessageContent. RenderMessage parses the
+// output of a template as a YAML StructuredMessage instead.
+//
+// # Recording and Replay
+//
+// A recorder captures every webhook body and non-empty getUpdates response
+// before parsing, as JSONL in rotating files, with the sender's name and
+// avatar, other personal data fields and tokens redacted:
+//
+//	recorder, err := utils.NewRecorder(utils.RecorderConfig{
+//	    Rotate:  utils.RotateConfig{Filename: "/var/lib/bot/updates.jsonl", MaxSize: 100 << 20, Compress: true},
+//	    Secrets: []string{webhookSecret},
+//	})
+//	bot, err := zalobot.New(token, types.WithUpdateRecorder(recorder))
+//
+// Replay feeds a capture back through the parser and a handler, at the
+// original pace (Speed 1), faster, or without delays (Speed 0):
+//
+//	result, err := bot.ReplayFile(ctx, "updates.jsonl", zalobot.ReplayConfig{Speed: 10}, handler)
+//
+// The zalobot-replay command prints the parsed updates of a capture, failing
+// on parse errors, or posts its webhook records to a running bot.
+//
+// # Multiple Bots
+//
+// BotManager runs many bots in one process with a shared HTTP client and a
+// rate limiter per token. Updates from every bot arrive on one channel,
+// tagged with the key the bot was added under:
+//
+//	manager := zalobot.NewBotManager(zalobot.ManagerConfig{RateLimit: 10})
+//	defer manager.Close()
+//
+//	manager.Add("shop-a", tokenA)
+//	manager.Add("shop-b", tokenB)
+//
+//	for update := range manager.StartPolling(types.UpdateConfig{Timeout: 30}) {
+//	    manager.HandleUpdate(ctx, update, handleUpdate) // zalobot.BotKeyFromContext(ctx) == update.BotKey
+//	}
+//
+// Bots can be added, removed or given a new token with SwapToken while the
+// manager is running.
+//
+// To serve webhooks for all bots from one HTTP server, route
+// /webhook/{botKey} through a WebhookRouter. Each route has its own secret,
+// body-size and concurrency limits, and Reconcile registers every bot's
+// webhook URL with Zalo:
+//
+//	router := zalobot.NewWebhookRouter(zalobot.RouterConfig{
+//	    BaseURL: "https://bots.example.com",
+//	    Manager: manager,
+//	})
+//	router.Handle("shop-a", nil, zalobot.RouteConfig{Handler: handleUpdate, SecretToken: secretA})
+//	router.Reconcile(ctx)
+//
+//	http.Handle("/webhook/", router)
+//
 // # Rich Media Messages
 //
 // Send images, files, videos, and audio:
@@ -192,6 +463,12 @@
 //	fmt.Printf("ID: %s\n", profile.ID)
 //	fmt.Printf("Avatar: %s\n", profile.Avatar)
 //
+// GetMe returns the bot's own identity. The result is cached for BotInfoTTL
+// (default 5 minutes) and, once fetched, is set as the sender of sent messages:
+//
+//	me, err := bot.GetMe(ctx)
+//	fmt.Printf("Bot: %s (%s)\n", me.AccountName, me.ID)
+//
 // # Error Handling
 //
 // The SDK provides typed errors for better error handling:
@@ -243,6 +520,102 @@
 //
 //	bot, err := zalobot.New(botToken, zalobot.WithRetryConfig(retryConfig))
 //
+// Change timeouts, retries, debug logging, the environment or the webhook
+// secret of a running bot with Reconfigure. The new settings are published to
+// every service as one snapshot, so concurrent requests never see a mix:
+//
+//	timeout := 10 * time.Second
+//	err := bot.Reconfigure(zalobot.RuntimeConfig{Timeout: &timeout})
+//
+// Every request carries the User-Agent "Go-Zalo-Bot-SDK/<version>", where the
+// version is embedded at build time. Append your own product to it with
+// types.WithAppIdentifier("shop-bot/2.1").
+//
+// Load configuration from a YAML or JSON file and ZALO_BOT_* environment
+// variables (ZALO_BOT_TOKEN, ZALO_BOT_TIMEOUT, ZALO_BOT_WEBHOOK_URL, ...):
+//
+//	settings, err := types.LoadConfig("bot.yaml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	bot, err := zalobot.New(settings.BotToken, settings.Options()...)
+//
+// The options make the bot check the webhook secret token from the settings;
+// register the webhook with bot.SetWebhook(settings.WebhookConfig()).
+//
+// # Logging
+//
+// The SDK logs API requests, retries, polling errors and rejected webhook
+// requests through a utils.Logger. Nothing is logged by default; WithDebug
+// enables a text logger on stderr at LogLevel (debug unless set with
+// WithLogLevel, which requires WithDebug). Bot tokens are redacted from
+// logged URLs. Plug in your own logger with WithLogger:
+//
+//	logger := utils.NewLogger(utils.LogConfig{
+//	    Level:  utils.LogLevelWarn,
+//	    Format: utils.LogFormatJSON,
+//	})
+//	bot, err := zalobot.New(botToken, types.WithLogger(logger))
+//
+// Applications using log/slog can pass utils.NewSlogLogger(slog.Default()),
+// or route slog through the SDK logger with utils.NewSlogHandler. Log files
+// can be rotated by size or age with utils.NewRotatingFileWriter as
+// LogConfig.Output, and utils.NewSampledLogger thins out repeated debug lines.
+//
+// # Health Checks
+//
+// Expose liveness and readiness probes for container orchestrators:
+//
+//	bot, err := zalobot.New(botToken, types.WithHealthConfig(&types.HealthConfig{
+//	    MaxConsecutiveErrors: 5,
+//	    MaxUpdateAge:         10 * time.Minute,
+//	    CheckCredentials:     true,
+//	}))
+//
+//	http.Handle("/livez", bot.LivenessHandler())
+//	http.Handle("/readyz", bot.ReadinessHandler())
+//	http.Handle("/healthz", bot.HealthHandler())
+//
+// The bot is live while it is open and polling makes progress. It is ready
+// once it is polling or has a webhook set and the HealthConfig checks pass.
+// HealthHandler reports the full status, answering 503 only when not live.
+//
+// # Metrics
+//
+// Collect API, update and handler metrics and expose them to Prometheus
+// without any extra dependency:
+//
+//	metrics := utils.NewPrometheusMetrics("zalobot")
+//	bot, err := zalobot.New(botToken, types.WithMetrics(metrics))
+//
+//	http.Handle("/metrics", metrics)
+//
+// The update queue depth of every polling consumer and the updates dropped
+// by overflow policies are exported as well.
+//
+// Run handlers through HandleUpdate to record their latency:
+//
+//	for update := range bot.GetUpdatesChan(updateConfig) {
+//	    bot.HandleUpdate(ctx, update, handleUpdate)
+//	}
+//
+// # Tracing
+//
+// Plug any tracing backend in by implementing utils.Tracer. Spans are started
+// for every API call, webhook delivery and handler run, and the span context
+// travels in the context passed to handlers, so replies sent with the
+// *WithContext methods join the trace of the update they answer:
+//
+//	bot, err := zalobot.New(botToken, types.WithTracer(myTracer))
+//
+//	http.Handle("/webhook", bot.WebhookHandler(func(ctx context.Context, update types.Update) error {
+//	    _, err := bot.SendMessageWithContext(ctx, types.MessageConfig{
+//	        ChatID: update.Message.Chat.ID,
+//	        Text:   "Got it",
+//	    })
+//	    return err
+//	}))
+//
 // # Examples
 //
 // The SDK includes comprehensive examples:
@@ -262,6 +635,7 @@
 //   - services - Service implementations for messages, users, and webhooks
 //   - auth - Authentication and token management
 //   - utils - Utility functions and helpers
+//   - cmd/zalobot-replay - Command that replays update captures
 //
 // # Best Practices
 //
@@ -0,0 +1,259 @@
+package zalobot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/services"
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// Update sources reported to metrics
+const (
+	UpdateSourceWebhook = "webhook"
+	UpdateSourcePolling = "polling"
+)
+
+// UpdateHandler handles a single incoming update
+type UpdateHandler func(ctx context.Context, update types.Update) error
+
+// HandleUpdate runs handler for update, recording its execution time and
+// outcome. The handler runs in a span that is a child of any span carried by
+// ctx, so replies sent with the handler's ctx are linked to the update.
+// A panic in the handler is recovered and returned as an error.
+func (b *BotAPI) HandleUpdate(ctx context.Context, update types.Update, handler UpdateHandler) (err error) {
+	if handler == nil {
+		return nil
+	}
+
+	eventName := UpdateEventName(update)
+	start := time.Now()
+
+	ctx, span := b.tracer().Start(ctx, "zalobot.handle_update", updateSpanFields(update)...)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("update handler panicked: %v", r)
+		}
+
+		outcome := utils.OutcomeSuccess
+		if err != nil {
+			outcome = utils.OutcomeError
+		}
+		b.metrics().ObserveHandler(eventName, outcome, time.Since(start))
+		endSpan(span, err)
+
+		if err != nil {
+			b.logger().Error("Update handler failed",
+				utils.Field{Key: "event_name", Value: eventName},
+				utils.Field{Key: "error", Value: err},
+			)
+		}
+	}()
+
+	return handler(ctx, update)
+}
+
+// UpdateEventName returns the event name of an update. Webhook updates carry
+// it explicitly; for polled updates it is derived from the populated field.
+func UpdateEventName(update types.Update) string {
+	switch {
+	case update.EventName != "":
+		return update.EventName
+	case update.Message != nil:
+		return "message"
+	case update.PostbackEvent != nil:
+		return "postback"
+	case update.UserAction != nil:
+		return "user_action"
+	default:
+		return "unknown"
+	}
+}
+
+// metrics returns the configured metrics collector, or a no-op collector
+func (b *BotAPI) metrics() utils.Metrics {
+	config := b.currentConfig()
+	if config.Metrics == nil {
+		return utils.NewNoOpMetrics()
+	}
+	return config.Metrics
+}
+
+// observeAPIRequest records and logs a direct API call made by BotAPI outside
+// the services
+func (b *BotAPI) observeAPIRequest(method string, start time.Time, err error) {
+	duration := time.Since(start)
+	b.metrics().ObserveAPIRequest(method, services.Outcome(err), duration)
+	if zaloBotErr, ok := err.(*types.ZaloBotError); ok && zaloBotErr.Type == types.ErrorTypeRateLimit {
+		b.metrics().IncRateLimit(method)
+	}
+
+	if err != nil {
+		b.logger().Warn("Zalo API request failed",
+			utils.Field{Key: "method", Value: method},
+			utils.Field{Key: "duration", Value: duration},
+			utils.Field{Key: "error", Value: err},
+		)
+		return
+	}
+	b.logger().Debug("Zalo API request",
+		utils.Field{Key: "method", Value: method},
+		utils.Field{Key: "duration", Value: duration},
+	)
+}
+
+// logger returns the configured logger, or a no-op logger
+func (b *BotAPI) logger() utils.Logger {
+	config := b.currentConfig()
+	if config.Logger == nil {
+		return utils.NewNoOpLogger()
+	}
+	return config.Logger
+}
+
+// observeUpdate records a received update and, for polled messages, the lag
+// between the message date and now
+func (b *BotAPI) observeUpdate(source string, update types.Update) {
+	metrics := b.metrics()
+	metrics.IncUpdateReceived(source, UpdateEventName(update))
+
+	if source == UpdateSourcePolling && update.Message != nil && !update.Message.Date.IsZero() {
+		metrics.ObservePollingLag(time.Since(update.Message.Date))
+	}
+}
+
+// waitRateLimit blocks until the configured rate limiter admits a direct API call
+func (b *BotAPI) waitRateLimit(ctx context.Context) error {
+	config := b.currentConfig()
+	if config.RateLimiter == nil {
+		return nil
+	}
+	return config.RateLimiter.Wait(ctx)
+}
+
+// withTokenRefresh runs request and, if the token was rejected and the
+// credential provider returns a new one, runs it once more
+func (b *BotAPI) withTokenRefresh(ctx context.Context, request func() error) error {
+	token := b.authService.GetToken()
+	err := request()
+	if err != nil && b.authService.RefreshAfterAuthError(ctx, err, token) {
+		err = request()
+	}
+	return err
+}
+
+// userAgent returns the User-Agent sent with direct API requests
+func (b *BotAPI) userAgent() string {
+	if userAgent := b.currentConfig().UserAgent; userAgent != "" {
+		return userAgent
+	}
+	return services.UserAgent()
+}
+
+// tracer returns the configured tracer, or a no-op tracer
+func (b *BotAPI) tracer() utils.Tracer {
+	config := b.currentConfig()
+	if config.Tracer == nil {
+		return utils.NewNoOpTracer()
+	}
+	return config.Tracer
+}
+
+// startAPISpan starts a span around a direct API call made by BotAPI
+func (b *BotAPI) startAPISpan(ctx context.Context, method, httpMethod string) (context.Context, utils.Span) {
+	return b.tracer().Start(ctx, "zalobot.api."+method,
+		utils.Field{Key: "zalo.method", Value: method},
+		utils.Field{Key: "http.method", Value: httpMethod},
+	)
+}
+
+// endSpan records the outcome of the traced work and ends the span
+func endSpan(span utils.Span, err error) {
+	span.SetAttributes(utils.Field{Key: "zalo.outcome", Value: services.Outcome(err)})
+	span.RecordError(err)
+	span.End()
+}
+
+// updateSpanFields returns the span attributes describing an update
+func updateSpanFields(update types.Update) []utils.Field {
+	fields := []utils.Field{{Key: "zalo.event_name", Value: UpdateEventName(update)}}
+	if update.Message != nil {
+		fields = append(fields, utils.Field{Key: "zalo.message_id", Value: update.Message.MessageID})
+		if update.Message.Chat != nil {
+			fields = append(fields, utils.Field{Key: "zalo.chat_id", Value: update.Message.Chat.ID})
+		}
+	}
+	return fields
+}
+
+// DefaultMaxWebhookBodyBytes is the default limit on a webhook request body
+const DefaultMaxWebhookBodyBytes = 1 << 20
+
+// WebhookHandler returns an http.Handler that receives webhook deliveries and
+// passes each update to handler. Requests with an invalid secret token are
+// rejected with 403, bodies over DefaultMaxWebhookBodyBytes with 413 and
+// malformed payloads with 400, and duplicate updates are acknowledged
+// without running handler. The request context, including any span started
+// by upstream middleware, is the parent of the receive and handler spans.
+func (b *BotAPI) WebhookHandler(handler UpdateHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.serveWebhook(w, r, handler, DefaultMaxWebhookBodyBytes)
+	})
+}
+
+// serveWebhook handles one webhook request as described on WebhookHandler,
+// reading at most maxBodyBytes of payload. It writes the response and
+// returns its status code; fields are added to the rejection log entry.
+func (b *BotAPI) serveWebhook(w http.ResponseWriter, r *http.Request, handler UpdateHandler, maxBodyBytes int64, fields ...utils.Field) int {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return http.StatusMethodNotAllowed
+	}
+
+	secretToken := r.Header.Get(b.GetFieldSecretToken())
+	if err := b.ValidateWebhookSecretToken(secretToken); err != nil {
+		b.logger().Warn("Rejected webhook request", append(fields,
+			utils.Field{Key: "remote_addr", Value: r.RemoteAddr},
+			utils.Field{Key: "error", Value: err},
+		)...)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return http.StatusForbidden
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return http.StatusBadRequest
+	}
+
+	ctx, update, err := b.ProcessWebhookWithContext(r.Context(), payload, secretToken)
+	if errors.Is(err, ErrDuplicateUpdate) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+		return http.StatusOK
+	}
+	if err != nil {
+		http.Error(w, "Invalid webhook payload", http.StatusBadRequest)
+		return http.StatusBadRequest
+	}
+
+	if err := b.HandleUpdate(ctx, *update, handler); err != nil {
+		b.releaseUpdate(ctx, *update)
+		http.Error(w, "Failed to handle update", http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"ok":true}`))
+	return http.StatusOK
+}
@@ -0,0 +1,202 @@
+package zalobot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+func TestUpdateEventName(t *testing.T) {
+	tests := []struct {
+		name   string
+		update types.Update
+		want   string
+	}{
+		{"explicit event name", types.Update{EventName: types.EventMessageText, Message: &types.Message{}}, types.EventMessageText},
+		{"polled message", types.Update{Message: &types.Message{}}, "message"},
+		{"postback", types.Update{PostbackEvent: &types.PostbackEvent{}}, "postback"},
+		{"user action", types.Update{UserAction: &types.UserAction{}}, "user_action"},
+		{"empty update", types.Update{}, "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UpdateEventName(tt.update); got != tt.want {
+				t.Errorf("UpdateEventName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBotAPI_HandleUpdate_Metrics(t *testing.T) {
+	metrics := utils.NewPrometheusMetrics("")
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	update := types.Update{EventName: types.EventMessageText, Message: &types.Message{Text: "hi"}}
+
+	if err := bot.HandleUpdate(context.Background(), update, func(ctx context.Context, u types.Update) error {
+		return nil
+	}); err != nil {
+		t.Errorf("HandleUpdate() error = %v", err)
+	}
+
+	wantErr := errors.New("handler failed")
+	if err := bot.HandleUpdate(context.Background(), update, func(ctx context.Context, u types.Update) error {
+		return wantErr
+	}); err != wantErr {
+		t.Errorf("HandleUpdate() error = %v, want %v", err, wantErr)
+	}
+
+	err = bot.HandleUpdate(context.Background(), update, func(ctx context.Context, u types.Update) error {
+		panic("boom")
+	})
+	if err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Errorf("HandleUpdate() error = %v, want recovered panic", err)
+	}
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	output := b.String()
+
+	for _, want := range []string{
+		`zalobot_handler_duration_seconds_count{event_name="message.text.received",outcome="success"} 1`,
+		`zalobot_handler_duration_seconds_count{event_name="message.text.received",outcome="error"} 2`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("metrics missing %q\n%s", want, output)
+		}
+	}
+}
+
+func TestBotAPI_ProcessWebhook_Metrics(t *testing.T) {
+	metrics := utils.NewPrometheusMetrics("")
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	bot.SetWebhookSecretToken("secret")
+	payload := []byte(`{"ok":true,"result":{"event_name":"message.image.received","message":{"message_id":"m1"}}}`)
+	if _, err := bot.ProcessWebhook(payload, "secret"); err != nil {
+		t.Fatalf("ProcessWebhook() error = %v", err)
+	}
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+
+	want := `zalobot_updates_received_total{source="webhook",event_name="message.image.received"} 1`
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("metrics missing %q\n%s", want, b.String())
+	}
+}
+
+func TestBotAPI_WebhookHandler_Tracing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true,"result":{"message_id":"msg1","date":1750316131602}}`))
+	}))
+	defer server.Close()
+
+	tracer := utils.NewRecordingTracer()
+	bot, err := New(healthTestToken, types.WithBaseURL(server.URL), types.WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+	bot.SetWebhookSecretToken("secret")
+
+	handler := bot.WebhookHandler(func(ctx context.Context, update types.Update) error {
+		_, err := bot.SendMessageWithContext(ctx, types.MessageConfig{ChatID: "user1", Text: "pong"})
+		return err
+	})
+
+	payload := `{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"ping","chat":{"id":"user1"}}}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+	req.Header.Set(bot.GetFieldSecretToken(), "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("WebhookHandler() status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	receive, ok := tracer.FindSpan("zalobot.webhook.receive")
+	if !ok {
+		t.Fatal("missing receive span")
+	}
+	handle, ok := tracer.FindSpan("zalobot.handle_update")
+	if !ok {
+		t.Fatal("missing handler span")
+	}
+	send, ok := tracer.FindSpan("zalobot.api.sendMessage")
+	if !ok {
+		t.Fatal("missing sendMessage span")
+	}
+
+	if handle.TraceID != receive.TraceID || send.TraceID != receive.TraceID {
+		t.Errorf("spans are not in one trace: receive=%s handle=%s send=%s", receive.TraceID, handle.TraceID, send.TraceID)
+	}
+	if handle.ParentID != receive.SpanID {
+		t.Errorf("handler span parent = %q, want %q", handle.ParentID, receive.SpanID)
+	}
+	if send.ParentID != handle.SpanID {
+		t.Errorf("sendMessage span parent = %q, want %q", send.ParentID, handle.SpanID)
+	}
+	if handle.Attributes["zalo.event_name"] != types.EventMessageText {
+		t.Errorf("handler span event name = %v", handle.Attributes["zalo.event_name"])
+	}
+	if send.Attributes["zalo.outcome"] != utils.OutcomeSuccess || !send.Ended {
+		t.Errorf("sendMessage span = %+v, want ended with success outcome", send)
+	}
+}
+
+func TestBotAPI_WebhookHandler_Rejects(t *testing.T) {
+	bot, err := New(healthTestToken)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+	bot.SetWebhookSecretToken("secret")
+
+	handler := bot.WebhookHandler(func(ctx context.Context, update types.Update) error {
+		t.Error("handler should not be called")
+		return nil
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		body   string
+		want   int
+	}{
+		{"wrong method", http.MethodGet, "secret", "", http.StatusMethodNotAllowed},
+		{"wrong secret", http.MethodPost, "nope", `{"ok":true,"result":{}}`, http.StatusForbidden},
+		{"malformed payload", http.MethodPost, "secret", `{`, http.StatusBadRequest},
+		{"body too large", http.MethodPost, "secret", strings.Repeat(" ", DefaultMaxWebhookBodyBytes+1), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(tt.body))
+			req.Header.Set(bot.GetFieldSecretToken(), tt.secret)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package zalobot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+// HealthStatus reports the runtime state of a bot for health and readiness checks
+type HealthStatus struct {
+	Status            string             `json:"status"`
+	Live              bool               `json:"live"`
+	Ready             bool               `json:"ready"`
+	Polling           bool               `json:"polling"`
+	WebhookActive     bool               `json:"webhook_active"`
+	LastUpdateAt      *time.Time         `json:"last_update_at,omitempty"`
+	LastPollAt        *time.Time         `json:"last_poll_at,omitempty"`
+	ConsecutiveErrors int                `json:"consecutive_errors"`
+	TotalErrors       int64              `json:"total_errors"`
+	LastError         string             `json:"last_error,omitempty"`
+	RateLimit         RateLimitStatus    `json:"rate_limit"`
+	InFlight          int                `json:"in_flight"` // API calls in progress
+	UpdatesBacklog    int                `json:"updates_backlog"`
+	Credentials       *CredentialsStatus `json:"credentials,omitempty"`
+	Webhook           *WatchdogStatus    `json:"webhook,omitempty"`
+	Reasons           []string           `json:"reasons,omitempty"`
+}
+
+// RateLimitStatus reports the rate limit state seen in the latest API responses
+type RateLimitStatus struct {
+	Limited      bool       `json:"limited"`
+	Limit        int        `json:"limit,omitempty"`
+	Remaining    int        `json:"remaining,omitempty"`
+	LimitedUntil *time.Time `json:"limited_until,omitempty"`
+}
+
+// CredentialsStatus reports the result of the cached getMe credential check
+type CredentialsStatus struct {
+	Valid     bool           `json:"valid"`
+	CheckedAt time.Time      `json:"checked_at"`
+	Bot       *types.BotInfo `json:"bot,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// Health status values: ok when ready, degraded when live but not ready,
+// and unavailable when not live
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusDegraded    = "degraded"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// runtimeState records update delivery times used by health checks
+type runtimeState struct {
+	lastUpdateAt atomic.Int64 // unix nanoseconds
+	lastPollAt   atomic.Int64 // unix nanoseconds
+	webhookSet   atomic.Bool  // a webhook is registered or delivering updates
+
+	credMu        sync.Mutex
+	credCheckedAt time.Time
+	credBot       *types.BotInfo
+	credErr       error
+}
+
+// markUpdateReceived records a successful getUpdates call or webhook delivery
+func (s *runtimeState) markUpdateReceived() {
+	s.lastUpdateAt.Store(time.Now().UnixNano())
+}
+
+// setWebhookActive records whether updates arrive through a webhook
+func (s *runtimeState) setWebhookActive(active bool) {
+	s.webhookSet.Store(active)
+}
+
+// markPolled records a completed getUpdates call
+func (s *runtimeState) markPolled() {
+	s.lastPollAt.Store(time.Now().UnixNano())
+}
+
+// unixTime converts a stored unix nanosecond value to a time pointer, nil if unset
+func unixTime(nanos int64) *time.Time {
+	if nanos == 0 {
+		return nil
+	}
+	t := time.Unix(0, nanos)
+	return &t
+}
+
+// healthConfig returns the configured health thresholds
+func (b *BotAPI) healthConfig() *types.HealthConfig {
+	config := b.currentConfig()
+	if config.Health == nil {
+		return types.DefaultHealthConfig()
+	}
+	return config.Health
+}
+
+// checkCredentials validates the bot credentials through getMe, reusing the
+// previous result until the configured TTL expires
+func (b *BotAPI) checkCredentials(ctx context.Context) *CredentialsStatus {
+	ttl := b.healthConfig().CredentialCacheTTL
+
+	b.state.credMu.Lock()
+	defer b.state.credMu.Unlock()
+
+	if b.state.credCheckedAt.IsZero() || time.Since(b.state.credCheckedAt) >= ttl {
+		b.state.credBot, b.state.credErr = b.getMe(ctx, ttl)
+		b.state.credCheckedAt = time.Now()
+	}
+
+	status := &CredentialsStatus{
+		Valid:     b.state.credErr == nil,
+		CheckedAt: b.state.credCheckedAt,
+		Bot:       b.state.credBot,
+	}
+	if b.state.credErr != nil {
+		status.Error = b.state.credErr.Error()
+	}
+	return status
+}
+
+// Health collects the current runtime state and evaluates liveness and
+// readiness against the configured HealthConfig thresholds
+func (b *BotAPI) Health(ctx context.Context) HealthStatus {
+	return b.health(ctx, true)
+}
+
+// health builds a HealthStatus, running the credential check only when
+// withCredentials is set
+func (b *BotAPI) health(ctx context.Context, withCredentials bool) HealthStatus {
+	config := b.healthConfig()
+	stats := b.stats.Snapshot()
+
+	status := HealthStatus{
+		Polling:           b.IsPolling(),
+		LastUpdateAt:      unixTime(b.state.lastUpdateAt.Load()),
+		LastPollAt:        unixTime(b.state.lastPollAt.Load()),
+		ConsecutiveErrors: stats.ConsecutiveErrors,
+		TotalErrors:       stats.TotalErrors,
+		LastError:         stats.LastError,
+		RateLimit: RateLimitStatus{
+			Limited:   stats.IsRateLimited(),
+			Limit:     stats.RateLimit.Limit,
+			Remaining: stats.RateLimit.Remaining,
+		},
+		WebhookActive: b.state.webhookSet.Load(),
+		InFlight:      stats.InFlight,
+	}
+	if status.RateLimit.Limited {
+		until := stats.RateLimitedUntil
+		status.RateLimit.LimitedUntil = &until
+	}
+
+	status.UpdatesBacklog = b.updatesBacklog()
+
+	// Liveness
+	status.Live = true
+	if b.ctx.Err() != nil {
+		status.Live = false
+		status.Reasons = append(status.Reasons, "bot is closed")
+	}
+	if status.Polling && config.MaxPollStall > 0 {
+		lastPoll := status.LastPollAt
+		if lastPoll != nil && time.Since(*lastPoll) > config.MaxPollStall {
+			status.Live = false
+			status.Reasons = append(status.Reasons, fmt.Sprintf("no getUpdates call completed for %s", time.Since(*lastPoll).Round(time.Second)))
+		}
+	}
+
+	// Readiness
+	status.Ready = status.Live
+	// Applications calling GetUpdates themselves count as polling while
+	// their calls keep completing
+	polled := status.LastPollAt != nil && (config.MaxPollStall <= 0 || time.Since(*status.LastPollAt) <= config.MaxPollStall)
+	if !status.Polling && !polled && !status.WebhookActive {
+		status.Ready = false
+		status.Reasons = append(status.Reasons, "not polling and no webhook set")
+	}
+	if config.MaxConsecutiveErrors > 0 && stats.ConsecutiveErrors >= config.MaxConsecutiveErrors {
+		status.Ready = false
+		status.Reasons = append(status.Reasons, fmt.Sprintf("%d consecutive API errors", stats.ConsecutiveErrors))
+	}
+	if status.RateLimit.Limited {
+		status.Ready = false
+		status.Reasons = append(status.Reasons, "rate limited")
+	}
+	if config.MaxUpdateAge > 0 {
+		lastUpdate := status.LastUpdateAt
+		if lastUpdate == nil || time.Since(*lastUpdate) > config.MaxUpdateAge {
+			status.Ready = false
+			status.Reasons = append(status.Reasons, fmt.Sprintf("no update received within %s", config.MaxUpdateAge))
+		}
+	}
+	if watchdog := b.WebhookWatchdog(); watchdog != nil {
+		webhook := watchdog.Status()
+		status.Webhook = &webhook
+		if webhook.Running && !webhook.Healthy {
+			status.Ready = false
+			status.Reasons = append(status.Reasons, webhook.Reasons...)
+		}
+	}
+	if withCredentials && config.CheckCredentials && status.Live {
+		status.Credentials = b.checkCredentials(ctx)
+		if !status.Credentials.Valid {
+			status.Ready = false
+			status.Reasons = append(status.Reasons, "credential check failed")
+		}
+	}
+
+	switch {
+	case !status.Live:
+		status.Status = HealthStatusUnavailable
+	case !status.Ready:
+		status.Status = HealthStatusDegraded
+	default:
+		status.Status = HealthStatusOK
+	}
+
+	return status
+}
+
+// HealthHandler returns an http.Handler that reports the full HealthStatus
+// as JSON, for dashboards and humans. It responds 200 unless the bot is not
+// live, so a degraded bot still reports its reasons with a 200.
+func (b *BotAPI) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := b.Health(r.Context())
+		writeHealth(w, status, status.Live)
+	})
+}
+
+// LivenessHandler returns an http.Handler for liveness probes. It responds
+// 200 while the bot is open and its polling loop is making progress, and 503
+// otherwise. It never calls the Zalo API.
+func (b *BotAPI) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := b.health(r.Context(), false)
+		writeHealth(w, status, status.Live)
+	})
+}
+
+// ReadinessHandler returns an http.Handler for readiness probes. It responds
+// 200 when the bot is ready to serve traffic and 503 otherwise: it must be
+// polling or have a webhook set, and the API error, rate limit, update age,
+// webhook watchdog and credential checks of the HealthConfig must pass.
+func (b *BotAPI) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := b.Health(r.Context())
+		writeHealth(w, status, status.Ready)
+	})
+}
+
+// writeHealth writes status as JSON with a status code reflecting ok
+func writeHealth(w http.ResponseWriter, status HealthStatus, ok bool) {
+	code := http.StatusOK
+	if !ok {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,285 @@
+package zalobot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+const healthTestToken = "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+
+func decodeHealth(t *testing.T, rec *httptest.ResponseRecorder) HealthStatus {
+	t.Helper()
+	var status HealthStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode health response: %v", err)
+	}
+	return status
+}
+
+func TestBotAPI_HealthHandler_Ready(t *testing.T) {
+	bot, err := New(healthTestToken)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	// Not ready before any update source is active
+	rec := httptest.NewRecorder()
+	bot.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("HealthHandler() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	status := decodeHealth(t, rec)
+	if status.Status != HealthStatusDegraded || !status.Live || status.Ready {
+		t.Errorf("HealthStatus = %+v, want degraded/live/not ready", status)
+	}
+	if status.Polling || status.WebhookActive {
+		t.Error("HealthStatus.Polling/WebhookActive = true, want false")
+	}
+
+	rec = httptest.NewRecorder()
+	bot.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("ReadinessHandler() status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	// A webhook delivery makes the bot ready
+	bot.SetWebhookSecretToken("secret")
+	payload := []byte(`{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"hi"}}}`)
+	if _, err := bot.ProcessWebhook(payload, "secret"); err != nil {
+		t.Fatalf("ProcessWebhook() error = %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	bot.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("ReadinessHandler() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	status = decodeHealth(t, rec)
+	if status.Status != HealthStatusOK || !status.Ready || !status.WebhookActive {
+		t.Errorf("HealthStatus = %+v, want ok/ready/webhook active", status)
+	}
+}
+
+func TestBotAPI_Health_ConsecutiveErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ok":          false,
+			"error_code":  400,
+			"description": "bad request",
+		})
+	}))
+	defer server.Close()
+
+	bot, err := New(healthTestToken,
+		types.WithBaseURL(server.URL),
+		types.WithHealthConfig(&types.HealthConfig{MaxConsecutiveErrors: 2}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := bot.GetUpdates(types.UpdateConfig{}); err == nil {
+			t.Fatal("GetUpdates() expected error")
+		}
+	}
+
+	status := bot.Health(bot.GetContext())
+	if status.Ready {
+		t.Error("HealthStatus.Ready = true, want false after consecutive errors")
+	}
+	if !status.Live {
+		t.Error("HealthStatus.Live = false, want true")
+	}
+	if status.ConsecutiveErrors != 2 {
+		t.Errorf("HealthStatus.ConsecutiveErrors = %d, want 2", status.ConsecutiveErrors)
+	}
+
+	rec := httptest.NewRecorder()
+	bot.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("ReadinessHandler() status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	rec = httptest.NewRecorder()
+	bot.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("LivenessHandler() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestBotAPI_Health_LastUpdateAndSuccessReset(t *testing.T) {
+	fail := atomic.Bool{}
+	fail.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.Write([]byte(`{"ok":false,"error_code":500,"description":"oops"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	bot, err := New(healthTestToken,
+		types.WithBaseURL(server.URL),
+		types.WithHealthConfig(&types.HealthConfig{MaxConsecutiveErrors: 1, MaxUpdateAge: time.Minute}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	status := bot.Health(bot.GetContext())
+	if status.Ready || status.LastUpdateAt != nil {
+		t.Errorf("HealthStatus = %+v, want not ready without any update", status)
+	}
+
+	bot.GetUpdates(types.UpdateConfig{})
+	fail.Store(false)
+	if _, err := bot.GetUpdates(types.UpdateConfig{}); err != nil {
+		t.Fatalf("GetUpdates() error = %v", err)
+	}
+
+	status = bot.Health(bot.GetContext())
+	if !status.Ready {
+		t.Errorf("HealthStatus.Ready = false, reasons = %v", status.Reasons)
+	}
+	if status.LastUpdateAt == nil || status.LastPollAt == nil {
+		t.Error("HealthStatus.LastUpdateAt/LastPollAt not set after successful getUpdates")
+	}
+	if status.ConsecutiveErrors != 0 || status.TotalErrors != 1 {
+		t.Errorf("ConsecutiveErrors/TotalErrors = %d/%d, want 0/1", status.ConsecutiveErrors, status.TotalErrors)
+	}
+}
+
+func TestBotAPI_Health_WebhookDelivery(t *testing.T) {
+	bot, err := New(healthTestToken)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	bot.SetWebhookSecretToken("secret")
+	payload := []byte(`{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"hi"}}}`)
+	if _, err := bot.ProcessWebhook(payload, "secret"); err != nil {
+		t.Fatalf("ProcessWebhook() error = %v", err)
+	}
+
+	if status := bot.Health(bot.GetContext()); status.LastUpdateAt == nil {
+		t.Error("HealthStatus.LastUpdateAt not set after webhook delivery")
+	}
+}
+
+func TestBotAPI_Health_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	bot, err := New(healthTestToken,
+		types.WithBaseURL(server.URL),
+		types.WithRetryConfig(&types.RetryConfig{MaxRetries: 0}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	if _, err := bot.GetUserProfile("user123"); err == nil {
+		t.Fatal("GetUserProfile() expected rate limit error")
+	}
+
+	status := bot.Health(bot.GetContext())
+	if !status.RateLimit.Limited || status.RateLimit.LimitedUntil == nil {
+		t.Errorf("HealthStatus.RateLimit = %+v, want limited", status.RateLimit)
+	}
+	if status.Ready {
+		t.Error("HealthStatus.Ready = true, want false while rate limited")
+	}
+}
+
+func TestBotAPI_Health_CredentialCheckCached(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	bot, err := New(healthTestToken,
+		types.WithBaseURL(server.URL),
+		types.WithHealthConfig(&types.HealthConfig{CheckCredentials: true, CredentialCacheTTL: time.Hour}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	for i := 0; i < 3; i++ {
+		status := bot.Health(bot.GetContext())
+		if status.Credentials == nil || status.Credentials.Valid {
+			t.Fatalf("HealthStatus.Credentials = %+v, want invalid", status.Credentials)
+		}
+		if status.Ready {
+			t.Error("HealthStatus.Ready = true, want false with invalid credentials")
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("getMe called %d times, want 1", got)
+	}
+
+	// Liveness never runs the credential check
+	rec := httptest.NewRecorder()
+	bot.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("LivenessHandler() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestBotAPI_Health_Closed(t *testing.T) {
+	bot, err := New(healthTestToken)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	bot.Close()
+
+	rec := httptest.NewRecorder()
+	bot.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("LivenessHandler() status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestBotAPI_Health_CredentialBotInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"id":"bot1","account_name":"bot.test"}}`))
+	}))
+	defer server.Close()
+
+	bot, err := New(healthTestToken,
+		types.WithBaseURL(server.URL),
+		types.WithHealthConfig(&types.HealthConfig{CheckCredentials: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	status := bot.Health(bot.GetContext())
+	if status.Credentials == nil || !status.Credentials.Valid {
+		t.Fatalf("HealthStatus.Credentials = %+v, want valid", status.Credentials)
+	}
+	if status.Credentials.Bot == nil || status.Credentials.Bot.ID != "bot1" {
+		t.Errorf("HealthStatus.Credentials.Bot = %+v, want bot1", status.Credentials.Bot)
+	}
+}
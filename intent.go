@@ -0,0 +1,74 @@
+package zalobot
+
+import "github.com/vkhangstack/go-zalo-bot/utils"
+
+// DefaultIntentConfidence is the confidence the most likely intent needs
+// for OnIntent routes to match
+const DefaultIntentConfidence = 0.5
+
+// IntentKey is the Context key under which an intent filter stores the
+// utils.IntentPrediction it matched
+const IntentKey = "intent"
+
+// UseIntents sets the classifier OnIntent routes use and the confidence
+// the most likely intent needs to match (default: DefaultIntentConfidence).
+// Call it again to swap in a retrained model while the bot is running.
+func (d *Dispatcher) UseIntents(classifier *utils.IntentClassifier, minConfidence float64) {
+	if minConfidence <= 0 {
+		minConfidence = DefaultIntentConfidence
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.classifier = classifier
+	d.minConfidence = minConfidence
+}
+
+// OnIntent registers handler for messages whose most likely intent is
+// intent, under the classifier set with UseIntents. The route never
+// matches before UseIntents is called.
+func (d *Dispatcher) OnIntent(intent string, handler ContextHandler) {
+	d.Handle(handler, func(c *Context) bool {
+		d.mu.RLock()
+		classifier, minConfidence := d.classifier, d.minConfidence
+		d.mu.RUnlock()
+
+		return Intent(classifier, minConfidence, intent)(c)
+	})
+}
+
+// Intent matches messages whose most likely intent under classifier is one
+// of intents, with at least minConfidence. The prediction is stored under
+// IntentKey. A nil classifier matches nothing.
+func Intent(classifier *utils.IntentClassifier, minConfidence float64, intents ...string) Filter {
+	return func(c *Context) bool {
+		if classifier == nil {
+			return false
+		}
+		predictions := c.classify(classifier)
+		if len(predictions) == 0 || predictions[0].Confidence < minConfidence {
+			return false
+		}
+		for _, intent := range intents {
+			if predictions[0].Intent == intent {
+				c.Set(IntentKey, predictions[0])
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// classify returns the intents of the message text under classifier,
+// classifying it once per update
+func (c *Context) classify(classifier *utils.IntentClassifier) []utils.IntentPrediction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.classifiedBy != classifier {
+		c.predictions = classifier.Classify(c.Text())
+		c.classifiedBy = classifier
+	}
+	return c.predictions
+}
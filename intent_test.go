@@ -0,0 +1,113 @@
+package zalobot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+func trainDispatcherIntents(t *testing.T, examples map[string][]string) *utils.IntentClassifier {
+	t.Helper()
+
+	classifier, err := utils.TrainIntentClassifier(examples)
+	if err != nil {
+		t.Fatalf("TrainIntentClassifier() error = %v", err)
+	}
+	return classifier
+}
+
+func TestDispatcher_OnIntent(t *testing.T) {
+	bot := newDispatcherTestBot(t)
+	dispatcher := NewDispatcher(bot)
+
+	var got []string
+	var prediction utils.IntentPrediction
+	dispatcher.OnCommand("start", func(c *Context) error {
+		got = append(got, "start")
+		return nil
+	})
+	dispatcher.OnIntent("order_status", func(c *Context) error {
+		value, _ := c.Get(IntentKey)
+		prediction = value.(utils.IntentPrediction)
+		got = append(got, "order_status")
+		return nil
+	})
+	dispatcher.OnIntent("greeting", func(c *Context) error {
+		got = append(got, "greeting")
+		return nil
+	})
+	dispatcher.Fallback(func(c *Context) error {
+		got = append(got, "fallback")
+		return nil
+	})
+
+	send := func(text string) {
+		t.Helper()
+		if err := dispatcher.Handler()(context.Background(), textUpdate(text)); err != nil {
+			t.Fatalf("handler(%q) error = %v", text, err)
+		}
+	}
+
+	// Intent routes do not match before a classifier is set
+	send("đơn hàng của tôi đâu")
+
+	dispatcher.UseIntents(trainDispatcherIntents(t, map[string][]string{
+		"order_status": {"đơn hàng của tôi đâu rồi", "kiểm tra đơn hàng", "where is my order"},
+		"greeting":     {"xin chào", "chào shop", "hello"},
+	}), 0)
+	send("Đơn hàng của mình đâu rồi?")
+	send("hello shop")
+	send("/start")
+	send("thời tiết hôm nay")
+
+	want := []string{"fallback", "order_status", "greeting", "start", "fallback"}
+	if len(got) != len(want) {
+		t.Fatalf("routes = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("routes = %v, want %v", got, want)
+		}
+	}
+	if prediction.Intent != "order_status" || prediction.Confidence < DefaultIntentConfidence {
+		t.Errorf("stored prediction = %+v", prediction)
+	}
+
+	// A retrained model replaces the old one
+	dispatcher.UseIntents(trainDispatcherIntents(t, map[string][]string{
+		"greeting": {"đơn hàng của tôi đâu"},
+		"other":    {"thời tiết"},
+	}), 0)
+	got = nil
+	send("đơn hàng của tôi đâu")
+	if len(got) != 1 || got[0] != "greeting" {
+		t.Errorf("routes after retraining = %v, want [greeting]", got)
+	}
+}
+
+func TestIntent_MinConfidence(t *testing.T) {
+	bot := newDispatcherTestBot(t)
+	classifier := trainDispatcherIntents(t, map[string][]string{
+		"order_status": {"đơn hàng đâu"},
+		"price":        {"giá bao nhiêu"},
+	})
+
+	c, cancel := bot.NewContext(context.Background(), textUpdate("đơn hàng giá bao nhiêu"))
+	defer cancel()
+
+	predictions := classifier.Classify(c.Text())
+	top := predictions[0]
+	if !Intent(classifier, top.Confidence, top.Intent)(c) {
+		t.Errorf("Intent() at the top confidence did not match %+v", top)
+	}
+	if Intent(classifier, top.Confidence+0.01, top.Intent)(c) {
+		t.Errorf("Intent() above the top confidence matched %+v", top)
+	}
+	if Intent(classifier, 0, predictions[1].Intent)(c) {
+		t.Error("Intent() matched an intent that is not the most likely")
+	}
+	if Intent(nil, 0, top.Intent)(c) {
+		t.Error("Intent() with a nil classifier matched")
+	}
+}
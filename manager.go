@@ -0,0 +1,348 @@
+package zalobot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// ManagerConfig represents configuration for a BotManager
+type ManagerConfig struct {
+	// HTTPClient is shared by every bot (default: a client with a pooled
+	// transport and a 30s timeout)
+	HTTPClient *http.Client
+	// RateLimit is the number of API requests per second allowed for each
+	// token (0 disables rate limiting)
+	RateLimit float64
+	// RateBurst is the number of requests a token may send in a burst
+	// (default: 1)
+	RateBurst int
+	// UpdateBuffer is the capacity of the merged updates channel (default: 100)
+	UpdateBuffer int
+	// Options are applied to every bot before its own options
+	Options []types.BotOption
+}
+
+// BotUpdate is an update tagged with the managed bot that received it
+type BotUpdate struct {
+	BotKey string
+	Bot    *BotAPI
+	types.Update
+}
+
+// BotManager runs many bots, one per token, in one process. Bots share an
+// HTTP client, each token has its own rate limiter, and updates from all bots
+// are merged into one channel tagged with the bot key.
+type BotManager struct {
+	config ManagerConfig
+	client *http.Client
+
+	mu      sync.RWMutex
+	bots    map[string]*managedBot
+	polling *types.UpdateConfig
+	closed  bool
+
+	updates chan BotUpdate
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// managedBot is a bot registered with the manager
+type managedBot struct {
+	bot *BotAPI
+}
+
+// NewBotManager creates an empty bot manager
+func NewBotManager(config ManagerConfig) *BotManager {
+	if config.HTTPClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = 100
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second, Transport: transport}
+	}
+	if config.RateBurst < 1 {
+		config.RateBurst = 1
+	}
+	if config.UpdateBuffer <= 0 {
+		config.UpdateBuffer = 100
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BotManager{
+		config:  config,
+		client:  config.HTTPClient,
+		bots:    make(map[string]*managedBot),
+		updates: make(chan BotUpdate, config.UpdateBuffer),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Add creates a bot for token under key. If the manager is polling, the new
+// bot starts polling immediately.
+func (m *BotManager) Add(key, token string, options ...types.BotOption) (*BotAPI, error) {
+	if key == "" {
+		return nil, types.NewValidationError("bot key is required")
+	}
+
+	bot, err := m.newBot(token, options)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		bot.Close()
+		return nil, types.NewValidationError("bot manager is closed")
+	}
+	if _, exists := m.bots[key]; exists {
+		bot.Close()
+		return nil, types.NewValidationError(fmt.Sprintf("bot %q already exists", key))
+	}
+
+	m.bots[key] = &managedBot{bot: bot}
+	if m.polling != nil {
+		m.startPolling(key, bot, *m.polling)
+	}
+	return bot, nil
+}
+
+// Remove stops and closes the bot registered under key
+func (m *BotManager) Remove(key string) error {
+	m.mu.Lock()
+	entry, ok := m.bots[key]
+	delete(m.bots, key)
+	m.mu.Unlock()
+
+	if !ok {
+		return types.NewValidationError(fmt.Sprintf("bot %q not found", key))
+	}
+
+	entry.bot.Close()
+	return nil
+}
+
+// SwapToken replaces the token of the bot registered under key in place,
+// with RotateToken. The bot keeps its options, webhook secret and polling;
+// requests already in flight finish with the old token.
+func (m *BotManager) SwapToken(key, token string) (*BotAPI, error) {
+	bot, ok := m.Get(key)
+	if !ok {
+		return nil, types.NewValidationError(fmt.Sprintf("bot %q not found", key))
+	}
+
+	if err := bot.RotateToken(token); err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
+
+// Get returns the bot registered under key
+func (m *BotManager) Get(key string) (*BotAPI, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.bots[key]
+	if !ok {
+		return nil, false
+	}
+	return entry.bot, true
+}
+
+// Keys returns the keys of all managed bots in sorted order
+func (m *BotManager) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]string, 0, len(m.bots))
+	for key := range m.bots {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Len returns the number of managed bots
+func (m *BotManager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.bots)
+}
+
+// StartPolling starts polling for every managed bot, including bots added
+// later, and returns the merged updates channel. The channel is closed by Close.
+func (m *BotManager) StartPolling(config types.UpdateConfig) <-chan BotUpdate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed || m.polling != nil {
+		return m.updates
+	}
+
+	m.polling = &config
+	for key, entry := range m.bots {
+		m.startPolling(key, entry.bot, config)
+	}
+	return m.updates
+}
+
+// StopPolling stops polling for every managed bot. The merged updates channel
+// stays open so polling can be started again.
+func (m *BotManager) StopPolling() {
+	m.mu.Lock()
+	m.polling = nil
+	bots := m.snapshot()
+	m.mu.Unlock()
+
+	for _, bot := range bots {
+		bot.StopPolling()
+	}
+}
+
+// Updates returns the merged updates channel
+func (m *BotManager) Updates() <-chan BotUpdate {
+	return m.updates
+}
+
+// SetWebhooks registers a webhook for every managed bot using the
+// configuration returned by configFor, returning the errors of all bots
+// that failed
+func (m *BotManager) SetWebhooks(configFor func(key string) types.WebhookConfig) error {
+	var errs []error
+	for _, key := range m.Keys() {
+		bot, ok := m.Get(key)
+		if !ok {
+			continue
+		}
+		if err := bot.SetWebhook(configFor(key)); err != nil {
+			errs = append(errs, fmt.Errorf("bot %q: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteWebhooks removes the webhook of every managed bot, returning the
+// errors of all bots that failed
+func (m *BotManager) DeleteWebhooks() error {
+	var errs []error
+	for _, key := range m.Keys() {
+		bot, ok := m.Get(key)
+		if !ok {
+			continue
+		}
+		if err := bot.DeleteWebhook(); err != nil {
+			errs = append(errs, fmt.Errorf("bot %q: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProcessWebhook validates and parses a webhook delivery for the bot
+// registered under key. The returned context carries the bot key and the
+// receive span. A duplicate update is reported with ErrDuplicateUpdate.
+func (m *BotManager) ProcessWebhook(ctx context.Context, key string, payload []byte, secretToken string) (context.Context, *BotUpdate, error) {
+	bot, ok := m.Get(key)
+	if !ok {
+		return ctx, nil, types.NewValidationError(fmt.Sprintf("bot %q not found", key))
+	}
+
+	ctx, update, err := bot.ProcessWebhookWithContext(ContextWithBotKey(ctx, key), payload, secretToken)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ctx, &BotUpdate{BotKey: key, Bot: bot, Update: *update}, nil
+}
+
+// HandleUpdate runs handler for update on the bot that received it. The
+// handler context carries the bot key, see BotKeyFromContext.
+func (m *BotManager) HandleUpdate(ctx context.Context, update BotUpdate, handler UpdateHandler) error {
+	if update.Bot == nil {
+		return types.NewValidationError(fmt.Sprintf("bot %q not found", update.BotKey))
+	}
+	return update.Bot.HandleUpdate(ContextWithBotKey(ctx, update.BotKey), update.Update, handler)
+}
+
+// Close stops polling, closes every bot and closes the merged updates channel
+func (m *BotManager) Close() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	m.polling = nil
+	bots := m.snapshot()
+	m.bots = make(map[string]*managedBot)
+	m.mu.Unlock()
+
+	m.cancel()
+	for _, bot := range bots {
+		bot.Close()
+	}
+
+	m.wg.Wait()
+	close(m.updates)
+}
+
+// newBot creates a bot that uses the shared client and its own rate limiter
+func (m *BotManager) newBot(token string, options []types.BotOption) (*BotAPI, error) {
+	all := make([]types.BotOption, 0, len(m.config.Options)+len(options)+2)
+	all = append(all, m.config.Options...)
+	all = append(all, types.WithHTTPClient(m.client))
+	if m.config.RateLimit > 0 {
+		all = append(all, types.WithRateLimiter(utils.NewTokenBucket(m.config.RateLimit, m.config.RateBurst)))
+	}
+	all = append(all, options...)
+
+	return New(token, all...)
+}
+
+// startPolling starts polling for bot and forwards its updates to the merged
+// channel; the caller must hold the lock
+func (m *BotManager) startPolling(key string, bot *BotAPI, config types.UpdateConfig) {
+	updates := bot.GetUpdatesChan(config)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for update := range updates {
+			select {
+			case m.updates <- BotUpdate{BotKey: key, Bot: bot, Update: update}:
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// snapshot returns the managed bots; the caller must hold the lock
+func (m *BotManager) snapshot() []*BotAPI {
+	bots := make([]*BotAPI, 0, len(m.bots))
+	for _, entry := range m.bots {
+		bots = append(bots, entry.bot)
+	}
+	return bots
+}
+
+// botKeyContextKey is the context key under which the bot key is stored
+type botKeyContextKey struct{}
+
+// ContextWithBotKey returns a copy of ctx carrying the key of a managed bot
+func ContextWithBotKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, botKeyContextKey{}, key)
+}
+
+// BotKeyFromContext returns the managed bot key carried by ctx, or ""
+func BotKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(botKeyContextKey{}).(string)
+	return key
+}
@@ -0,0 +1,212 @@
+package zalobot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+const (
+	managerTokenA = "111111:AAA-DEF1234ghIkl-zyx57W2v1u123ew11"
+	managerTokenB = "222222:BBB-DEF1234ghIkl-zyx57W2v1u123ew11"
+)
+
+// newManagerTestServer serves one update per token on the first getUpdates
+// call and empty results afterwards
+func newManagerTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	served := make(map[string]bool)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.HasSuffix(r.URL.Path, "/getUpdates") {
+			w.Write([]byte(`{"ok":true,"result":{}}`))
+			return
+		}
+
+		token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/bot"), "/getUpdates")
+		mu.Lock()
+		first := !served[token]
+		served[token] = true
+		mu.Unlock()
+
+		if first {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":1,"message":{"message_id":"` + token[:6] + `","text":"hi"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBotManager_AddRemove(t *testing.T) {
+	manager := NewBotManager(ManagerConfig{})
+	defer manager.Close()
+
+	botA, err := manager.Add("a", managerTokenA)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	botB, err := manager.Add("b", managerTokenB)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if botA.GetHTTPClient() != botB.GetHTTPClient() {
+		t.Error("managed bots should share one HTTP client")
+	}
+	if _, err := manager.Add("a", managerTokenB); err == nil {
+		t.Error("Add() with a duplicate key should fail")
+	}
+	if _, err := manager.Add("c", "invalid"); err == nil {
+		t.Error("Add() with an invalid token should fail")
+	}
+
+	if got := manager.Keys(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Keys() = %v, want [a b]", got)
+	}
+
+	if err := manager.Remove("a"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, ok := manager.Get("a"); ok || manager.Len() != 1 {
+		t.Error("Remove() did not remove the bot")
+	}
+	if err := manager.Remove("a"); err == nil {
+		t.Error("Remove() of an unknown key should fail")
+	}
+}
+
+func TestBotManager_PerTokenRateLimiter(t *testing.T) {
+	manager := NewBotManager(ManagerConfig{RateLimit: 5, RateBurst: 2})
+	defer manager.Close()
+
+	botA, _ := manager.Add("a", managerTokenA)
+	botB, _ := manager.Add("b", managerTokenB)
+
+	limiterA := botA.GetConfig().RateLimiter
+	limiterB := botB.GetConfig().RateLimiter
+	if limiterA == nil || limiterB == nil {
+		t.Fatal("managed bots should have rate limiters")
+	}
+	if limiterA == limiterB {
+		t.Error("each token should have its own rate limiter")
+	}
+}
+
+func TestBotManager_Polling(t *testing.T) {
+	server := newManagerTestServer(t)
+	manager := NewBotManager(ManagerConfig{Options: []types.BotOption{types.WithBaseURL(server.URL)}})
+
+	if _, err := manager.Add("a", managerTokenA); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	updates := manager.StartPolling(types.UpdateConfig{Timeout: 1})
+
+	// A bot added while polling starts polling too
+	if _, err := manager.Add("b", managerTokenB); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	got := make(map[string]string)
+	timeout := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case update := <-updates:
+			if update.Bot == nil || update.Message == nil {
+				t.Fatalf("update = %+v, want bot and message", update)
+			}
+			got[update.BotKey] = update.Message.MessageID
+		case <-timeout:
+			t.Fatalf("timed out waiting for updates, got %v", got)
+		}
+	}
+
+	if got["a"] != managerTokenA[:6] || got["b"] != managerTokenB[:6] {
+		t.Errorf("updates tagged %v, want each bot's own update", got)
+	}
+
+	manager.Close()
+	for range updates {
+	}
+	if manager.Len() != 0 {
+		t.Error("Close() should remove every bot")
+	}
+	if _, err := manager.Add("c", managerTokenA); err == nil {
+		t.Error("Add() after Close() should fail")
+	}
+}
+
+func TestBotManager_SwapToken(t *testing.T) {
+	manager := NewBotManager(ManagerConfig{})
+	defer manager.Close()
+
+	old, _ := manager.Add("a", managerTokenA, types.WithEnvironment(types.Development))
+	old.SetWebhookSecretToken("secret")
+
+	bot, err := manager.SwapToken("a", managerTokenB)
+	if err != nil {
+		t.Fatalf("SwapToken() error = %v", err)
+	}
+
+	if current, _ := manager.Get("a"); current != bot || bot.GetBotToken() != managerTokenB {
+		t.Error("SwapToken() did not install the new token")
+	}
+	if bot != old {
+		t.Error("SwapToken() should rotate the token of the existing bot")
+	}
+	if bot.GetConfig().Environment != types.Development {
+		t.Error("SwapToken() should keep the bot's options")
+	}
+	if bot.ValidateWebhookSecretToken("secret") != nil {
+		t.Error("SwapToken() should keep the webhook secret")
+	}
+	if old.GetContext().Err() != nil {
+		t.Error("SwapToken() should not close the bot")
+	}
+	if _, err := manager.SwapToken("a", "bad"); err == nil || bot.GetBotToken() != managerTokenB {
+		t.Error("SwapToken() with an invalid token should fail and keep the current one")
+	}
+
+	if _, err := manager.SwapToken("missing", managerTokenB); err == nil {
+		t.Error("SwapToken() of an unknown key should fail")
+	}
+}
+
+func TestBotManager_Webhook(t *testing.T) {
+	manager := NewBotManager(ManagerConfig{})
+	defer manager.Close()
+
+	bot, _ := manager.Add("a", managerTokenA)
+	bot.SetWebhookSecretToken("secret")
+
+	payload := []byte(`{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"hi"}}}`)
+	ctx, update, err := manager.ProcessWebhook(context.Background(), "a", payload, "secret")
+	if err != nil {
+		t.Fatalf("ProcessWebhook() error = %v", err)
+	}
+	if update.BotKey != "a" || update.Bot != bot || BotKeyFromContext(ctx) != "a" {
+		t.Errorf("update = %+v, want tagged with bot a", update)
+	}
+
+	var handledKey string
+	err = manager.HandleUpdate(context.Background(), *update, func(ctx context.Context, u types.Update) error {
+		handledKey = BotKeyFromContext(ctx)
+		return nil
+	})
+	if err != nil || handledKey != "a" {
+		t.Errorf("HandleUpdate() key = %q, error = %v", handledKey, err)
+	}
+
+	if _, _, err := manager.ProcessWebhook(context.Background(), "missing", payload, "secret"); err == nil {
+		t.Error("ProcessWebhook() for an unknown bot should fail")
+	}
+}
@@ -0,0 +1,112 @@
+package zalobot
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// RuntimeConfig lists the settings Reconfigure can change while the bot is
+// running. Nil fields keep their current value.
+type RuntimeConfig struct {
+	// Timeout replaces the HTTP request timeout
+	Timeout *time.Duration
+	// RetryConfig replaces the retry behavior of API requests
+	RetryConfig *types.RetryConfig
+	// Debug switches the SDK's default stderr logger on or off. A logger
+	// passed with WithLogger is left as it is.
+	Debug *bool
+	// Environment switches between development and production
+	Environment *types.Environment
+	// SecretToken replaces the webhook secret token
+	SecretToken *string
+}
+
+// Reconfigure applies runtime changes to a running bot. It builds a new
+// configuration snapshot from the current one and publishes it to every
+// service at once; requests already in flight finish with the snapshot they
+// started with. Concurrent calls are applied one after another.
+func (b *BotAPI) Reconfigure(changes RuntimeConfig) error {
+	b.reconfigureMu.Lock()
+	defer b.reconfigureMu.Unlock()
+
+	current := b.currentConfig()
+	next := *current
+
+	if changes.Timeout != nil {
+		if *changes.Timeout <= 0 {
+			return types.NewValidationError("timeout must be positive")
+		}
+		next.Timeout = *changes.Timeout
+
+		// Copy the client so requests using the old one are unaffected;
+		// the transport and its connection pool are shared
+		client := http.Client{}
+		if current.HTTPClient != nil {
+			client = *current.HTTPClient
+		}
+		client.Timeout = next.Timeout
+		next.HTTPClient = &client
+	}
+
+	if changes.RetryConfig != nil {
+		if changes.RetryConfig.MaxRetries < 0 {
+			return types.NewValidationError("max retries cannot be negative")
+		}
+		retryConfig := *changes.RetryConfig
+		retryConfig.RetryableErrors = append([]types.ErrorType(nil), changes.RetryConfig.RetryableErrors...)
+		next.RetryConfig = &retryConfig
+		next.Retries = retryConfig.MaxRetries
+	}
+
+	if changes.Environment != nil {
+		if !changes.Environment.IsValid() {
+			return types.NewValidationError("invalid environment")
+		}
+		next.Environment = *changes.Environment
+	}
+
+	if changes.Debug != nil {
+		next.Debug = *changes.Debug
+	}
+
+	if changes.SecretToken != nil {
+		next.WebhookSecret = *changes.SecretToken
+	}
+
+	// All changes are valid; apply the ones that live outside the snapshot
+	if next.Environment != current.Environment {
+		if err := b.authService.SetEnvironment(next.Environment); err != nil {
+			b.authService.SetEnvironment(current.Environment)
+			return err
+		}
+	}
+
+	if b.ownLogger && next.Debug != current.Debug {
+		next.Logger = next.DefaultLogger()
+	}
+
+	b.mu.Lock()
+	b.config = &next
+	b.client = next.HTTPClient
+	b.mu.Unlock()
+
+	b.authService.SetConfig(&next)
+	b.messageService.SetConfig(&next)
+	b.userService.SetConfig(&next)
+	b.webhookService.SetConfig(&next)
+
+	if changes.SecretToken != nil {
+		b.SetWebhookSecretToken(*changes.SecretToken)
+	}
+
+	b.logger().Info("Bot reconfigured",
+		utils.Field{Key: "timeout", Value: next.Timeout},
+		utils.Field{Key: "retries", Value: next.Retries},
+		utils.Field{Key: "debug", Value: next.Debug},
+		utils.Field{Key: "environment", Value: next.Environment},
+	)
+	return nil
+}
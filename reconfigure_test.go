@@ -0,0 +1,249 @@
+package zalobot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+const reconfigureTestToken = "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+
+func TestBotAPI_Reconfigure(t *testing.T) {
+	bot, err := New(reconfigureTestToken)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	before := bot.currentConfig()
+	timeout := 5 * time.Second
+	debug := true
+	env := types.Development
+	secret := "new-secret"
+
+	err = bot.Reconfigure(RuntimeConfig{
+		Timeout:     &timeout,
+		RetryConfig: &types.RetryConfig{MaxRetries: 1, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1},
+		Debug:       &debug,
+		Environment: &env,
+		SecretToken: &secret,
+	})
+	if err != nil {
+		t.Fatalf("Reconfigure() error = %v", err)
+	}
+
+	after := bot.currentConfig()
+	if after == before {
+		t.Fatal("Reconfigure() modified the config in place, want a new snapshot")
+	}
+	if before.Timeout != 30*time.Second || before.Environment != types.Production {
+		t.Errorf("previous snapshot changed: %+v", before)
+	}
+	if after.Timeout != timeout || after.HTTPClient.Timeout != timeout || bot.GetHTTPClient().Timeout != timeout {
+		t.Errorf("timeout not applied: config %v, client %v", after.Timeout, bot.GetHTTPClient().Timeout)
+	}
+	if after.RetryConfig.MaxRetries != 1 || after.Retries != 1 {
+		t.Errorf("retry config not applied: %+v", after.RetryConfig)
+	}
+	if !after.Debug || !after.Logger.IsEnabled(utils.LogLevelDebug) {
+		t.Error("debug not applied")
+	}
+	if after.Environment != types.Development || bot.GetAuthService().GetEnvironment() != types.Development {
+		t.Error("environment not applied")
+	}
+	if bot.GetWebhookService().GetSecretToken() != secret {
+		t.Error("secret token not applied")
+	}
+
+	// Every service sees the same snapshot
+	if bot.GetMessageService().GetConfig() != after || bot.GetUserService().GetConfig() != after || bot.GetWebhookService().GetConfig() != after {
+		t.Error("services do not share the published snapshot")
+	}
+
+	// Invalid changes are rejected without publishing anything
+	badTimeout := time.Duration(0)
+	badEnv := types.Environment("staging")
+	if err := bot.Reconfigure(RuntimeConfig{Timeout: &badTimeout}); err == nil {
+		t.Error("Reconfigure() expected error for zero timeout")
+	}
+	if err := bot.Reconfigure(RuntimeConfig{Environment: &badEnv}); err == nil {
+		t.Error("Reconfigure() expected error for invalid environment")
+	}
+	if bot.currentConfig() != after {
+		t.Error("rejected Reconfigure() published a snapshot")
+	}
+}
+
+func TestBotAPI_Reconfigure_LeavesUserLogger(t *testing.T) {
+	logger := utils.NewLogger(utils.LogConfig{Level: utils.LogLevelWarn, Output: &strings.Builder{}})
+	bot, err := New(reconfigureTestToken, types.WithLogger(logger))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	debug := true
+	if err := bot.Reconfigure(RuntimeConfig{Debug: &debug}); err != nil {
+		t.Fatalf("Reconfigure() error = %v", err)
+	}
+	if bot.currentConfig().Logger != logger || logger.IsEnabled(utils.LogLevelInfo) {
+		t.Error("Reconfigure() changed a logger passed with WithLogger")
+	}
+
+	// The default logger is switched off again
+	defaultBot, err := New(reconfigureTestToken, types.WithDebug())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer defaultBot.Close()
+
+	debug = false
+	if err := defaultBot.Reconfigure(RuntimeConfig{Debug: &debug}); err != nil {
+		t.Fatalf("Reconfigure() error = %v", err)
+	}
+	if _, ok := defaultBot.currentConfig().Logger.(*utils.NoOpLogger); !ok {
+		t.Errorf("default logger after disabling debug = %T, want *utils.NoOpLogger", defaultBot.currentConfig().Logger)
+	}
+}
+
+func TestBotAPI_Reconfigure_CredentialCheckTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	bot, err := New(reconfigureTestToken, types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	timeout := 50 * time.Millisecond
+	if err := bot.Reconfigure(RuntimeConfig{Timeout: &timeout}); err != nil {
+		t.Fatalf("Reconfigure() error = %v", err)
+	}
+
+	start := time.Now()
+	if err := bot.GetAuthService().ValidateCredentials(context.Background()); err == nil {
+		t.Fatal("ValidateCredentials() expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ValidateCredentials() took %v, want the new timeout", elapsed)
+	}
+}
+
+func TestBotAPI_GetConfig_ReturnsCopy(t *testing.T) {
+	bot, err := New(reconfigureTestToken)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	config := bot.GetConfig()
+	config.Timeout = time.Nanosecond
+	config.RetryConfig.MaxRetries = 99
+
+	if got := bot.GetConfig(); got.Timeout != 30*time.Second || got.RetryConfig.MaxRetries == 99 {
+		t.Errorf("changing the result of GetConfig() changed the bot: %+v", got)
+	}
+}
+
+func TestBotAPI_Reconfigure_Concurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getWebhookInfo"):
+			w.Write([]byte(`{"ok":true,"result":{"url":"https://example.com/webhook"}}`))
+		default:
+			w.Write([]byte(`{"ok":true,"result":{"message_id":"m1","date":1750316131602}}`))
+		}
+	}))
+	defer server.Close()
+
+	bot, err := New(reconfigureTestToken, types.WithBaseURL(server.URL), types.WithLogger(utils.NewNoOpLogger()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+	bot.SetWebhookSecretToken("secret-0")
+
+	handler := bot.WebhookHandler(func(ctx context.Context, update types.Update) error { return nil })
+	payload := `{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","date":1750316131602,"text":"hi","from":{"id":"u1"},"chat":{"id":"c1","chat_type":"PRIVATE"}}}}`
+
+	const iterations = 50
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	// Writers: reconfigure every setting repeatedly
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(stop)
+		for i := 0; i < iterations; i++ {
+			timeout := time.Duration(i%5+1) * time.Second
+			debug := i%2 == 0
+			secret := "secret-" + string(rune('a'+i%26))
+			if err := bot.Reconfigure(RuntimeConfig{
+				Timeout:     &timeout,
+				RetryConfig: &types.RetryConfig{MaxRetries: i % 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1},
+				Debug:       &debug,
+				SecretToken: &secret,
+			}); err != nil {
+				t.Errorf("Reconfigure() error = %v", err)
+			}
+		}
+	}()
+
+	// Readers: API calls, webhook deliveries and config reads
+	readers := []func(){
+		func() {
+			if _, err := bot.SendMessage(types.MessageConfig{ChatID: "c1", Text: "hi"}); err != nil {
+				t.Errorf("SendMessage() error = %v", err)
+			}
+		},
+		func() {
+			if _, err := bot.GetWebhookInfo(); err != nil {
+				t.Errorf("GetWebhookInfo() error = %v", err)
+			}
+		},
+		func() {
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+			req.Header.Set(bot.GetFieldSecretToken(), bot.GetWebhookService().GetSecretToken())
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		},
+		func() {
+			config := bot.GetConfig()
+			if config.HTTPClient.Timeout != config.Timeout {
+				t.Errorf("inconsistent snapshot: client timeout %v, config timeout %v", config.HTTPClient.Timeout, config.Timeout)
+			}
+			_ = bot.GetAuthService().GetEnvironment()
+		},
+	}
+	for _, read := range readers {
+		wg.Add(1)
+		go func(read func()) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					read()
+				}
+			}
+		}(read)
+	}
+
+	wg.Wait()
+}
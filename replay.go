@@ -0,0 +1,157 @@
+package zalobot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// ReplayConfig represents configuration for replaying captured updates
+type ReplayConfig struct {
+	// Speed scales the delays between records: 1 keeps the original pace
+	// and 10 replays ten times faster (0 replays without delays)
+	Speed float64
+	// Sources limits replay to records from these sources, such as
+	// UpdateSourceWebhook (default: all)
+	Sources []string
+}
+
+// ReplayResult counts what a replay processed
+type ReplayResult struct {
+	Records       int `json:"records"`
+	Updates       int `json:"updates"`
+	ParseErrors   int `json:"parse_errors"`
+	HandlerErrors int `json:"handler_errors"`
+}
+
+// recordPayload passes a raw payload to the configured recorder
+func (b *BotAPI) recordPayload(source string, payload []byte) {
+	recorder := b.currentConfig().Recorder
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Record(source, payload); err != nil {
+		b.logger().Warn("Failed to record payload",
+			utils.Field{Key: "source", Value: source},
+			utils.Field{Key: "error", Value: err},
+		)
+	}
+}
+
+// ParseRecord parses a captured payload into updates the same way it was
+// parsed when received: a webhook body holds one update and a getUpdates
+// response holds any number
+func ParseRecord(record utils.Record) ([]types.Update, error) {
+	payload := []byte(record.Payload)
+
+	// Payloads that were not valid JSON are stored as a JSON string
+	var raw string
+	if json.Unmarshal(payload, &raw) == nil {
+		payload = []byte(raw)
+	}
+
+	switch record.Source {
+	case UpdateSourceWebhook:
+		update, err := types.ParseWebhookUpdate(payload)
+		if err != nil {
+			return nil, err
+		}
+		return []types.Update{*update}, nil
+
+	case UpdateSourcePolling:
+		var response struct {
+			OK     bool           `json:"ok"`
+			Result []types.Update `json:"result"`
+		}
+		if err := json.Unmarshal(payload, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse getUpdates response: %w", err)
+		}
+		return response.Result, nil
+
+	default:
+		return nil, fmt.Errorf("unknown record source %q", record.Source)
+	}
+}
+
+// ReplayRecords reads captured records from reader, in the JSONL format
+// written by utils.Recorder, waits between them according to config, and
+// calls fn with each record and the result of parsing it. Reading stops at
+// the first malformed record, when fn returns an error, or when ctx is
+// cancelled.
+func ReplayRecords(ctx context.Context, reader io.Reader, config ReplayConfig, fn func(record utils.Record, updates []types.Update, err error) error) error {
+	var previous time.Time
+
+	sources := make(map[string]bool, len(config.Sources))
+	for _, source := range config.Sources {
+		sources[source] = true
+	}
+
+	return utils.ReadRecords(reader, func(record utils.Record) error {
+		if len(sources) > 0 && !sources[record.Source] {
+			return nil
+		}
+
+		if config.Speed > 0 && !previous.IsZero() && record.Time.After(previous) {
+			delay := time.Duration(float64(record.Time.Sub(previous)) / config.Speed)
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		previous = record.Time
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		updates, err := ParseRecord(record)
+		return fn(record, updates, err)
+	})
+}
+
+// Replay feeds captured updates from reader through the parser and
+// HandleUpdate, as ReplayRecords. Parse and handler errors are counted and
+// replay continues.
+func (b *BotAPI) Replay(ctx context.Context, reader io.Reader, config ReplayConfig, handler UpdateHandler) (ReplayResult, error) {
+	var result ReplayResult
+
+	err := ReplayRecords(ctx, reader, config, func(record utils.Record, updates []types.Update, err error) error {
+		result.Records++
+		if err != nil {
+			result.ParseErrors++
+			b.logger().Warn("Failed to parse recorded payload",
+				utils.Field{Key: "source", Value: record.Source},
+				utils.Field{Key: "time", Value: record.Time},
+				utils.Field{Key: "error", Value: err},
+			)
+			return nil
+		}
+
+		for _, update := range updates {
+			result.Updates++
+			if err := b.HandleUpdate(ctx, update, handler); err != nil {
+				result.HandlerErrors++
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// ReplayFile replays a capture file, which may be gzipped by rotation
+func (b *BotAPI) ReplayFile(ctx context.Context, path string, config ReplayConfig, handler UpdateHandler) (ReplayResult, error) {
+	reader, err := utils.OpenRecordFile(path)
+	if err != nil {
+		return ReplayResult{}, err
+	}
+	defer reader.Close()
+	return b.Replay(ctx, reader, config, handler)
+}
@@ -0,0 +1,134 @@
+package zalobot
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestBotAPI_RecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":[{"update_id":1,"message":{"message_id":"m2","text":"polled","from":{"id":"u1","phone":"0901234567"}}}]}`))
+	}))
+	defer server.Close()
+
+	var capture syncBuffer
+	recorder, err := utils.NewRecorder(utils.RecorderConfig{Output: &capture})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+		types.WithBaseURL(server.URL),
+		types.WithUpdateRecorder(recorder),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+	bot.SetWebhookSecretToken("webhook-secret")
+
+	webhookPayload := []byte(`{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"hello"}}}`)
+	if _, err := bot.ProcessWebhook(webhookPayload, "webhook-secret"); err != nil {
+		t.Fatalf("ProcessWebhook() error = %v", err)
+	}
+	// Rejected requests are not recorded
+	bot.ProcessWebhook([]byte(`{"ok":true}`), "wrong-secret")
+	// Unparseable bodies are recorded for reproducing parser bugs
+	bot.ProcessWebhook([]byte(`{"ok":true,"result":{}}`), "webhook-secret")
+
+	if _, err := bot.GetUpdates(types.UpdateConfig{}); err != nil {
+		t.Fatalf("GetUpdates() error = %v", err)
+	}
+
+	if strings.Contains(capture.String(), "0901234567") {
+		t.Errorf("capture contains personal data:\n%s", capture.String())
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		var texts []string
+		result, err := bot.Replay(context.Background(), strings.NewReader(capture.String()), ReplayConfig{}, func(ctx context.Context, update types.Update) error {
+			texts = append(texts, update.Message.Text)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Replay() error = %v", err)
+		}
+
+		want := ReplayResult{Records: 3, Updates: 2, ParseErrors: 1}
+		if result != want {
+			t.Errorf("Replay() = %+v, want %+v", result, want)
+		}
+		if strings.Join(texts, ",") != "hello,polled" {
+			t.Errorf("replayed texts = %v, want [hello polled]", texts)
+		}
+	})
+
+	t.Run("source filter", func(t *testing.T) {
+		result, err := bot.Replay(context.Background(), strings.NewReader(capture.String()), ReplayConfig{Sources: []string{UpdateSourcePolling}}, func(ctx context.Context, update types.Update) error {
+			return nil
+		})
+		if err != nil || result.Records != 1 || result.Updates != 1 {
+			t.Errorf("Replay() = %+v, %v, want 1 polling record", result, err)
+		}
+	})
+}
+
+func TestReplayRecords_Speed(t *testing.T) {
+	start := time.Unix(1700000000, 0).UTC()
+	capture := strings.Join([]string{
+		`{"time":"` + start.Format(time.RFC3339Nano) + `","source":"webhook","payload":{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1"}}}}`,
+		`{"time":"` + start.Add(2*time.Second).Format(time.RFC3339Nano) + `","source":"webhook","payload":{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m2"}}}}`,
+	}, "\n")
+
+	began := time.Now()
+	count := 0
+	err := ReplayRecords(context.Background(), strings.NewReader(capture), ReplayConfig{Speed: 20}, func(record utils.Record, updates []types.Update, err error) error {
+		count += len(updates)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ReplayRecords() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("replayed %d updates, want 2", count)
+	}
+	if elapsed := time.Since(began); elapsed < 80*time.Millisecond {
+		t.Errorf("replay took %s, want about 100ms at 20x speed", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ReplayRecords(ctx, strings.NewReader(capture), ReplayConfig{Speed: 1}, func(record utils.Record, updates []types.Update, err error) error {
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("ReplayRecords() with cancelled context error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,238 @@
+package zalobot
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// DefaultShutdownTimeout is how long a webhook server waits for in-flight
+// requests when shutting down
+const DefaultShutdownTimeout = 10 * time.Second
+
+// UpdateSource delivers updates to a handler for BotAPI.Run. PollingSource
+// and WebhookSource let the same handler run with either delivery mode.
+type UpdateSource interface {
+	// Name identifies the source, such as UpdateSourcePolling
+	Name() string
+	// Prepare makes the webhook state on the Zalo side match the source
+	Prepare(ctx context.Context, bot *BotAPI) error
+	// Run passes updates to handler until ctx is cancelled, then stops
+	// gracefully. It returns nil after a shutdown requested through ctx.
+	Run(ctx context.Context, bot *BotAPI, handler UpdateHandler) error
+}
+
+// Run prepares source and passes its updates to handler until ctx is
+// cancelled or the process receives SIGINT or SIGTERM. Handlers still
+// running at shutdown finish with a context that is not cancelled.
+func (b *BotAPI) Run(ctx context.Context, source UpdateSource, handler UpdateHandler) error {
+	if source == nil {
+		return types.NewValidationError("update source is required")
+	}
+	if handler == nil {
+		return types.NewValidationError("update handler is required")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := source.Prepare(ctx, b); err != nil {
+		return err
+	}
+
+	b.logger().Info("Bot running", utils.Field{Key: "source", Value: source.Name()})
+	err := source.Run(ctx, b, handler)
+	b.logger().Info("Bot stopped", utils.Field{Key: "source", Value: source.Name()})
+	return err
+}
+
+// NewUpdateSource returns a WebhookSource listening on addr when webhook has
+// a URL, and a PollingSource otherwise, so the mode can be chosen by
+// configuration alone
+func NewUpdateSource(webhook types.WebhookConfig, addr string) UpdateSource {
+	if webhook.URL == "" {
+		return &PollingSource{}
+	}
+	return &WebhookSource{Webhook: webhook, Addr: addr}
+}
+
+// PollingSource receives updates with getUpdates. Prepare deletes a
+// registered webhook, since Zalo does not serve getUpdates while one is set.
+type PollingSource struct {
+	// Config is passed to GetUpdatesChan
+	Config types.UpdateConfig
+}
+
+// Name returns UpdateSourcePolling
+func (s *PollingSource) Name() string {
+	return UpdateSourcePolling
+}
+
+// Prepare stops a running webhook watchdog and deletes the registered webhook
+func (s *PollingSource) Prepare(ctx context.Context, bot *BotAPI) error {
+	if watchdog := bot.WebhookWatchdog(); watchdog != nil {
+		watchdog.Stop()
+	}
+
+	info, err := bot.GetWebhookInfo()
+	if err != nil {
+		return err
+	}
+	if info == nil || info.URL == "" {
+		return nil
+	}
+
+	bot.logger().Info("Deleting webhook to receive updates by polling", utils.Field{Key: "url", Value: info.URL})
+	return bot.DeleteWebhook()
+}
+
+// Run polls for updates and handles them one at a time, in order. Updates
+// already received when ctx is cancelled are handled before Run returns.
+func (s *PollingSource) Run(ctx context.Context, bot *BotAPI, handler UpdateHandler) error {
+	config := s.Config
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	updates := bot.GetUpdatesChan(config)
+	handlerCtx := context.WithoutCancel(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			bot.StopPolling()
+			for update := range updates {
+				bot.HandleUpdate(handlerCtx, update, handler)
+			}
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return types.NewNetworkError("polling stopped")
+			}
+			bot.HandleUpdate(handlerCtx, update, handler)
+		}
+	}
+}
+
+// WebhookSource receives updates on an HTTP server. Prepare registers
+// Webhook with SetWebhook, and the server routes Path to WebhookHandler.
+type WebhookSource struct {
+	// Webhook is the registration passed to SetWebhook
+	Webhook types.WebhookConfig
+	// Addr is the address the server listens on (default: ":8080")
+	Addr string
+	// Listener, if set, is used instead of listening on Addr
+	Listener net.Listener
+	// Path is the path webhook deliveries are served on (default: the path
+	// of Webhook.URL, or "/webhook")
+	Path string
+	// Mux, if set, serves the webhook next to other routes such as health checks
+	Mux *http.ServeMux
+	// CertFile and KeyFile, if set, make the server use TLS
+	CertFile string
+	KeyFile  string
+	// ShutdownTimeout bounds the wait for in-flight requests at shutdown
+	// (default: DefaultShutdownTimeout)
+	ShutdownTimeout time.Duration
+	// DeleteOnShutdown deletes the webhook when Run returns, so updates
+	// queue for the next process instead of failing delivery
+	DeleteOnShutdown bool
+}
+
+// Name returns UpdateSourceWebhook
+func (s *WebhookSource) Name() string {
+	return UpdateSourceWebhook
+}
+
+// Prepare stops polling and registers the webhook
+func (s *WebhookSource) Prepare(ctx context.Context, bot *BotAPI) error {
+	bot.StopPolling()
+	return bot.SetWebhook(s.Webhook)
+}
+
+// Run serves webhook deliveries until ctx is cancelled, then shuts the
+// server down, waiting for in-flight requests
+func (s *WebhookSource) Run(ctx context.Context, bot *BotAPI, handler UpdateHandler) error {
+	listener := s.Listener
+	if listener == nil {
+		addr := s.Addr
+		if addr == "" {
+			addr = ":8080"
+		}
+		var err error
+		if listener, err = net.Listen("tcp", addr); err != nil {
+			return types.NewNetworkError("failed to listen for webhooks: " + err.Error())
+		}
+	}
+
+	mux := s.Mux
+	if mux == nil {
+		mux = http.NewServeMux()
+	}
+	mux.Handle(s.path(), bot.WebhookHandler(handler))
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.CertFile != "" || s.KeyFile != "" {
+			serveErr <- server.ServeTLS(listener, s.CertFile, s.KeyFile)
+		} else {
+			serveErr <- server.Serve(listener)
+		}
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		err = types.NewNetworkError("webhook server failed: " + err.Error())
+	case <-ctx.Done():
+		timeout := s.ShutdownTimeout
+		if timeout <= 0 {
+			timeout = DefaultShutdownTimeout
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+			err = types.NewNetworkError("webhook server shutdown: " + shutdownErr.Error())
+		}
+		if serveErr := <-serveErr; err == nil && serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			err = types.NewNetworkError("webhook server failed: " + serveErr.Error())
+		}
+	}
+
+	if s.DeleteOnShutdown {
+		if deleteErr := bot.DeleteWebhook(); deleteErr != nil && err == nil {
+			err = deleteErr
+		}
+	}
+	return err
+}
+
+// path returns the path webhook deliveries are served on
+func (s *WebhookSource) path() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	if u, err := url.Parse(s.Webhook.URL); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return "/webhook"
+}
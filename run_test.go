@@ -0,0 +1,198 @@
+package zalobot
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+// fakeRunServer records the webhook calls made while preparing a source
+type fakeRunServer struct {
+	mu         sync.Mutex
+	webhookURL string
+	calls      []string
+}
+
+func (f *fakeRunServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.calls = append(f.calls, method)
+
+	switch method {
+	case "getWebhookInfo":
+		w.Write([]byte(`{"ok":true,"result":{"url":"` + f.webhookURL + `"}}`))
+	case "deleteWebhook":
+		f.webhookURL = ""
+		w.Write([]byte(`{"ok":true}`))
+	case "setWebhook":
+		f.webhookURL = "https://example.com/hook"
+		w.Write([]byte(`{"ok":true}`))
+	case "getUpdates":
+		if r.URL.Query().Get("offset") == "" {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":1,"message":{"message_id":"m1","text":"hi"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}
+}
+
+func (f *fakeRunServer) called(method string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, call := range f.calls {
+		if call == method {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBotAPI_Run_Polling(t *testing.T) {
+	fake := &fakeRunServer{webhookURL: "https://example.com/hook"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan types.Update, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.Run(ctx, &PollingSource{}, func(ctx context.Context, update types.Update) error {
+			received <- update
+			return nil
+		})
+	}()
+
+	select {
+	case update := <-received:
+		if update.Message == nil || update.Message.Text != "hi" {
+			t.Errorf("update = %+v, want message hi", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for polled update")
+	}
+
+	if !fake.called("deleteWebhook") {
+		t.Error("Run() with PollingSource should delete the registered webhook")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after cancel")
+	}
+	if bot.IsPolling() {
+		t.Error("polling should stop when Run returns")
+	}
+}
+
+func TestBotAPI_Run_Webhook(t *testing.T) {
+	fake := &fakeRunServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := &WebhookSource{
+		Webhook:          types.WebhookConfig{URL: "https://example.com/hook", SecretToken: "webhook-secret"},
+		Listener:         listener,
+		DeleteOnShutdown: true,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan types.Update, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.Run(ctx, source, func(ctx context.Context, update types.Update) error {
+			received <- update
+			return nil
+		})
+	}()
+
+	payload := `{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"hi"}}}`
+	var resp *http.Response
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		req, _ := http.NewRequest(http.MethodPost, "http://"+listener.Addr().String()+"/hook", strings.NewReader(payload))
+		req.Header.Set(bot.GetFieldSecretToken(), "webhook-secret")
+		resp, err = http.DefaultClient.Do(req)
+		if err == nil && resp.StatusCode != http.StatusNotFound {
+			break
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("webhook server not serving: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("webhook status = %d, want 200", resp.StatusCode)
+	}
+
+	select {
+	case update := <-received:
+		if update.EventName != types.EventMessageText {
+			t.Errorf("update event = %q", update.EventName)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook update")
+	}
+
+	if !fake.called("setWebhook") {
+		t.Error("Run() with WebhookSource should register the webhook")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after cancel")
+	}
+	if !fake.called("deleteWebhook") {
+		t.Error("DeleteOnShutdown should delete the webhook")
+	}
+}
+
+func TestNewUpdateSource(t *testing.T) {
+	if source := NewUpdateSource(types.WebhookConfig{}, ":8080"); source.Name() != UpdateSourcePolling {
+		t.Errorf("NewUpdateSource() without URL = %s, want polling", source.Name())
+	}
+	source := NewUpdateSource(types.WebhookConfig{URL: "https://example.com/bot"}, ":8443")
+	webhook, ok := source.(*WebhookSource)
+	if !ok || webhook.Addr != ":8443" || webhook.path() != "/bot" {
+		t.Errorf("NewUpdateSource() with URL = %+v, want webhook source on :8443 serving /bot", source)
+	}
+}
@@ -205,7 +205,7 @@ run_validation() {
 update_version_yml() {
     log_info "Updating version.yml..."
 
-    local version_file="${PROJECT_ROOT}/version.yml"
+    local version_file="${PROJECT_ROOT}/utils/version.yml"
 
     if [[ ! -f "${version_file}" ]]; then
         log_warning "version.yml not found at ${version_file}, skipping"
@@ -493,7 +493,7 @@ main() {
     # Commit changelog and version.yml changes if not dry run
     if [[ "${DRY_RUN}" == false ]]; then
         log_info "Committing CHANGELOG.md and version.yml changes..."
-        git add "${PROJECT_ROOT}/CHANGELOG.md" "${PROJECT_ROOT}/version.yml"
+        git add "${PROJECT_ROOT}/CHANGELOG.md" "${PROJECT_ROOT}/utils/version.yml"
         git commit -m "chore: update CHANGELOG and version.yml for ${VERSION}"
         log_success "CHANGELOG.md and version.yml committed"
         echo ""
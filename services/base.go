@@ -8,10 +8,12 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/vkhangstack/go-zalo-bot/auth"
 	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
 // BaseService provides common functionality for all services
@@ -19,6 +21,11 @@ type BaseService struct {
 	authService *auth.AuthService
 	client      *http.Client
 	config      *types.Config
+	stats       *RequestStats
+
+	// published is the configuration snapshot set by SetConfig; it takes
+	// precedence over config and is never modified once stored
+	published atomic.Pointer[types.Config]
 }
 
 // NewBaseService creates a new base service
@@ -27,6 +34,7 @@ func NewBaseService(authService *auth.AuthService, client *http.Client, config *
 		authService: authService,
 		client:      client,
 		config:      config,
+		stats:       NewRequestStats(),
 	}
 }
 
@@ -73,18 +81,65 @@ func (r *APIResponse) GetError() error {
 
 // DoRequest executes an HTTP request with retry logic, connection pooling, and timeout handling
 // URL pattern: https://bot-api.zapps.me/bot${BOT_TOKEN}/method
-func (s *BaseService) DoRequest(ctx context.Context, apiReq *APIRequest) (*APIResponse, error) {
+func (s *BaseService) DoRequest(ctx context.Context, apiReq *APIRequest) (resp *APIResponse, err error) {
 	var lastErr error
-	retryConfig := s.config.RetryConfig
+	retryConfig := s.currentConfig().RetryConfig
 	if retryConfig == nil {
 		retryConfig = types.DefaultRetryConfig()
 	}
 
+	metrics := s.metrics()
+	logger := s.logger()
+	start := time.Now()
+	attempts := 0
+	refreshed := false
+
+	ctx, span := s.tracer().Start(ctx, "zalobot.api."+apiReq.APIMethod,
+		utils.Field{Key: "zalo.method", Value: apiReq.APIMethod},
+		utils.Field{Key: "http.method", Value: apiReq.Method},
+	)
+	defer func() {
+		// One outcome per call, however many attempts it took
+		if err != nil {
+			s.stats.RecordFailure(err)
+		} else {
+			s.stats.RecordSuccess()
+		}
+
+		outcome := Outcome(err)
+		metrics.ObserveAPIRequest(apiReq.APIMethod, outcome, time.Since(start))
+		span.SetAttributes(
+			utils.Field{Key: "zalo.attempts", Value: attempts},
+			utils.Field{Key: "zalo.outcome", Value: outcome},
+		)
+		span.RecordError(err)
+		span.End()
+
+		if err != nil {
+			logger.Warn("Zalo API request failed",
+				utils.Field{Key: "method", Value: apiReq.APIMethod},
+				utils.Field{Key: "attempts", Value: attempts},
+				utils.Field{Key: "duration", Value: time.Since(start)},
+				utils.Field{Key: "error", Value: err},
+			)
+		}
+	}()
+
+	s.stats.Begin()
+	defer s.stats.End()
+
 	// Retry loop with exponential backoff
 	for attempt := 0; attempt <= retryConfig.MaxRetries; attempt++ {
 		// Add delay for retry attempts
 		if attempt > 0 {
+			metrics.IncRetry(apiReq.APIMethod)
 			delay := s.calculateBackoffDelay(attempt, retryConfig)
+			logger.Debug("Retrying Zalo API request",
+				utils.Field{Key: "method", Value: apiReq.APIMethod},
+				utils.Field{Key: "attempt", Value: attempt + 1},
+				utils.Field{Key: "delay", Value: delay},
+				utils.Field{Key: "error", Value: lastErr},
+			)
 			select {
 			case <-ctx.Done():
 				return nil, types.NewNetworkError(fmt.Sprintf("request cancelled: %v", ctx.Err()))
@@ -93,12 +148,35 @@ func (s *BaseService) DoRequest(ctx context.Context, apiReq *APIRequest) (*APIRe
 			}
 		}
 
+		if err := s.waitRateLimit(ctx); err != nil {
+			return nil, types.NewNetworkError(fmt.Sprintf("request cancelled: %v", err))
+		}
+
 		// Execute the request
-		resp, err := s.executeRequest(ctx, apiReq)
+		attempts++
+		token := s.authService.GetToken()
+		resp, err := s.executeRequest(ctx, apiReq, attempts)
+
+		// A rejected token is re-fetched once from the credential provider,
+		// and the request is repeated straight away if it changed
+		if err != nil && !refreshed && types.IsAuthError(err) {
+			refreshed = true
+			if s.authService.RefreshAfterAuthError(ctx, err, token) {
+				if err := s.waitRateLimit(ctx); err != nil {
+					return nil, types.NewNetworkError(fmt.Sprintf("request cancelled: %v", err))
+				}
+				attempts++
+				resp, err = s.executeRequest(ctx, apiReq, attempts)
+			}
+		}
+
 		if err == nil {
 			return resp, nil
 		}
 
+		if zaloBotErr, ok := err.(*types.ZaloBotError); ok && zaloBotErr.Type == types.ErrorTypeRateLimit {
+			metrics.IncRateLimit(apiReq.APIMethod)
+		}
 		lastErr = err
 
 		// Check if error is retryable
@@ -115,11 +193,28 @@ func (s *BaseService) DoRequest(ctx context.Context, apiReq *APIRequest) (*APIRe
 	return nil, lastErr
 }
 
-// executeRequest performs a single HTTP request
-func (s *BaseService) executeRequest(ctx context.Context, apiReq *APIRequest) (*APIResponse, error) {
+// executeRequest performs a single HTTP request and logs it at debug level
+func (s *BaseService) executeRequest(ctx context.Context, apiReq *APIRequest, attempt int) (_ *APIResponse, err error) {
 	// Construct URL with bot token embedded
 	url := s.authService.GetAPIEndpoint(apiReq.APIMethod)
 
+	start := time.Now()
+	status := 0
+	defer func() {
+		fields := []utils.Field{
+			{Key: "method", Value: apiReq.APIMethod},
+			{Key: "http_method", Value: apiReq.Method},
+			{Key: "url", Value: utils.RedactURL(url)},
+			{Key: "status", Value: status},
+			{Key: "duration", Value: time.Since(start)},
+			{Key: "attempt", Value: attempt},
+		}
+		if err != nil {
+			fields = append(fields, utils.Field{Key: "error", Value: err})
+		}
+		s.logger().Debug("Zalo API request", fields...)
+	}()
+
 	// Add query parameters if any
 	if len(apiReq.QueryParams) > 0 {
 		url += "?"
@@ -151,19 +246,20 @@ func (s *BaseService) executeRequest(ctx context.Context, apiReq *APIRequest) (*
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", UserAgent())
+	req.Header.Set("User-Agent", s.userAgent())
 
 	// Add environment-specific headers
-	if s.config.Environment == types.Development {
+	if s.currentConfig().Environment == types.Development {
 		req.Header.Set("X-Environment", "development")
 	}
 
 	// Execute request with connection pooling (handled by http.Client)
-	resp, err := s.client.Do(req)
+	resp, err := s.httpClient().Do(req)
 	if err != nil {
 		return nil, types.NewNetworkError(fmt.Sprintf("request failed: %v", err))
 	}
 	defer resp.Body.Close()
+	status = resp.StatusCode
 
 	// Read response body
 	bodyBytes, err := io.ReadAll(resp.Body)
@@ -173,6 +269,7 @@ func (s *BaseService) executeRequest(ctx context.Context, apiReq *APIRequest) (*
 
 	// Parse rate limit information from headers
 	rateLimitInfo := types.ParseRateLimitHeaders(resp.Header)
+	s.stats.RecordRateLimit(rateLimitInfo)
 
 	// Handle HTTP status codes
 	if resp.StatusCode == http.StatusTooManyRequests {
@@ -233,14 +330,109 @@ func (s *BaseService) GetAuthService() *auth.AuthService {
 
 // GetHTTPClient returns the HTTP client
 func (s *BaseService) GetHTTPClient() *http.Client {
-	return s.client
+	return s.httpClient()
 }
 
-// GetConfig returns the configuration
+// GetConfig returns the configuration. After SetConfig it returns the
+// published snapshot, which must be treated as read-only.
 func (s *BaseService) GetConfig() *types.Config {
+	return s.currentConfig()
+}
+
+// SetConfig atomically publishes a new configuration snapshot to the service.
+// Requests already in flight keep the snapshot they started with. The
+// snapshot must not be modified after it is published.
+func (s *BaseService) SetConfig(config *types.Config) {
+	s.published.Store(config)
+}
+
+// currentConfig returns the published snapshot, or the initial configuration
+func (s *BaseService) currentConfig() *types.Config {
+	if config := s.published.Load(); config != nil {
+		return config
+	}
 	return s.config
 }
 
+// httpClient returns the HTTP client of the published snapshot, or the
+// initial client
+func (s *BaseService) httpClient() *http.Client {
+	if config := s.published.Load(); config != nil && config.HTTPClient != nil {
+		return config.HTTPClient
+	}
+	return s.client
+}
+
+// metrics returns the configured metrics collector, or a no-op collector
+func (s *BaseService) metrics() utils.Metrics {
+	config := s.currentConfig()
+	if config == nil || config.Metrics == nil {
+		return utils.NewNoOpMetrics()
+	}
+	return config.Metrics
+}
+
+// logger returns the configured logger, or a no-op logger
+func (s *BaseService) logger() utils.Logger {
+	config := s.currentConfig()
+	if config == nil || config.Logger == nil {
+		return utils.NewNoOpLogger()
+	}
+	return config.Logger
+}
+
+// userAgent returns the configured User-Agent, or the SDK default
+func (s *BaseService) userAgent() string {
+	if config := s.currentConfig(); config != nil && config.UserAgent != "" {
+		return config.UserAgent
+	}
+	return UserAgent()
+}
+
+// tracer returns the configured tracer, or a no-op tracer
+func (s *BaseService) tracer() utils.Tracer {
+	config := s.currentConfig()
+	if config == nil || config.Tracer == nil {
+		return utils.NewNoOpTracer()
+	}
+	return config.Tracer
+}
+
+// waitRateLimit blocks until the configured rate limiter admits a request
+func (s *BaseService) waitRateLimit(ctx context.Context) error {
+	config := s.currentConfig()
+	if config == nil || config.RateLimiter == nil {
+		return nil
+	}
+	return config.RateLimiter.Wait(ctx)
+}
+
+// Outcome returns the metrics outcome label for an API call result: "success"
+// for a nil error, the error type (e.g. "rate_limit_error") for a ZaloBotError
+// and "error" otherwise
+func Outcome(err error) string {
+	if err == nil {
+		return utils.OutcomeSuccess
+	}
+	if zaloBotErr, ok := err.(*types.ZaloBotError); ok && zaloBotErr.Type != "" {
+		return zaloBotErr.Type.String()
+	}
+	return utils.OutcomeError
+}
+
+// GetRequestStats returns the request stats tracker
+func (s *BaseService) GetRequestStats() *RequestStats {
+	return s.stats
+}
+
+// SetRequestStats replaces the request stats tracker, allowing several
+// services to share one
+func (s *BaseService) SetRequestStats(stats *RequestStats) {
+	if stats != nil {
+		s.stats = stats
+	}
+}
+
 // GetFieldSecretToken returns the header field name for webhook secret token
 func (s *BaseService) GetFieldSecretToken() string {
 	return "x-bot-api-secret-token"
@@ -1,15 +1,19 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/vkhangstack/go-zalo-bot/auth"
 	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
 func setupTestService(t *testing.T, botToken string) (*BaseService, *types.Config) {
@@ -184,6 +188,42 @@ func TestBaseService_DoRequest_AuthError(t *testing.T) {
 	}
 }
 
+func TestBaseService_DoRequest_AuthErrorRefreshesToken(t *testing.T) {
+	oldToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	newToken := "789012:XYZ-GHI5678jklMn-abc90D3f4g567hi89"
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.Contains(r.URL.Path, oldToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(APIResponse{OK: false, ErrorCode: 401, Description: "invalid token"})
+			return
+		}
+		json.NewEncoder(w).Encode(APIResponse{OK: true, Result: json.RawMessage(`{}`)})
+	}))
+	defer server.Close()
+
+	service, config := setupTestService(t, oldToken)
+	config.BaseURL = server.URL
+	config.Credentials = auth.StaticCredentials(newToken)
+
+	authService, _ := auth.NewAuthService(config)
+	service.authService = authService
+
+	if _, err := service.DoRequest(context.Background(), &APIRequest{Method: "GET", APIMethod: "getMe"}); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+
+	want := []string{"/bot" + oldToken + "/getMe", "/bot" + newToken + "/getMe"}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("request paths = %v, want %v", paths, want)
+	}
+	if authService.GetToken() != newToken {
+		t.Errorf("token = %s, want %s", authService.GetToken(), newToken)
+	}
+}
+
 func TestBaseService_DoRequest_RetryMechanism(t *testing.T) {
 	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
 
@@ -446,3 +486,175 @@ func TestBaseService_Getters(t *testing.T) {
 		t.Errorf("GetFieldSecretToken() = %v, want x-bot-api-secret-token", got)
 	}
 }
+
+// recordingMetrics records the metrics hooks called by the services
+type recordingMetrics struct {
+	utils.NoOpMetrics
+	mu         sync.Mutex
+	requests   []string
+	retries    int
+	rateLimits int
+}
+
+func (m *recordingMetrics) ObserveAPIRequest(method, outcome string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, method+":"+outcome)
+}
+
+func (m *recordingMetrics) IncRetry(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+}
+
+func (m *recordingMetrics) IncRateLimit(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rateLimits++
+}
+
+func TestBaseService_DoRequest_Metrics(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+
+	attemptCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attemptCount++
+		if attemptCount == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(APIResponse{OK: true, Result: json.RawMessage(`{}`)})
+	}))
+	defer server.Close()
+
+	metrics := &recordingMetrics{}
+	service, config := setupTestService(t, botToken)
+	config.BaseURL = server.URL
+	config.Metrics = metrics
+	config.RetryConfig = &types.RetryConfig{
+		MaxRetries:      2,
+		InitialDelay:    time.Millisecond,
+		MaxDelay:        10 * time.Millisecond,
+		BackoffFactor:   2.0,
+		RetryableErrors: []types.ErrorType{types.ErrorTypeRateLimit},
+	}
+
+	authService, _ := auth.NewAuthService(config)
+	service.authService = authService
+
+	if _, err := service.DoRequest(context.Background(), &APIRequest{Method: "GET", APIMethod: "getMe"}); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+
+	if len(metrics.requests) != 1 || metrics.requests[0] != "getMe:success" {
+		t.Errorf("ObserveAPIRequest calls = %v, want [getMe:success]", metrics.requests)
+	}
+	if metrics.retries != 1 || metrics.rateLimits != 1 {
+		t.Errorf("retries/rateLimits = %d/%d, want 1/1", metrics.retries, metrics.rateLimits)
+	}
+}
+
+func TestBaseService_DoRequest_Logging(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{OK: false, ErrorCode: 400, Description: "bad request"})
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	service, config := setupTestService(t, botToken)
+	config.BaseURL = server.URL
+	config.Logger = utils.NewLogger(utils.LogConfig{Level: utils.LogLevelDebug, Output: &buf, Format: utils.LogFormatJSON})
+
+	authService, _ := auth.NewAuthService(config)
+	service.authService = authService
+
+	if _, err := service.DoRequest(context.Background(), &APIRequest{Method: "GET", APIMethod: "getMe"}); err == nil {
+		t.Fatal("DoRequest() expected error")
+	}
+
+	output := buf.String()
+	if strings.Contains(output, botToken) {
+		t.Errorf("log output contains the bot token:\n%s", output)
+	}
+
+	for _, want := range []string{
+		`"level":"DEBUG","message":"Zalo API request"`,
+		`"url":"` + server.URL + `/bot***/getMe"`,
+		`"status":400`,
+		`"attempt":1`,
+		`"level":"WARN","message":"Zalo API request failed"`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("log output missing %q\n%s", want, output)
+		}
+	}
+}
+
+// countingLimiter counts Wait calls and fails once limit is reached
+type countingLimiter struct {
+	waits int
+	limit int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	if l.waits > l.limit {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func TestBaseService_DoRequest_RateLimiter(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(APIResponse{OK: true, Result: json.RawMessage(`{}`)})
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{limit: 1}
+	service, config := setupTestService(t, botToken)
+	config.BaseURL = server.URL
+	config.RateLimiter = limiter
+
+	authService, _ := auth.NewAuthService(config)
+	service.authService = authService
+
+	req := &APIRequest{Method: "GET", APIMethod: "getMe"}
+	if _, err := service.DoRequest(context.Background(), req); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+
+	_, err := service.DoRequest(context.Background(), req)
+	if zaloBotErr, ok := err.(*types.ZaloBotError); !ok || zaloBotErr.Type != types.ErrorTypeNetwork {
+		t.Errorf("DoRequest() error = %v, want network error from the limiter", err)
+	}
+	if limiter.waits != 2 {
+		t.Errorf("limiter waits = %d, want 2", limiter.waits)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil error", nil, utils.OutcomeSuccess},
+		{"rate limit", types.NewRateLimitError("slow down"), "rate_limit_error"},
+		{"network", types.NewNetworkError("down"), "network_error"},
+		{"plain error", context.Canceled, utils.OutcomeError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Outcome(tt.err); got != tt.want {
+				t.Errorf("Outcome() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+// RequestStats tracks the outcome of API requests. A single RequestStats is
+// shared by every service of a bot so health checks see the bot as a whole.
+type RequestStats struct {
+	mu                sync.RWMutex
+	consecutiveErrors int
+	totalRequests     int64
+	totalErrors       int64
+	lastSuccess       time.Time
+	lastFailure       time.Time
+	lastError         string
+	rateLimit         types.RateLimitInfo
+	rateLimitedUntil  time.Time
+	inFlight          int
+}
+
+// RequestStatsSnapshot is a point-in-time copy of RequestStats
+type RequestStatsSnapshot struct {
+	ConsecutiveErrors int
+	TotalRequests     int64
+	TotalErrors       int64
+	LastSuccess       time.Time
+	LastFailure       time.Time
+	LastError         string
+	RateLimit         types.RateLimitInfo
+	RateLimitedUntil  time.Time
+	InFlight          int
+}
+
+// NewRequestStats creates a new request stats tracker
+func NewRequestStats() *RequestStats {
+	return &RequestStats{}
+}
+
+// Begin marks the start of an outgoing request
+func (s *RequestStats) Begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight++
+}
+
+// End marks the end of an outgoing request started with Begin
+func (s *RequestStats) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inFlight > 0 {
+		s.inFlight--
+	}
+}
+
+// RecordSuccess records a successful API call and resets the consecutive error count
+func (s *RequestStats) RecordSuccess() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totalRequests++
+	s.consecutiveErrors = 0
+	s.lastSuccess = time.Now()
+}
+
+// RecordFailure records a failed API call
+func (s *RequestStats) RecordFailure(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totalRequests++
+	s.totalErrors++
+	s.consecutiveErrors++
+	s.lastFailure = time.Now()
+	if err != nil {
+		s.lastError = err.Error()
+	}
+
+	if zaloBotErr, ok := err.(*types.ZaloBotError); ok && zaloBotErr.Type == types.ErrorTypeRateLimit {
+		// Without a Retry-After header assume the limit lifts after a short pause
+		if s.rateLimitedUntil.Before(s.lastFailure) {
+			s.rateLimitedUntil = s.lastFailure.Add(time.Second)
+		}
+	}
+}
+
+// RecordRateLimit records the rate limit headers of the latest response
+func (s *RequestStats) RecordRateLimit(info *types.RateLimitInfo) {
+	if info == nil || (info.Limit == 0 && info.RetryAfter == 0) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = *info
+	if info.RetryAfter > 0 {
+		s.rateLimitedUntil = time.Now().Add(info.RetryAfter)
+	}
+}
+
+// Snapshot returns a copy of the current stats
+func (s *RequestStats) Snapshot() RequestStatsSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return RequestStatsSnapshot{
+		ConsecutiveErrors: s.consecutiveErrors,
+		TotalRequests:     s.totalRequests,
+		TotalErrors:       s.totalErrors,
+		LastSuccess:       s.lastSuccess,
+		LastFailure:       s.lastFailure,
+		LastError:         s.lastError,
+		RateLimit:         s.rateLimit,
+		RateLimitedUntil:  s.rateLimitedUntil,
+		InFlight:          s.inFlight,
+	}
+}
+
+// IsRateLimited returns true if the API asked us to back off and the wait has not elapsed yet
+func (s RequestStatsSnapshot) IsRateLimited() bool {
+	return time.Now().Before(s.RateLimitedUntil)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/auth"
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+func TestRequestStats_SuccessResetsConsecutiveErrors(t *testing.T) {
+	stats := NewRequestStats()
+
+	stats.RecordFailure(fmt.Errorf("boom"))
+	stats.RecordFailure(types.NewNetworkError("timeout"))
+
+	snapshot := stats.Snapshot()
+	if snapshot.ConsecutiveErrors != 2 || snapshot.TotalErrors != 2 {
+		t.Errorf("ConsecutiveErrors/TotalErrors = %d/%d, want 2/2", snapshot.ConsecutiveErrors, snapshot.TotalErrors)
+	}
+	if snapshot.LastError == "" || snapshot.LastFailure.IsZero() {
+		t.Error("LastError/LastFailure not recorded")
+	}
+
+	stats.RecordSuccess()
+
+	snapshot = stats.Snapshot()
+	if snapshot.ConsecutiveErrors != 0 || snapshot.TotalErrors != 2 || snapshot.TotalRequests != 3 {
+		t.Errorf("snapshot = %+v, want consecutive errors reset", snapshot)
+	}
+	if snapshot.LastSuccess.IsZero() {
+		t.Error("LastSuccess not recorded")
+	}
+}
+
+func TestRequestStats_InFlight(t *testing.T) {
+	stats := NewRequestStats()
+
+	stats.Begin()
+	stats.Begin()
+	if got := stats.Snapshot().InFlight; got != 2 {
+		t.Errorf("InFlight = %d, want 2", got)
+	}
+
+	stats.End()
+	stats.End()
+	stats.End()
+	if got := stats.Snapshot().InFlight; got != 0 {
+		t.Errorf("InFlight = %d, want 0", got)
+	}
+}
+
+func TestRequestStats_RateLimit(t *testing.T) {
+	stats := NewRequestStats()
+
+	stats.RecordRateLimit(&types.RateLimitInfo{})
+	if stats.Snapshot().IsRateLimited() {
+		t.Error("IsRateLimited() = true for empty rate limit info")
+	}
+
+	stats.RecordRateLimit(&types.RateLimitInfo{Limit: 100, Remaining: 3, RetryAfter: time.Minute})
+	snapshot := stats.Snapshot()
+	if !snapshot.IsRateLimited() {
+		t.Error("IsRateLimited() = false after Retry-After")
+	}
+	if snapshot.RateLimit.Limit != 100 || snapshot.RateLimit.Remaining != 3 {
+		t.Errorf("RateLimit = %+v", snapshot.RateLimit)
+	}
+}
+
+func TestBaseService_SharedRequestStats(t *testing.T) {
+	service, _ := setupTestService(t, "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+
+	shared := NewRequestStats()
+	service.SetRequestStats(shared)
+	service.SetRequestStats(nil)
+
+	if service.GetRequestStats() != shared {
+		t.Error("GetRequestStats() did not return the shared stats")
+	}
+}
+
+func TestBaseService_DoRequest_RecordsOncePerCall(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	service, config := setupTestService(t, "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+	config.BaseURL = server.URL
+	config.RetryConfig = &types.RetryConfig{
+		MaxRetries:      3,
+		InitialDelay:    time.Millisecond,
+		MaxDelay:        time.Millisecond,
+		BackoffFactor:   1,
+		RetryableErrors: []types.ErrorType{types.ErrorTypeAPI, types.ErrorTypeNetwork},
+	}
+	authService, _ := auth.NewAuthService(config)
+	service.authService = authService
+	service.config = config
+
+	if _, err := service.DoRequest(context.Background(), &APIRequest{Method: "POST", APIMethod: "testMethod"}); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("server calls = %d, want 3", calls.Load())
+	}
+
+	snapshot := service.GetRequestStats().Snapshot()
+	if snapshot.TotalRequests != 1 || snapshot.TotalErrors != 0 || snapshot.ConsecutiveErrors != 0 {
+		t.Errorf("TotalRequests/TotalErrors/ConsecutiveErrors = %d/%d/%d, want 1/0/0",
+			snapshot.TotalRequests, snapshot.TotalErrors, snapshot.ConsecutiveErrors)
+	}
+}
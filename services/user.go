@@ -55,6 +55,26 @@ func (s *UserService) GetUserProfile(ctx context.Context, userID string) (*types
 	return &userProfile, nil
 }
 
+// GetMe retrieves the identity of the bot the token belongs to
+func (s *UserService) GetMe(ctx context.Context) (*types.BotInfo, error) {
+	apiReq := &APIRequest{
+		Method:    http.MethodGet,
+		APIMethod: "getMe",
+	}
+
+	resp, err := s.DoRequest(ctx, apiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var info types.BotInfo
+	if err := parseResult(resp.Result, &info); err != nil {
+		return nil, types.NewAPIError(0, "failed to parse bot info", err.Error())
+	}
+
+	return &info, nil
+}
+
 // validateUserID validates the user ID format
 func validateUserID(userID string) error {
 	if userID == "" {
@@ -267,3 +267,32 @@ func TestValidateUserID(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_GetMe(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot"+botToken+"/getMe" {
+			t.Errorf("Request path = %v", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(APIResponse{
+			OK:     true,
+			Result: json.RawMessage(`{"id": "bot1", "account_name": "bot.test", "account_type": "BASIC", "can_join_groups": true}`),
+		})
+	}))
+	defer server.Close()
+
+	service, config := setupTestUserService(t, botToken)
+	config.BaseURL = server.URL
+	authService, _ := auth.NewAuthService(config)
+	service.authService = authService
+	service.BaseService.authService = authService
+
+	info, err := service.GetMe(context.Background())
+	if err != nil {
+		t.Fatalf("GetMe() error = %v", err)
+	}
+	if info.ID != "bot1" || info.AccountName != "bot.test" || info.AccountType != "BASIC" || !info.CanJoinGroups {
+		t.Errorf("GetMe() = %+v", info)
+	}
+}
@@ -1,31 +1,35 @@
 package services
 
 import (
+	"fmt"
+	"regexp"
 	"testing"
+
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
+// semverPattern matches major.minor.patch with an optional pre-release
+var semverPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+
 func TestVersion(t *testing.T) {
 	version := Version()
-	if version == "" {
-		t.Error("Version() returned empty string")
+	if !semverPattern.MatchString(version) {
+		t.Errorf("Version() = %q, want a semantic version", version)
 	}
 
-	// Should match the version in version.yml
-	expectedVersion := "0.0.4"
-	if version != expectedVersion {
-		t.Errorf("Expected version %s, got %s", expectedVersion, version)
+	// Should match the components from the embedded version.yml
+	expected := fmt.Sprintf("%d.%d.%d", VersionMajor(), VersionMinor(), VersionPatch())
+	if pre := VersionPreRelease(); pre != "" {
+		expected += "-" + pre
+	}
+	if version != expected {
+		t.Errorf("Expected version %s, got %s", expected, version)
 	}
 }
 
 func TestUserAgent(t *testing.T) {
-	userAgent := UserAgent()
-	if userAgent == "" {
-		t.Error("UserAgent() returned empty string")
-	}
-
-	// Should match the user_agent in version.yml
-	expectedUserAgent := "Go-Zalo-Bot-SDK/0.0.4"
-	if userAgent != expectedUserAgent {
+	expectedUserAgent := "Go-Zalo-Bot-SDK/" + Version()
+	if userAgent := UserAgent(); userAgent != expectedUserAgent {
 		t.Errorf("Expected user agent %s, got %s", expectedUserAgent, userAgent)
 	}
 }
@@ -43,14 +47,15 @@ func TestSDKName(t *testing.T) {
 }
 
 func TestVersionComponents(t *testing.T) {
+	info := utils.GetVersionInfo()
 	tests := []struct {
 		name     string
 		function func() int
 		expected int
 	}{
-		{"Major", VersionMajor, 0},
-		{"Minor", VersionMinor, 0},
-		{"Patch", VersionPatch, 4},
+		{"Major", VersionMajor, info.Version.Major},
+		{"Minor", VersionMinor, info.Version.Minor},
+		{"Patch", VersionPatch, info.Version.Patch},
 	}
 
 	for _, tt := range tests {
@@ -59,6 +64,9 @@ func TestVersionComponents(t *testing.T) {
 			if got != tt.expected {
 				t.Errorf("Version%s() = %d, want %d", tt.name, got, tt.expected)
 			}
+			if got < 0 {
+				t.Errorf("Version%s() = %d, want non-negative", tt.name, got)
+			}
 		})
 	}
 }
@@ -73,13 +81,8 @@ func TestVersionPreRelease(t *testing.T) {
 
 func TestReleaseDate(t *testing.T) {
 	date := ReleaseDate()
-	if date == "" {
-		t.Error("ReleaseDate() returned empty string")
-	}
-
-	expectedDate := "2025-11-18"
-	if date != expectedDate {
-		t.Errorf("Expected release date %s, got %s", expectedDate, date)
+	if !regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`).MatchString(date) {
+		t.Errorf("ReleaseDate() = %q, want YYYY-MM-DD", date)
 	}
 }
 
@@ -108,6 +111,8 @@ func TestVersionDetails(t *testing.T) {
 		"user_agent",
 		"release_date",
 		"release_branch",
+		"module_version",
+		"go_version",
 	}
 
 	for _, field := range requiredFields {
@@ -117,47 +122,29 @@ func TestVersionDetails(t *testing.T) {
 	}
 
 	// Verify some specific values
-	if details["version"] != "0.0.4" {
-		t.Errorf("Expected version 0.0.4, got %v", details["version"])
+	if details["version"] != Version() {
+		t.Errorf("Expected version %s, got %v", Version(), details["version"])
 	}
 
 	if details["sdk_name"] != "Go-Zalo-Bot-SDK" {
 		t.Errorf("Expected SDK name Go-Zalo-Bot-SDK, got %v", details["sdk_name"])
 	}
 
-	if details["user_agent"] != "Go-Zalo-Bot-SDK/0.0.4" {
-		t.Errorf("Expected user agent Go-Zalo-Bot-SDK/0.0.4, got %v", details["user_agent"])
+	if details["user_agent"] != UserAgent() {
+		t.Errorf("Expected user agent %s, got %v", UserAgent(), details["user_agent"])
 	}
 }
 
 func TestReloadVersion(t *testing.T) {
-	// This test verifies that ReloadVersion doesn't panic
-	// and returns without error when version.yml is valid
-	err := ReloadVersion()
-	if err != nil {
+	before := Version()
+
+	// The embedded version.yml is always valid
+	if err := ReloadVersion(); err != nil {
 		t.Errorf("ReloadVersion() returned error: %v", err)
 	}
 
 	// Verify version is still correct after reload
-	if Version() != "0.0.4" {
-		t.Errorf("Version mismatch after reload: got %s, want 0.0.4", Version())
-	}
-}
-
-func TestVersionFallback(t *testing.T) {
-	// This test verifies that the fallback mechanism works
-	// We can't easily test this without moving version.yml,
-	// but we can verify the fallback constants are defined
-	if fallbackSDKName == "" {
-		t.Error("fallbackSDKName should not be empty")
-	}
-
-	if fallbackVersion == "" {
-		t.Error("fallbackVersion should not be empty")
-	}
-
-	// Verify fallback values are reasonable
-	if fallbackMajor < 0 || fallbackMinor < 0 || fallbackPatch < 0 {
-		t.Error("Fallback version components should not be negative")
+	if Version() != before {
+		t.Errorf("Version mismatch after reload: got %s, want %s", Version(), before)
 	}
 }
@@ -1,16 +1,28 @@
 package services
 
 import (
+	"crypto/subtle"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/vkhangstack/go-zalo-bot/types"
 	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
+// DefaultSecretOverlap is how long the previous webhook secret token stays
+// valid after a rotation unless another period is given
+const DefaultSecretOverlap = 10 * time.Minute
+
 // WebhookService handles webhook-related operations
 type WebhookService struct {
 	*BaseService
-	secretToken string
+
+	mu            sync.RWMutex
+	secretToken   string
+	previous      string    // Secret token replaced by the last rotation
+	previousUntil time.Time // End of the overlap window of previous
+	now           func() time.Time
 }
 
 // NewWebhookService creates a new webhook service
@@ -18,32 +30,112 @@ func NewWebhookService(base *BaseService, secretToken string) *WebhookService {
 	return &WebhookService{
 		BaseService: base,
 		secretToken: secretToken,
+		now:         time.Now,
 	}
 }
 
-// SetSecretToken sets the webhook secret token
+// SetSecretToken sets the webhook secret token, dropping any previous token
+// still in its overlap window. Setting the current token again is a no-op.
 func (s *WebhookService) SetSecretToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token == s.secretToken {
+		return
+	}
+	s.secretToken = token
+	s.previous = ""
+	s.previousUntil = time.Time{}
+}
+
+// RotateSecretToken makes token the current secret token while the replaced
+// one stays valid for overlap (DefaultSecretOverlap if overlap <= 0), so
+// deliveries Zalo signed before it switched secrets are still accepted
+func (s *WebhookService) RotateSecretToken(token string, overlap time.Duration) {
+	if overlap <= 0 {
+		overlap = DefaultSecretOverlap
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token == s.secretToken {
+		return
+	}
+	s.previous = s.secretToken
+	s.previousUntil = s.currentTime().Add(overlap)
 	s.secretToken = token
 }
 
+// RetirePreviousSecretToken stops accepting the secret token replaced by the
+// last rotation before its overlap window ends
+func (s *WebhookService) RetirePreviousSecretToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.previous = ""
+	s.previousUntil = time.Time{}
+}
+
+// PreviousSecretTokenExpiry returns when the previous secret token stops being
+// accepted, or the zero time if there is none
+func (s *WebhookService) PreviousSecretTokenExpiry() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.previous == "" || !s.currentTime().Before(s.previousUntil) {
+		return time.Time{}
+	}
+	return s.previousUntil
+}
+
+// currentTime returns the current time from the service's clock
+func (s *WebhookService) currentTime() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
 // GetSecretToken returns the webhook secret token
 func (s *WebhookService) GetSecretToken() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.secretToken
 }
 
 // ValidateSecretToken compares an incoming X-Bot-Api-Secret-Token header value
 // against the configured secret token, as instructed by the Zalo webhook docs:
 // https://bot.zapps.me/docs/webhook/
+//
+// The comparison takes constant time, and during a rotation the previous
+// secret token is accepted until its overlap window ends.
 func (s *WebhookService) ValidateSecretToken(token string) error {
-	if s.secretToken == "" {
+	s.mu.RLock()
+	current := s.secretToken
+	previous := s.previous
+	previousValid := previous != "" && s.currentTime().Before(s.previousUntil)
+	s.mu.RUnlock()
+
+	if current == "" {
 		return fmt.Errorf("webhook secret token is not configured")
 	}
-	if token != s.secretToken {
+
+	// Compare against both secrets so timing does not reveal which matched
+	match := secretTokenEqual(token, current)
+	if previousValid && secretTokenEqual(token, previous) {
+		match = true
+	}
+	if !match {
 		return fmt.Errorf("invalid secret token")
 	}
 	return nil
 }
 
+// secretTokenEqual compares two secret tokens in constant time
+func secretTokenEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
 // RejectInvalidRequest creates an error for rejecting invalid webhook requests
 func (s *WebhookService) RejectInvalidRequest(reason string) error {
 	return utils.RejectInvalidWebhookRequest(reason)
@@ -53,23 +145,7 @@ func (s *WebhookService) RejectInvalidRequest(reason string) error {
 // envelope Zalo sends per https://bot.zapps.me/docs/webhook/:
 // {"ok":true,"result":{"event_name":...,"message":{...}}}.
 func (s *WebhookService) ParseUpdate(payload []byte) (*types.Update, error) {
-	if len(payload) == 0 {
-		return nil, fmt.Errorf("empty webhook payload")
-	}
-
-	webhookPayload, err := types.ParseWebhookPayload(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
-	}
-
-	if webhookPayload.Result.EventName == "" {
-		return nil, fmt.Errorf("webhook payload result is empty or missing event_name")
-	}
-
-	return &types.Update{
-		EventName: webhookPayload.Result.EventName,
-		Message:   webhookPayload.Result.Message,
-	}, nil
+	return types.ParseWebhookUpdate(payload)
 }
 
 // ProcessWebhook validates the request's secret token and parses its payload
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/vkhangstack/go-zalo-bot/types"
 )
@@ -59,6 +62,78 @@ func TestWebhookService_ValidateSecretToken(t *testing.T) {
 	}
 }
 
+func TestWebhookService_SecretToken_Concurrent(t *testing.T) {
+	service := NewWebhookService(nil, "token-0")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			service.SetSecretToken(fmt.Sprintf("token-%d", i))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			service.ValidateSecretToken(service.GetSecretToken())
+		}
+	}()
+	wg.Wait()
+
+	if err := service.ValidateSecretToken("token-199"); err != nil {
+		t.Errorf("ValidateSecretToken() error = %v", err)
+	}
+}
+
+func TestWebhookService_RotateSecretToken(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := NewWebhookService(nil, "old-secret")
+	service.now = func() time.Time { return now }
+
+	service.RotateSecretToken("new-secret", time.Minute)
+
+	if got := service.GetSecretToken(); got != "new-secret" {
+		t.Errorf("GetSecretToken() = %v, want new-secret", got)
+	}
+	for _, token := range []string{"new-secret", "old-secret"} {
+		if err := service.ValidateSecretToken(token); err != nil {
+			t.Errorf("ValidateSecretToken(%q) during overlap error = %v", token, err)
+		}
+	}
+	if got := service.PreviousSecretTokenExpiry(); !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("PreviousSecretTokenExpiry() = %v, want %v", got, now.Add(time.Minute))
+	}
+
+	// Setting the current token again keeps the overlap window
+	service.SetSecretToken("new-secret")
+	if err := service.ValidateSecretToken("old-secret"); err != nil {
+		t.Errorf("ValidateSecretToken(old) after SetSecretToken(current) error = %v", err)
+	}
+
+	// The previous token is rejected once the window ends
+	now = now.Add(time.Minute)
+	if err := service.ValidateSecretToken("old-secret"); err == nil {
+		t.Error("ValidateSecretToken(old) after overlap expected error")
+	}
+	if !service.PreviousSecretTokenExpiry().IsZero() {
+		t.Error("PreviousSecretTokenExpiry() after overlap should be zero")
+	}
+	if err := service.ValidateSecretToken("new-secret"); err != nil {
+		t.Errorf("ValidateSecretToken(new) error = %v", err)
+	}
+
+	// Retiring ends the window early
+	service.RotateSecretToken("newer-secret", 0)
+	if err := service.ValidateSecretToken("new-secret"); err != nil {
+		t.Errorf("ValidateSecretToken(previous) with default overlap error = %v", err)
+	}
+	service.RetirePreviousSecretToken()
+	if err := service.ValidateSecretToken("new-secret"); err == nil {
+		t.Error("ValidateSecretToken(previous) after retire expected error")
+	}
+}
+
 func TestWebhookService_ParseUpdate(t *testing.T) {
 	service := &WebhookService{}
 
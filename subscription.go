@@ -0,0 +1,383 @@
+package zalobot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// droppedSpillError is the drop reason reported when an update could not be
+// written to or read from a spill queue
+const droppedSpillError = "spill_error"
+
+// UpdateSubscription is one consumer of polled updates. Every subscription
+// receives every update through its own buffer and overflow policy, so a
+// slow consumer only holds up the others under types.OverflowBlock.
+type UpdateSubscription struct {
+	bot    *BotAPI
+	config types.BufferConfig
+	ch     chan types.Update
+	done   chan struct{}
+
+	// Spill queue, nil unless the policy is types.OverflowSpill
+	spill *utils.DiskQueue
+	wake  chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	spilled int // updates in the spill queue, including one being delivered
+	dropped atomic.Int64
+	active  sync.WaitGroup // running deliveries and the spill drainer
+}
+
+// SubscribeUpdates adds a consumer of polled updates with its own buffer.
+// Polling is started by GetUpdatesChan; every subscription then receives
+// every update, and all subscription channels are closed when polling stops.
+func (b *BotAPI) SubscribeUpdates(config types.BufferConfig) (*UpdateSubscription, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	b.pollingMu.Lock()
+	defer b.pollingMu.Unlock()
+	return b.subscribeLocked(config)
+}
+
+// subscribeLocked adds a subscription; pollingMu must be held
+func (b *BotAPI) subscribeLocked(config types.BufferConfig) (*UpdateSubscription, error) {
+	taken := func(name string) bool {
+		for _, sub := range b.subscriptions {
+			if sub.config.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if config.Name == "" {
+		for i := len(b.subscriptions) + 1; config.Name == "" || taken(config.Name); i++ {
+			config.Name = fmt.Sprintf("subscriber-%d", i)
+		}
+	} else if taken(config.Name) {
+		return nil, types.NewValidationError(fmt.Sprintf("update subscriber %q already exists", config.Name))
+	}
+
+	sub := &UpdateSubscription{
+		bot:    b,
+		config: config,
+		ch:     make(chan types.Update, config.Size),
+		done:   make(chan struct{}),
+	}
+
+	if config.Overflow == types.OverflowSpill {
+		queue, err := utils.NewDiskQueue(filepath.Join(config.SpillDir, url.PathEscape(config.Name)+".queue"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open spill queue: %w", err)
+		}
+		sub.spill = queue
+		sub.spilled = queue.Len()
+		sub.wake = make(chan struct{}, 1)
+
+		sub.active.Add(1)
+		go sub.drainSpill()
+		if sub.spilled > 0 {
+			sub.signal()
+		}
+	}
+
+	b.subscriptions = append(b.subscriptions, sub)
+	return sub, nil
+}
+
+// C returns the channel updates are delivered on
+func (s *UpdateSubscription) C() <-chan types.Update {
+	return s.ch
+}
+
+// Name returns the subscription name used in metrics
+func (s *UpdateSubscription) Name() string {
+	return s.config.Name
+}
+
+// Depth returns the number of updates waiting for the consumer, including
+// spilled updates
+func (s *UpdateSubscription) Depth() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.ch) + s.spilled
+}
+
+// Dropped returns the number of updates dropped because the buffer was full
+func (s *UpdateSubscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops delivering updates to the subscription and closes its
+// channel. Spilled updates stay on disk for the next subscription with the
+// same name and directory.
+func (s *UpdateSubscription) Close() error {
+	s.bot.removeSubscription(s)
+	return s.close()
+}
+
+// close stops delivery, waits for running deliveries and closes the channel
+func (s *UpdateSubscription) close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	s.mu.Unlock()
+
+	s.active.Wait()
+	close(s.ch)
+	s.bot.reportQueueDepth(s)
+
+	if s.spill != nil {
+		return s.spill.Close()
+	}
+	return nil
+}
+
+// deliver passes update to the subscription according to its overflow
+// policy. It returns false if ctx was cancelled while blocking.
+func (s *UpdateSubscription) deliver(ctx context.Context, update types.Update) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return true
+	}
+	s.active.Add(1)
+	defer s.active.Done()
+
+	if s.spill != nil {
+		defer s.mu.Unlock()
+		// Once anything is spilled, later updates queue behind it
+		if s.spilled == 0 {
+			select {
+			case s.ch <- update:
+				return true
+			default:
+			}
+		}
+		s.spillUpdate(update)
+		return true
+	}
+	s.mu.Unlock()
+
+	switch s.config.Overflow {
+	case types.OverflowDropNewest:
+		select {
+		case s.ch <- update:
+		default:
+			s.drop(string(types.OverflowDropNewest))
+		}
+
+	case types.OverflowDropOldest:
+		for {
+			select {
+			case s.ch <- update:
+				return true
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.drop(string(types.OverflowDropOldest))
+			default:
+			}
+		}
+
+	default:
+		select {
+		case s.ch <- update:
+		case <-s.done:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// spillUpdate writes update to the spill queue; mu must be held
+func (s *UpdateSubscription) spillUpdate(update types.Update) {
+	data, err := json.Marshal(update)
+	if err == nil {
+		err = s.spill.Push(data)
+	}
+	if err != nil {
+		s.bot.logger().Error("Failed to spill update",
+			utils.Field{Key: "subscriber", Value: s.config.Name},
+			utils.Field{Key: "error", Value: err},
+		)
+		s.drop(droppedSpillError)
+		return
+	}
+
+	s.spilled++
+	s.signal()
+}
+
+// signal wakes the spill drainer
+func (s *UpdateSubscription) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// drainSpill moves spilled updates to the channel in order. An update is
+// removed from the queue only once the channel accepted it.
+func (s *UpdateSubscription) drainSpill() {
+	defer s.active.Done()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		}
+
+		for {
+			data, ok, err := s.spill.Peek()
+			if err != nil {
+				s.bot.logger().Error("Failed to read spilled update",
+					utils.Field{Key: "subscriber", Value: s.config.Name},
+					utils.Field{Key: "error", Value: err},
+				)
+				break
+			}
+			if !ok {
+				break
+			}
+
+			var update types.Update
+			if err := json.Unmarshal(data, &update); err != nil {
+				s.commitSpilled()
+				s.drop(droppedSpillError)
+				continue
+			}
+
+			select {
+			case s.ch <- update:
+				s.commitSpilled()
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// commitSpilled removes the first update from the spill queue
+func (s *UpdateSubscription) commitSpilled() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, _, err := s.spill.Pop(); err != nil {
+		s.bot.logger().Error("Failed to remove spilled update",
+			utils.Field{Key: "subscriber", Value: s.config.Name},
+			utils.Field{Key: "error", Value: err},
+		)
+	}
+	s.spilled--
+}
+
+// drop counts an update the consumer did not receive
+func (s *UpdateSubscription) drop(reason string) {
+	s.dropped.Add(1)
+	if metrics, ok := s.bot.metrics().(utils.QueueMetrics); ok {
+		metrics.IncDroppedUpdate(s.config.Name, reason)
+	}
+	s.bot.logger().Debug("Dropped update",
+		utils.Field{Key: "subscriber", Value: s.config.Name},
+		utils.Field{Key: "reason", Value: reason},
+	)
+}
+
+// fanOut delivers update to every subscription. It returns false if ctx was
+// cancelled while a subscription was blocking.
+func (b *BotAPI) fanOut(ctx context.Context, update types.Update) bool {
+	b.pollingMu.RLock()
+	subs := append([]*UpdateSubscription(nil), b.subscriptions...)
+	b.pollingMu.RUnlock()
+
+	for _, sub := range subs {
+		if !sub.deliver(ctx, update) {
+			return false
+		}
+	}
+	return true
+}
+
+// removeSubscription forgets sub so it receives no more updates
+func (b *BotAPI) removeSubscription(sub *UpdateSubscription) {
+	b.pollingMu.Lock()
+	defer b.pollingMu.Unlock()
+
+	for i, s := range b.subscriptions {
+		if s == sub {
+			b.subscriptions = append(b.subscriptions[:i:i], b.subscriptions[i+1:]...)
+			break
+		}
+	}
+	if b.primary == sub {
+		b.primary = nil
+	}
+}
+
+// stopUpdates marks polling as stopped and closes every subscription
+func (b *BotAPI) stopUpdates() {
+	b.pollingMu.Lock()
+	subs := b.subscriptions
+	b.subscriptions = nil
+	b.primary = nil
+	b.isPolling = false
+	b.pollingMu.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// updatesBacklog returns the updates waiting across all subscriptions
+func (b *BotAPI) updatesBacklog() int {
+	b.pollingMu.RLock()
+	subs := append([]*UpdateSubscription(nil), b.subscriptions...)
+	b.pollingMu.RUnlock()
+
+	backlog := 0
+	for _, sub := range subs {
+		backlog += sub.Depth()
+	}
+	return backlog
+}
+
+// reportQueueDepth reports the depth of sub to metrics
+func (b *BotAPI) reportQueueDepth(sub *UpdateSubscription) {
+	if metrics, ok := b.metrics().(utils.QueueMetrics); ok {
+		metrics.SetUpdateQueueDepth(sub.config.Name, sub.Depth())
+	}
+}
+
+// reportQueueDepths reports the depth of every subscription to metrics
+func (b *BotAPI) reportQueueDepths() {
+	if _, ok := b.metrics().(utils.QueueMetrics); !ok {
+		return
+	}
+
+	b.pollingMu.RLock()
+	subs := append([]*UpdateSubscription(nil), b.subscriptions...)
+	b.pollingMu.RUnlock()
+
+	for _, sub := range subs {
+		b.reportQueueDepth(sub)
+	}
+}
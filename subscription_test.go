@@ -0,0 +1,226 @@
+package zalobot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// newUpdatesServer serves getUpdates with updates 1 to count, honouring the
+// offset, and records the highest offset requested
+func newUpdatesServer(count int, offset *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if int64(from) > offset.Load() {
+			offset.Store(int64(from))
+		}
+		if from == 0 {
+			from = 1
+		}
+
+		var updates []string
+		for id := from; id <= count; id++ {
+			updates = append(updates, fmt.Sprintf(`{"update_id":%d,"message":{"message_id":"m%d","text":"update %d"}}`, id, id, id))
+		}
+		w.Write([]byte(`{"ok":true,"result":[` + strings.Join(updates, ",") + `]}`))
+	}))
+}
+
+// receiveIDs reads update IDs from ch until it is closed
+func receiveIDs(t *testing.T, ch <-chan types.Update) []int {
+	t.Helper()
+	var ids []int
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case update, ok := <-ch:
+			if !ok {
+				return ids
+			}
+			ids = append(ids, update.UpdateID)
+		case <-timeout:
+			t.Fatalf("channel not closed, received %v", ids)
+		}
+	}
+}
+
+// waitForOffset waits until polling acknowledged every update up to count
+func waitForOffset(t *testing.T, offset *atomic.Int64, count int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for offset.Load() <= int64(count) {
+		if time.Now().After(deadline) {
+			t.Fatalf("offset = %d, want %d", offset.Load(), count+1)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBotAPI_SubscribeUpdates_FanOut(t *testing.T) {
+	var offset atomic.Int64
+	server := newUpdatesServer(3, &offset)
+	defer server.Close()
+
+	metrics := utils.NewPrometheusMetrics("")
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL), types.WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	audit, err := bot.SubscribeUpdates(types.BufferConfig{Name: "audit", Size: 10})
+	if err != nil {
+		t.Fatalf("SubscribeUpdates() error = %v", err)
+	}
+	if _, err := bot.SubscribeUpdates(types.BufferConfig{Name: "audit"}); err == nil {
+		t.Error("SubscribeUpdates() with a duplicate name error = nil")
+	}
+
+	updates := bot.GetUpdatesChan(types.UpdateConfig{Buffer: types.BufferConfig{Size: 10}})
+	waitForOffset(t, &offset, 3)
+
+	if backlog := bot.Health(context.Background()).UpdatesBacklog; backlog != 6 {
+		t.Errorf("UpdatesBacklog = %d, want 6", backlog)
+	}
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	if !strings.Contains(b.String(), `zalobot_update_queue_depth{subscriber="audit"} 3`) {
+		t.Errorf("metrics missing audit queue depth:\n%s", b.String())
+	}
+
+	bot.StopPolling()
+	for name, ch := range map[string]<-chan types.Update{"default": updates, "audit": audit.C()} {
+		if ids := receiveIDs(t, ch); fmt.Sprint(ids) != "[1 2 3]" {
+			t.Errorf("%s received %v, want [1 2 3]", name, ids)
+		}
+	}
+}
+
+func TestBotAPI_SubscribeUpdates_Overflow(t *testing.T) {
+	tests := []struct {
+		policy      types.OverflowPolicy
+		want        string
+		wantDropped int64
+	}{
+		{policy: types.OverflowDropNewest, want: "[1]", wantDropped: 2},
+		{policy: types.OverflowDropOldest, want: "[3]", wantDropped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			var offset atomic.Int64
+			server := newUpdatesServer(3, &offset)
+			defer server.Close()
+
+			bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer bot.Close()
+
+			sub, err := bot.SubscribeUpdates(types.BufferConfig{Size: 1, Overflow: tt.policy})
+			if err != nil {
+				t.Fatalf("SubscribeUpdates() error = %v", err)
+			}
+			if sub.Name() != "subscriber-1" {
+				t.Errorf("Name() = %q, want subscriber-1", sub.Name())
+			}
+
+			// The primary consumer keeps up, so polling is not held back
+			bot.GetUpdatesChan(types.UpdateConfig{})
+			waitForOffset(t, &offset, 3)
+			bot.StopPolling()
+
+			if ids := receiveIDs(t, sub.C()); fmt.Sprint(ids) != tt.want {
+				t.Errorf("received %v, want %s", ids, tt.want)
+			}
+			if sub.Dropped() != tt.wantDropped {
+				t.Errorf("Dropped() = %d, want %d", sub.Dropped(), tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestBotAPI_SubscribeUpdates_Spill(t *testing.T) {
+	var offset atomic.Int64
+	server := newUpdatesServer(5, &offset)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	buffer := types.BufferConfig{Size: 1, Overflow: types.OverflowSpill, SpillDir: t.TempDir()}
+	updates := bot.GetUpdatesChan(types.UpdateConfig{Buffer: buffer})
+
+	// A slow consumer does not stop polling
+	waitForOffset(t, &offset, 5)
+	if backlog := bot.Health(context.Background()).UpdatesBacklog; backlog != 5 {
+		t.Errorf("UpdatesBacklog = %d, want 5", backlog)
+	}
+
+	first := <-updates
+	second := <-updates
+	if first.UpdateID != 1 || second.UpdateID != 2 {
+		t.Fatalf("received %d, %d, want 1, 2", first.UpdateID, second.UpdateID)
+	}
+
+	// Updates still spilled when polling stops are delivered on the next start
+	bot.StopPolling()
+	remaining := receiveIDs(t, updates)
+
+	restarted := bot.GetUpdatesChan(types.UpdateConfig{Offset: 6, Buffer: buffer})
+	for len(remaining) < 3 {
+		select {
+		case update := <-restarted:
+			remaining = append(remaining, update.UpdateID)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("spilled updates not restored, received %v", remaining)
+		}
+	}
+	bot.StopPolling()
+
+	if fmt.Sprint(remaining) != "[3 4 5]" {
+		t.Errorf("remaining updates = %v, want [3 4 5]", remaining)
+	}
+}
+
+func TestBotAPI_StopPolling_RightAfterStart(t *testing.T) {
+	var offset atomic.Int64
+	server := newUpdatesServer(0, &offset)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	// StopPolling may run before the polling goroutine has started
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			updates := bot.GetUpdatesChan(types.UpdateConfig{})
+			bot.StopPolling()
+			receiveIDs(t, updates)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("StopPolling did not return")
+	}
+}
@@ -0,0 +1,462 @@
+package zalobot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// ErrTemplateNotFound is returned when neither the requested locale nor the
+// default locale has a template
+var ErrTemplateNotFound = errors.New("template not found")
+
+// LocaleKey is the Context key holding the locale TemplateRegistry.Reply
+// renders in. Middleware can set it from a session or user profile.
+const LocaleKey = "locale"
+
+// DefaultLocale is the default locale of a TemplateRegistry
+const DefaultLocale = "vi"
+
+// TemplateConfig represents configuration for a TemplateRegistry
+type TemplateConfig struct {
+	// DefaultLocale is used when a locale or a template in it is missing
+	// (default: DefaultLocale)
+	DefaultLocale string
+	// Funcs are added to the template functions, after TemplateFuncs
+	Funcs template.FuncMap
+}
+
+// TemplateRegistry holds message templates by locale. Templates use
+// text/template syntax; templates of one locale can include each other with
+// {{template "name" .}}. A missing map key is an error rather than "<no
+// value>". It is safe for concurrent use.
+type TemplateRegistry struct {
+	config TemplateConfig
+	funcs  template.FuncMap
+
+	mu      sync.RWMutex
+	sources map[string]map[string]string // locale, then name
+	sets    map[string]*template.Template
+}
+
+// NewTemplateRegistry creates an empty template registry
+func NewTemplateRegistry(config TemplateConfig) *TemplateRegistry {
+	if config.DefaultLocale == "" {
+		config.DefaultLocale = DefaultLocale
+	}
+	config.DefaultLocale = strings.ToLower(config.DefaultLocale)
+
+	funcs := TemplateFuncs()
+	for name, fn := range config.Funcs {
+		funcs[name] = fn
+	}
+
+	return &TemplateRegistry{
+		config:  config,
+		funcs:   funcs,
+		sources: make(map[string]map[string]string),
+		sets:    make(map[string]*template.Template),
+	}
+}
+
+// TemplateFuncs returns the built-in template functions:
+//
+//	vnd       amount in đồng                {{vnd .Total}}        1.500.000 ₫
+//	number    number with optional decimals {{number .Rating 1}}  4,5
+//	date      date                          {{date .Due}}         02/09/2024
+//	datetime  time and date                 {{datetime .At}}      08:05 02/09/2024
+//	longdate  spelled-out date              {{longdate .Due}}     Thứ Hai, ngày 2 tháng 9 năm 2024
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"vnd": func(amount interface{}) (string, error) {
+			n, err := templateNumber(amount)
+			if err != nil {
+				return "", err
+			}
+			return utils.FormatVND(int64(math.Round(n))), nil
+		},
+		"number": func(value interface{}, decimals ...int) (string, error) {
+			n, err := templateNumber(value)
+			if err != nil {
+				return "", err
+			}
+			places := 0
+			if len(decimals) > 0 {
+				places = decimals[0]
+			}
+			return utils.FormatNumberVI(n, places), nil
+		},
+		"date":     utils.FormatDateVI,
+		"datetime": utils.FormatDateTimeVI,
+		"longdate": utils.FormatDateLongVI,
+	}
+}
+
+// templateNumber converts a numeric template argument to float64
+func templateNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", value)
+	}
+}
+
+// Add parses a template and adds it to locale, replacing any template of
+// the same name
+func (r *TemplateRegistry) Add(locale, name, source string) error {
+	return r.update(map[string]map[string]string{
+		strings.ToLower(locale): {name: source},
+	})
+}
+
+// LoadFS loads templates from fsys. A file "<locale>/<name>.tmpl" holds one
+// template, and a file "<locale>.yaml" or "<locale>.yml" holds a bundle
+// mapping template names to sources. Loaded templates are added to those
+// already registered; nothing is changed if any template fails to parse.
+func (r *TemplateRegistry) LoadFS(fsys fs.FS) error {
+	loaded := make(map[string]map[string]string)
+	add := func(locale, name, source string) {
+		locale = strings.ToLower(locale)
+		if loaded[locale] == nil {
+			loaded[locale] = make(map[string]string)
+		}
+		loaded[locale][name] = source
+	}
+
+	err := fs.WalkDir(fsys, ".", func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		switch ext := path.Ext(file); ext {
+		case ".tmpl":
+			dir := path.Dir(file)
+			if dir == "." {
+				return fmt.Errorf("template %s is not in a locale directory", file)
+			}
+			data, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return err
+			}
+			add(path.Base(dir), strings.TrimSuffix(path.Base(file), ext), string(data))
+
+		case ".yaml", ".yml":
+			data, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return err
+			}
+			var bundle map[string]string
+			if err := yaml.Unmarshal(data, &bundle); err != nil {
+				return fmt.Errorf("template bundle %s: %w", file, err)
+			}
+			locale := strings.TrimSuffix(path.Base(file), ext)
+			for name, source := range bundle {
+				add(locale, name, source)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.update(loaded)
+}
+
+// LoadDir loads templates from a directory, as LoadFS
+func (r *TemplateRegistry) LoadDir(dir string) error {
+	return r.LoadFS(os.DirFS(dir))
+}
+
+// update merges templates into the registry, reparsing changed locales
+func (r *TemplateRegistry) update(changes map[string]map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sets := make(map[string]*template.Template, len(changes))
+	merged := make(map[string]map[string]string, len(changes))
+	for locale, templates := range changes {
+		sources := make(map[string]string, len(r.sources[locale])+len(templates))
+		for name, source := range r.sources[locale] {
+			sources[name] = source
+		}
+		for name, source := range templates {
+			sources[name] = source
+		}
+
+		set, err := r.parse(locale, sources)
+		if err != nil {
+			return err
+		}
+		sets[locale] = set
+		merged[locale] = sources
+	}
+
+	for locale, set := range sets {
+		r.sets[locale] = set
+		r.sources[locale] = merged[locale]
+	}
+	return nil
+}
+
+// parse parses the templates of one locale into a set
+func (r *TemplateRegistry) parse(locale string, sources map[string]string) (*template.Template, error) {
+	set := template.New(locale).Funcs(r.funcs).Option("missingkey=error")
+	for _, name := range sortedNames(sources) {
+		if _, err := set.New(name).Parse(sources[name]); err != nil {
+			return nil, fmt.Errorf("template %s/%s: %w", locale, name, err)
+		}
+	}
+	return set, nil
+}
+
+// sortedNames returns the keys of m in order
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Locales returns the loaded locales in order
+func (r *TemplateRegistry) Locales() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	locales := make([]string, 0, len(r.sources))
+	for locale := range r.sources {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Check reports templates missing from a locale that another locale has,
+// and a default locale without templates. Run it at startup so untranslated
+// copy is found before a customer sees the fallback.
+func (r *TemplateRegistry) Check() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var problems []string
+	if len(r.sources[r.config.DefaultLocale]) == 0 {
+		problems = append(problems, fmt.Sprintf("default locale %q has no templates", r.config.DefaultLocale))
+	}
+
+	all := make(map[string]string)
+	for _, templates := range r.sources {
+		for name := range templates {
+			all[name] = ""
+		}
+	}
+
+	locales := make([]string, 0, len(r.sources))
+	for locale := range r.sources {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	for _, locale := range locales {
+		var missing []string
+		for _, name := range sortedNames(all) {
+			if _, ok := r.sources[locale][name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("locale %s is missing %s", locale, strings.Join(missing, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("templates: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// CheckData renders template name in every locale that has it with sample
+// data, reporting keys the templates use that sample does not provide
+func (r *TemplateRegistry) CheckData(name string, sample interface{}) error {
+	var problems []string
+	for _, locale := range r.Locales() {
+		r.mu.RLock()
+		t := r.sets[locale].Lookup(name)
+		r.mu.RUnlock()
+		if t == nil {
+			continue
+		}
+		if err := t.Execute(io.Discard, sample); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", locale, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("template %s: %s", name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// lookup finds a template in locale, its base language ("vi" for "vi-VN"),
+// or the default locale
+func (r *TemplateRegistry) lookup(locale, name string) (*template.Template, error) {
+	locale = strings.ToLower(locale)
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, r.config.DefaultLocale)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, candidate := range candidates {
+		if set := r.sets[candidate]; set != nil {
+			if t := set.Lookup(name); t != nil {
+				return t, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+}
+
+// execute renders a template to a string
+func (r *TemplateRegistry) execute(locale, name string, data interface{}) (string, error) {
+	t, err := r.lookup(locale, name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Render renders a text template. The output is tidied with
+// utils.FormatMessage and must pass utils.ValidateMessageContent.
+func (r *TemplateRegistry) Render(locale, name string, data interface{}) (string, error) {
+	output, err := r.execute(locale, name, data)
+	if err != nil {
+		return "", err
+	}
+
+	text := utils.FormatMessage(output)
+	if err := utils.ValidateMessageContent(text); err != nil {
+		return "", fmt.Errorf("template %s: %w", name, err)
+	}
+	return text, nil
+}
+
+// RenderMessage renders a template whose output is a StructuredMessage in
+// YAML or JSON, using the field names of the API:
+//
+//	type: template
+//	elements:
+//	  - title: "Đơn hàng {{.ID}}"
+//	    subtitle: "Tổng cộng {{vnd .Total}}"
+//	quick_replies:
+//	  - content_type: text
+//	    title: Xem chi tiết
+//	    payload: "ORDER_{{.ID}}"
+//
+// Titles are tidied with utils.FormatMessage and the message must validate.
+func (r *TemplateRegistry) RenderMessage(locale, name string, data interface{}) (types.StructuredMessage, error) {
+	var message types.StructuredMessage
+
+	output, err := r.execute(locale, name, data)
+	if err != nil {
+		return message, err
+	}
+
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(output), &value); err != nil {
+		return message, fmt.Errorf("template %s: %w", name, err)
+	}
+	if message, err = decodeStructuredMessage(value); err != nil {
+		return message, fmt.Errorf("template %s: %w", name, err)
+	}
+
+	for i := range message.Elements {
+		element := &message.Elements[i]
+		element.Title = utils.FormatMessage(element.Title)
+		element.Subtitle = utils.FormatMessage(element.Subtitle)
+		for j := range element.Buttons {
+			element.Buttons[j].Title = utils.FormatMessage(element.Buttons[j].Title)
+		}
+	}
+	for i := range message.QuickReplies {
+		message.QuickReplies[i].Title = utils.FormatMessage(message.QuickReplies[i].Title)
+	}
+
+	if err := message.Validate(); err != nil {
+		return message, err
+	}
+	return message, nil
+}
+
+// decodeStructuredMessage converts a message decoded from YAML into a
+// StructuredMessage. It goes through JSON so the API field names apply.
+func decodeStructuredMessage(value interface{}) (types.StructuredMessage, error) {
+	var message types.StructuredMessage
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return message, err
+	}
+	err = json.Unmarshal(encoded, &message)
+	return message, err
+}
+
+// Reply renders a text template in the locale stored under LocaleKey and
+// sends it to the chat the update came from
+func (r *TemplateRegistry) Reply(c *Context, name string, data interface{}) (*types.Message, error) {
+	text, err := r.Render(c.GetString(LocaleKey), name, data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Reply(text)
+}
+
+// ReplyMessage renders a StructuredMessage template in the locale stored
+// under LocaleKey and sends it to the chat the update came from
+func (r *TemplateRegistry) ReplyMessage(c *Context, name string, data interface{}) (*types.Message, error) {
+	message, err := r.RenderMessage(c.GetString(LocaleKey), name, data)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReplyTemplate(message)
+}
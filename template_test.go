@@ -0,0 +1,152 @@
+package zalobot
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+func newTestTemplates(t *testing.T) *TemplateRegistry {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"vi/greeting.tmpl": {Data: []byte("  Xin chào {{.Name}}!\r\n\n\n\nĐơn hàng của bạn: {{vnd .Total}}\n")},
+		"vi/order.tmpl": {Data: []byte(`type: template
+elements:
+  - title: "Đơn hàng {{.ID}}"
+    subtitle: "Giao ngày {{date .Due}}"
+quick_replies:
+  - content_type: text
+    title: "  Xem chi tiết "
+    payload: "ORDER_{{.ID}}"
+`)},
+		"en.yaml": {Data: []byte(`greeting: "Hello {{.Name}}! Your total is {{number .Total}} VND"
+footer: "{{template \"greeting\" .}} Thanks!"
+`)},
+	}
+
+	registry := NewTemplateRegistry(TemplateConfig{})
+	if err := registry.LoadFS(fsys); err != nil {
+		t.Fatalf("LoadFS() error = %v", err)
+	}
+	return registry
+}
+
+func TestTemplateRegistry_Render(t *testing.T) {
+	registry := newTestTemplates(t)
+	data := map[string]interface{}{"Name": "Lan", "Total": 1500000}
+
+	text, err := registry.Render("vi", "greeting", data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "Xin chào Lan!\n\nĐơn hàng của bạn: 1.500.000 ₫"; text != want {
+		t.Errorf("Render(vi) = %q, want %q", text, want)
+	}
+
+	// Regional locales fall back to their language, then to the default
+	if text, _ := registry.Render("en-US", "greeting", data); text != "Hello Lan! Your total is 1.500.000 VND" {
+		t.Errorf("Render(en-US) = %q", text)
+	}
+	if text, _ := registry.Render("en", "footer", data); !strings.HasSuffix(text, "Thanks!") {
+		t.Errorf("Render(en, footer) = %q, want included template", text)
+	}
+	if text, _ := registry.Render("fr", "greeting", data); !strings.HasPrefix(text, "Xin chào") {
+		t.Errorf("Render(fr) = %q, want default locale", text)
+	}
+
+	if _, err := registry.Render("vi", "missing", data); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Render(missing) error = %v, want ErrTemplateNotFound", err)
+	}
+	if _, err := registry.Render("vi", "greeting", map[string]interface{}{"Name": "Lan"}); err == nil {
+		t.Error("Render() with a missing key error = nil")
+	}
+}
+
+func TestTemplateRegistry_RenderMessage(t *testing.T) {
+	registry := newTestTemplates(t)
+
+	message, err := registry.RenderMessage("vi", "order", map[string]interface{}{
+		"ID":  "A42",
+		"Due": time.Date(2024, time.September, 2, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("RenderMessage() error = %v", err)
+	}
+	if message.Type != types.StructuredMessageTypeTemplate || len(message.Elements) != 1 || len(message.QuickReplies) != 1 {
+		t.Fatalf("RenderMessage() = %+v", message)
+	}
+	if message.Elements[0].Subtitle != "Giao ngày 02/09/2024" {
+		t.Errorf("Subtitle = %q", message.Elements[0].Subtitle)
+	}
+	if reply := message.QuickReplies[0]; reply.Title != "Xem chi tiết" || reply.Payload != "ORDER_A42" {
+		t.Errorf("QuickReply = %+v", reply)
+	}
+}
+
+func TestTemplateRegistry_Check(t *testing.T) {
+	registry := newTestTemplates(t)
+
+	err := registry.Check()
+	if err == nil {
+		t.Fatal("Check() error = nil, want missing templates")
+	}
+	for _, want := range []string{"locale en is missing order", "locale vi is missing footer"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Check() error = %v, want %q", err, want)
+		}
+	}
+
+	registry.Add("en", "order", "type: template")
+	registry.Add("vi", "footer", "Cảm ơn!")
+	if err := registry.Check(); err != nil {
+		t.Errorf("Check() error = %v, want nil", err)
+	}
+
+	if err := registry.CheckData("greeting", map[string]interface{}{"Name": "Lan"}); err == nil {
+		t.Error("CheckData() without Total error = nil")
+	}
+	if err := registry.CheckData("greeting", map[string]interface{}{"Name": "Lan", "Total": 1}); err != nil {
+		t.Errorf("CheckData() error = %v", err)
+	}
+
+	// A template that fails to parse leaves the registry unchanged
+	if err := registry.Add("vi", "greeting", "{{.Name"); err == nil {
+		t.Error("Add() with a syntax error error = nil")
+	}
+	if text, _ := registry.Render("vi", "greeting", map[string]interface{}{"Name": "Lan", "Total": 1}); !strings.HasPrefix(text, "Xin chào Lan") {
+		t.Errorf("Render() after failed Add = %q", text)
+	}
+}
+
+func TestTemplateRegistry_Reply(t *testing.T) {
+	fake := &fakeReplyServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	registry := newTestTemplates(t)
+	update := types.Update{Message: &types.Message{From: &types.User{ID: "u1"}, Chat: &types.Chat{ID: "chat-1"}}}
+	err = bot.Handler(func(c *Context) error {
+		c.Set(LocaleKey, "en")
+		_, err := registry.Reply(c, "greeting", map[string]interface{}{"Name": "Lan", "Total": 20000})
+		return err
+	})(context.Background(), update)
+	if err != nil {
+		t.Fatalf("Reply() error = %v", err)
+	}
+
+	if text := fake.bodies[0]["text"]; text != "Hello Lan! Your total is 20.000 VND" {
+		t.Errorf("sent text = %v", text)
+	}
+}
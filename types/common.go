@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strconv"
 	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
 // APIResponse represents a generic API response wrapper
@@ -106,27 +108,14 @@ func (r *RateLimitInfo) String() string {
 		r.Limit, r.Remaining, r.Reset.Format(time.RFC3339), r.RetryAfter)
 }
 
-// LogLevel represents the logging level
-type LogLevel string
+// LogLevel represents the logging level. It is the utils.LogLevel used by
+// loggers, and reads and writes as "debug", "info", "warn" or "error" in
+// configuration files.
+type LogLevel = utils.LogLevel
 
 const (
-	LogLevelDebug LogLevel = "debug"
-	LogLevelInfo  LogLevel = "info"
-	LogLevelWarn  LogLevel = "warn"
-	LogLevelError LogLevel = "error"
+	LogLevelDebug = utils.LogLevelDebug
+	LogLevelInfo  = utils.LogLevelInfo
+	LogLevelWarn  = utils.LogLevelWarn
+	LogLevelError = utils.LogLevelError
 )
-
-// IsValid validates the log level
-func (ll LogLevel) IsValid() bool {
-	switch ll {
-	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
-		return true
-	default:
-		return false
-	}
-}
-
-// String returns the string representation of LogLevel
-func (ll LogLevel) String() string {
-	return string(ll)
-}
@@ -16,8 +16,8 @@ func TestLogLevel_IsValid(t *testing.T) {
 		{"valid info", LogLevelInfo, true},
 		{"valid warn", LogLevelWarn, true},
 		{"valid error", LogLevelError, true},
-		{"invalid level", LogLevel("invalid"), false},
-		{"empty level", LogLevel(""), false},
+		{"invalid level", LogLevel(99), false},
+		{"negative level", LogLevel(-1), false},
 	}
 
 	for _, tt := range tests {
@@ -29,7 +29,7 @@ func TestLogLevel_IsValid(t *testing.T) {
 	}
 }
 
-func TestLogLevel_String(t *testing.T) {
+func TestLogLevel_MarshalText(t *testing.T) {
 	tests := []struct {
 		name string
 		ll   LogLevel
@@ -43,8 +43,14 @@ func TestLogLevel_String(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.ll.String(); got != tt.want {
-				t.Errorf("LogLevel.String() = %v, want %v", got, tt.want)
+			got, err := tt.ll.MarshalText()
+			if err != nil || string(got) != tt.want {
+				t.Errorf("LogLevel.MarshalText() = %s, %v, want %v", got, err, tt.want)
+			}
+
+			var parsed LogLevel
+			if err := parsed.UnmarshalText([]byte(tt.want)); err != nil || parsed != tt.ll {
+				t.Errorf("LogLevel.UnmarshalText(%q) = %v, %v, want %v", tt.want, parsed, err, tt.ll)
 			}
 		})
 	}
@@ -1,21 +1,54 @@
 package types
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
 	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
 // Config represents the configuration for the Zalo Bot SDK
 type Config struct {
-	BotToken    string // Bot token for authentication
-	BaseURL     string // Base API URL (default: https://bot-api.zapps.me)
-	Debug       bool
-	Timeout     time.Duration
-	Retries     int
-	Environment Environment // Development or Production
-	HTTPClient  *http.Client
-	RetryConfig *RetryConfig // Retry configuration for error handling
+	BotToken      string // Bot token for authentication
+	BaseURL       string // Base API URL (default: https://bot-api.zapps.me)
+	Debug         bool
+	Timeout       time.Duration
+	Retries       int
+	Environment   Environment // Development or Production
+	HTTPClient    *http.Client
+	RetryConfig   *RetryConfig       // Retry configuration for error handling
+	LogLevel      LogLevel           // Minimum level of the stderr logger enabled by Debug; other levels require Debug (default: debug)
+	Logger        utils.Logger       // Destination of SDK log output (default: none, or stderr with Debug)
+	Health        *HealthConfig      // Thresholds for health and readiness checks
+	Metrics       utils.Metrics      // Instrumentation hooks (default: no-op)
+	Tracer        utils.Tracer       // Tracing hooks (default: no-op)
+	RateLimiter   utils.RateLimiter  // Throttles outgoing API requests (default: none)
+	Credentials   CredentialProvider // Source of the bot token, re-fetched after auth failures
+	BotInfoTTL    time.Duration      // How long the GetMe result is cached (default: 5m)
+	AppIdentifier string             // Appended to the SDK User-Agent, e.g. "shop-bot/2.1"
+	UserAgent     string             // User-Agent of every request (default: SDK name/version, then AppIdentifier)
+	Dedup         *DedupConfig       // Drops updates delivered more than once (default: disabled)
+	Recorder      UpdateRecorder     // Captures raw webhook and getUpdates payloads (default: none)
+	Sessions      *SessionConfig     // Per-chat session data for handlers (default: disabled)
+	WebhookSecret string             // Secret token expected on incoming webhook requests (default: none)
+}
+
+// UpdateRecorder captures raw update payloads before they are parsed, such
+// as utils.Recorder writing them to JSONL files for replay. source is
+// "webhook" or "polling".
+type UpdateRecorder interface {
+	Record(source string, payload []byte) error
+}
+
+// CredentialProvider supplies the bot token. The SDK fetches it at start-up
+// when no token is given, and again after the API rejects the current token,
+// so a rotated token is picked up without rebuilding the bot.
+type CredentialProvider interface {
+	Token(ctx context.Context) (string, error)
 }
 
 // MessageConfig represents configuration for sending messages
@@ -30,7 +63,115 @@ type MessageConfig struct {
 type WebhookConfig struct {
 	URL         string
 	SecretToken string
+	// Certificate is the path of a PEM public key certificate to upload, for
+	// webhooks served with a self-signed certificate
 	Certificate string
+	// CertificateData is the PEM certificate itself, used instead of
+	// Certificate when it is not kept in a file
+	CertificateData []byte
+	// MaxConnections limits simultaneous webhook deliveries, 1-100
+	// (0: server default)
+	MaxConnections int
+	// AllowedUpdates lists the event names to deliver, such as
+	// EventMessageText (empty: all events). Names are passed to the server
+	// as given, so event types without an Event* constant can be listed.
+	AllowedUpdates []string
+	// DropPendingUpdates discards updates queued before the webhook is set
+	DropPendingUpdates bool
+}
+
+// MaxWebhookConnections is the largest MaxConnections accepted by SetWebhook
+const MaxWebhookConnections = 100
+
+// HealthConfig represents the thresholds used by the liveness and readiness checks
+type HealthConfig struct {
+	// MaxConsecutiveErrors marks the bot not ready once this many API calls
+	// in a row have failed (default: 5)
+	MaxConsecutiveErrors int
+	// MaxPollStall marks the bot not alive when polling is active but no
+	// getUpdates call has completed for this long (default: 2m)
+	MaxPollStall time.Duration
+	// MaxUpdateAge marks the bot not ready when no update has been received
+	// for this long (0 disables the check)
+	MaxUpdateAge time.Duration
+	// CheckCredentials adds a getMe credential check to readiness
+	CheckCredentials bool
+	// CredentialCacheTTL is how long a credential check result is reused (default: 5m)
+	CredentialCacheTTL time.Duration
+}
+
+// DefaultHealthConfig returns a default health check configuration
+func DefaultHealthConfig() *HealthConfig {
+	return &HealthConfig{
+		MaxConsecutiveErrors: 5,
+		MaxPollStall:         2 * time.Minute,
+		CredentialCacheTTL:   5 * time.Minute,
+	}
+}
+
+// DedupConfig represents configuration for update deduplication. Zalo
+// delivers updates at least once, retrying webhooks that are acknowledged
+// slowly; with deduplication an update seen within TTL is not handled again.
+type DedupConfig struct {
+	// TTL is how long an update key is remembered (default: 10m)
+	TTL time.Duration
+	// MaxEntries bounds the in-memory cache; the oldest keys are evicted
+	// first (default: 10000)
+	MaxEntries int
+	// Store, if set, also records keys outside the process, so duplicates
+	// are dropped across restarts and replicas
+	Store DedupStore
+	// KeyPrefix namespaces the keys of this bot, so bots sharing a Store
+	// do not drop each other's updates (default: "bot<ID>:", with the bot
+	// ID taken from the bot token)
+	KeyPrefix string
+}
+
+// DedupStore is a persistent store of update keys, such as a Redis SET NX
+// with an expiry. Implementations must be safe for concurrent use.
+type DedupStore interface {
+	// Add records key for ttl and reports whether it was not recorded yet
+	Add(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Remove forgets key, so an update whose handler failed is handled again
+	// when it is redelivered
+	Remove(ctx context.Context, key string) error
+}
+
+// DefaultDedupConfig returns a default deduplication configuration
+func DefaultDedupConfig() *DedupConfig {
+	return &DedupConfig{
+		TTL:        10 * time.Minute,
+		MaxEntries: 10000,
+	}
+}
+
+// SessionConfig represents configuration for per-chat sessions, the data a
+// handler keeps for a chat between updates
+type SessionConfig struct {
+	// TTL is how long a session is kept after its last change (default: 30m)
+	TTL time.Duration
+	// Store holds session data (default: in memory). Use a persistent store
+	// to keep sessions across restarts and replicas.
+	Store SessionStore
+}
+
+// SessionStore holds session data by chat ID, such as in Redis. Values must
+// be JSON-serializable for stores outside the process. Implementations must
+// be safe for concurrent use.
+type SessionStore interface {
+	// Load returns the data stored for key, or nil if there is none
+	Load(ctx context.Context, key string) (map[string]interface{}, error)
+	// Save stores data for key, expiring it after ttl
+	Save(ctx context.Context, key string, data map[string]interface{}, ttl time.Duration) error
+	// Delete removes the data stored for key
+	Delete(ctx context.Context, key string) error
+}
+
+// DefaultSessionConfig returns a default session configuration
+func DefaultSessionConfig() *SessionConfig {
+	return &SessionConfig{
+		TTL: 30 * time.Minute,
+	}
 }
 
 // UpdateConfig represents configuration for getting updates
@@ -38,6 +179,44 @@ type UpdateConfig struct {
 	Offset  int
 	Limit   int
 	Timeout int
+	// Buffer configures the channel returned by GetUpdatesChan; it is
+	// ignored by GetUpdates
+	Buffer BufferConfig
+}
+
+// DefaultUpdateBufferSize is the default capacity of an update channel
+const DefaultUpdateBufferSize = 100
+
+// OverflowPolicy decides what happens to a polled update when a consumer's
+// buffer is full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits for the consumer, which pauses polling (default)
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest discards the oldest buffered update to make room
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDropNewest discards the incoming update
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowSpill queues updates in a file under SpillDir until the
+	// consumer catches up, preserving their order
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+// BufferConfig represents the buffer between polling and one consumer of
+// updates
+type BufferConfig struct {
+	// Name identifies the consumer in metrics and spill files (default:
+	// "default" for GetUpdatesChan, "subscriber-N" otherwise)
+	Name string
+	// Size is the channel capacity (default: 100)
+	Size int
+	// Overflow is the policy applied when the channel is full (default: OverflowBlock)
+	Overflow OverflowPolicy
+	// SpillDir is the directory of the spill queue, required by OverflowSpill.
+	// Updates still queued when polling stops are delivered the next time a
+	// consumer with the same name and directory subscribes.
+	SpillDir string
 }
 
 // BotOption represents a configuration option for the bot
@@ -78,6 +257,91 @@ func WithRetryConfig(retryConfig *RetryConfig) BotOption {
 	return func(c *Config) { c.RetryConfig = retryConfig }
 }
 
+// WithLogLevel sets the minimum level of the stderr logger enabled by
+// WithDebug. Levels other than debug are rejected without WithDebug, as
+// nothing would be logged.
+func WithLogLevel(level LogLevel) BotOption {
+	return func(c *Config) { c.LogLevel = level }
+}
+
+// WithLogger sets the logger that receives SDK log output. The logger's own
+// level applies; LogLevel only configures the default logger.
+func WithLogger(logger utils.Logger) BotOption {
+	return func(c *Config) { c.Logger = logger }
+}
+
+// WithHealthConfig sets the thresholds for health and readiness checks
+func WithHealthConfig(health *HealthConfig) BotOption {
+	return func(c *Config) { c.Health = health }
+}
+
+// WithMetrics sets the metrics collector that receives API, update and
+// handler instrumentation
+func WithMetrics(metrics utils.Metrics) BotOption {
+	return func(c *Config) { c.Metrics = metrics }
+}
+
+// WithTracer sets the tracer used to create spans around API calls, webhook
+// receipt and handler execution
+func WithTracer(tracer utils.Tracer) BotOption {
+	return func(c *Config) { c.Tracer = tracer }
+}
+
+// WithRateLimiter sets the limiter every outgoing API request waits on
+func WithRateLimiter(limiter utils.RateLimiter) BotOption {
+	return func(c *Config) { c.RateLimiter = limiter }
+}
+
+// WithAppIdentifier appends an application identifier, such as
+// "shop-bot/2.1", to the User-Agent of every request
+func WithAppIdentifier(appIdentifier string) BotOption {
+	return func(c *Config) { c.AppIdentifier = appIdentifier }
+}
+
+// WithBotInfoTTL sets how long the bot identity returned by GetMe is cached
+func WithBotInfoTTL(ttl time.Duration) BotOption {
+	return func(c *Config) { c.BotInfoTTL = ttl }
+}
+
+// WithWebhookSecretToken sets the secret token incoming webhook requests must
+// carry. SetWebhook still has to be called to register it with Zalo.
+func WithWebhookSecretToken(token string) BotOption {
+	return func(c *Config) { c.WebhookSecret = token }
+}
+
+// WithCredentialProvider sets the provider the bot token is fetched from
+func WithCredentialProvider(provider CredentialProvider) BotOption {
+	return func(c *Config) { c.Credentials = provider }
+}
+
+// WithUpdateRecorder captures every webhook request body and non-empty
+// getUpdates response with recorder
+func WithUpdateRecorder(recorder UpdateRecorder) BotOption {
+	return func(c *Config) { c.Recorder = recorder }
+}
+
+// WithSessions enables per-chat sessions for handlers. A nil config uses
+// DefaultSessionConfig.
+func WithSessions(sessions *SessionConfig) BotOption {
+	return func(c *Config) {
+		c.Sessions = sessions
+		if c.Sessions == nil {
+			c.Sessions = DefaultSessionConfig()
+		}
+	}
+}
+
+// WithDeduplication drops webhook and polling updates already received
+// within the configured TTL
+func WithDeduplication(dedup *DedupConfig) BotOption {
+	return func(c *Config) {
+		c.Dedup = dedup
+		if c.Dedup == nil {
+			c.Dedup = DefaultDedupConfig()
+		}
+	}
+}
+
 // ImageMessageConfig represents configuration for sending image messages
 type ImageMessageConfig struct {
 	ChatID   string
@@ -155,6 +419,20 @@ func (c *Config) GetAPIEndpoint(method string) string {
 	return fmt.Sprintf("%s/bot%s/%s", c.BaseURL, c.BotToken, method)
 }
 
+// DefaultLogger returns the logger used when Logger is not set: a text
+// logger on stderr at LogLevel when Debug is set, and a no-op logger
+// otherwise
+func (c *Config) DefaultLogger() utils.Logger {
+	if !c.Debug {
+		return utils.NewNoOpLogger()
+	}
+	return utils.NewLogger(utils.LogConfig{
+		Level:  c.LogLevel,
+		Output: os.Stderr,
+		Format: utils.LogFormatText,
+	})
+}
+
 // Validate validates the Config
 func (c *Config) Validate() error {
 	if c.BotToken == "" {
@@ -198,16 +476,78 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if !c.LogLevel.IsValid() {
+		return &ZaloBotError{
+			Code:    400,
+			Message: "Invalid log level",
+			Type:    ErrorTypeValidation,
+		}
+	}
+
+	if c.LogLevel != LogLevelDebug && !c.Debug {
+		return NewValidationError("log level only applies to the stderr logger enabled by debug mode")
+	}
+
+	if c.Logger == nil {
+		c.Logger = c.DefaultLogger()
+	}
+
 	if c.HTTPClient == nil {
 		c.HTTPClient = &http.Client{
 			Timeout: c.Timeout,
 		}
 	}
 
+	if c.BotInfoTTL <= 0 {
+		c.BotInfoTTL = 5 * time.Minute
+	}
+
+	if c.UserAgent == "" {
+		c.UserAgent = utils.UserAgent(c.AppIdentifier)
+	}
+
 	if c.RetryConfig == nil {
 		c.RetryConfig = DefaultRetryConfig()
 	}
 
+	if c.Health == nil {
+		c.Health = DefaultHealthConfig()
+	}
+
+	// Defaults are filled in on copies, as the structs belong to the caller
+	// and may be shared by several bots
+	if c.Dedup != nil {
+		dedup := *c.Dedup
+		defaults := DefaultDedupConfig()
+		if dedup.TTL <= 0 {
+			dedup.TTL = defaults.TTL
+		}
+		if dedup.MaxEntries <= 0 {
+			dedup.MaxEntries = defaults.MaxEntries
+		}
+		if dedup.KeyPrefix == "" {
+			botID, _, _ := strings.Cut(c.BotToken, ":")
+			dedup.KeyPrefix = "bot" + botID + ":"
+		}
+		c.Dedup = &dedup
+	}
+
+	if c.Sessions != nil {
+		sessions := *c.Sessions
+		if sessions.TTL <= 0 {
+			sessions.TTL = DefaultSessionConfig().TTL
+		}
+		c.Sessions = &sessions
+	}
+
+	if c.Metrics == nil {
+		c.Metrics = utils.NewNoOpMetrics()
+	}
+
+	if c.Tracer == nil {
+		c.Tracer = utils.NewNoOpTracer()
+	}
+
 	return nil
 }
 
@@ -292,6 +632,25 @@ func (wc *WebhookConfig) Validate() error {
 		}
 	}
 
+	if wc.Certificate != "" && len(wc.CertificateData) > 0 {
+		return NewValidationError("only one of Certificate and CertificateData can be set")
+	}
+
+	if wc.MaxConnections < 0 || wc.MaxConnections > MaxWebhookConnections {
+		return NewValidationError(fmt.Sprintf("max connections must be between 0 and %d (0 for the server default)", MaxWebhookConnections))
+	}
+
+	seen := make(map[string]bool, len(wc.AllowedUpdates))
+	for _, event := range wc.AllowedUpdates {
+		if event == "" {
+			return NewValidationError("allowed update names cannot be empty")
+		}
+		if seen[event] {
+			return NewValidationError(fmt.Sprintf("duplicate allowed update %q", event))
+		}
+		seen[event] = true
+	}
+
 	return nil
 }
 
@@ -309,6 +668,30 @@ func (uc *UpdateConfig) Validate() error {
 		uc.Timeout = 0
 	}
 
+	return uc.Buffer.Validate()
+}
+
+// Validate validates the BufferConfig and fills in defaults
+func (bc *BufferConfig) Validate() error {
+	if bc.Size < 0 {
+		return NewValidationError("buffer size must not be negative")
+	}
+	if bc.Size == 0 {
+		bc.Size = DefaultUpdateBufferSize
+	}
+
+	switch bc.Overflow {
+	case "":
+		bc.Overflow = OverflowBlock
+	case OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+	case OverflowSpill:
+		if bc.SpillDir == "" {
+			return NewValidationError("SpillDir is required by the spill overflow policy")
+		}
+	default:
+		return NewValidationError(fmt.Sprintf("unknown overflow policy %q", bc.Overflow))
+	}
+
 	return nil
 }
 
@@ -380,7 +763,9 @@ func (smc *StructuredMessageConfig) Validate() error {
 // IsValid validates the chat action type
 func (cat *ChatActionType) IsValid() bool {
 	switch *cat {
-	case ChatActionTyping, ChatActionUploadPhoto:
+	case ChatActionTyping, ChatActionUploadPhoto, ChatActionRecordVideo, ChatActionUploadVideo,
+		ChatActionRecordVoice, ChatActionUploadVoice, ChatActionUploadDocument, ChatActionChooseSticker,
+		ChatActionFindLocation:
 		return true
 	default:
 		return false
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"testing"
 	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/utils"
 )
 
 func TestEnvironment_IsValid(t *testing.T) {
@@ -231,6 +233,51 @@ func TestWebhookConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "all options",
+			config: &WebhookConfig{
+				URL:                "https://example.com/webhook",
+				Certificate:        "/etc/ssl/bot.pem",
+				MaxConnections:     40,
+				AllowedUpdates:     []string{EventMessageText, EventMessageImage},
+				DropPendingUpdates: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "certificate path and data",
+			config: &WebhookConfig{
+				URL:             "https://example.com/webhook",
+				Certificate:     "/etc/ssl/bot.pem",
+				CertificateData: []byte("-----BEGIN CERTIFICATE-----"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "negative max connections",
+			config:  &WebhookConfig{URL: "https://example.com/webhook", MaxConnections: -1},
+			wantErr: true,
+		},
+		{
+			name:    "too many max connections",
+			config:  &WebhookConfig{URL: "https://example.com/webhook", MaxConnections: MaxWebhookConnections + 1},
+			wantErr: true,
+		},
+		{
+			name:    "allowed update without an event constant",
+			config:  &WebhookConfig{URL: "https://example.com/webhook", AllowedUpdates: []string{EventMessageText, "user.follow"}},
+			wantErr: false,
+		},
+		{
+			name:    "empty allowed update",
+			config:  &WebhookConfig{URL: "https://example.com/webhook", AllowedUpdates: []string{""}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate allowed update",
+			config:  &WebhookConfig{URL: "https://example.com/webhook", AllowedUpdates: []string{EventMessageText, EventMessageText}},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -324,6 +371,53 @@ func TestUpdateConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestBufferConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  BufferConfig
+		want    BufferConfig
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			config: BufferConfig{},
+			want:   BufferConfig{Size: DefaultUpdateBufferSize, Overflow: OverflowBlock},
+		},
+		{
+			name:   "drop oldest",
+			config: BufferConfig{Size: 10, Overflow: OverflowDropOldest},
+			want:   BufferConfig{Size: 10, Overflow: OverflowDropOldest},
+		},
+		{
+			name:    "negative size",
+			config:  BufferConfig{Size: -1},
+			wantErr: true,
+		},
+		{
+			name:    "unknown policy",
+			config:  BufferConfig{Overflow: "drop_all"},
+			wantErr: true,
+		},
+		{
+			name:    "spill without directory",
+			config:  BufferConfig{Overflow: OverflowSpill},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BufferConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.config != tt.want {
+				t.Errorf("BufferConfig = %+v, want %+v", tt.config, tt.want)
+			}
+		})
+	}
+}
+
 func TestConfig_GetAPIEndpoint(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -529,6 +623,63 @@ func TestBotOptions(t *testing.T) {
 	if config.RetryConfig != retryConfig {
 		t.Error("WithRetryConfig() failed, RetryConfig not set")
 	}
+
+	// Test WithLogger
+	logger := utils.NewNoOpLogger()
+	WithLogger(logger)(config)
+	if config.Logger != logger {
+		t.Error("WithLogger() failed, Logger not set")
+	}
+}
+
+func TestConfig_Validate_DefaultLogger(t *testing.T) {
+	config := &Config{BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if _, ok := config.Logger.(*utils.NoOpLogger); !ok {
+		t.Errorf("default logger = %T, want *utils.NoOpLogger", config.Logger)
+	}
+
+	debugConfig := &Config{BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", Debug: true}
+	if err := debugConfig.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !debugConfig.Logger.IsEnabled(utils.LogLevelDebug) {
+		t.Error("debug mode should enable debug logging")
+	}
+
+	warnConfig := &Config{BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", Debug: true, LogLevel: LogLevelWarn}
+	if err := warnConfig.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if warnConfig.Logger.IsEnabled(utils.LogLevelInfo) || !warnConfig.Logger.IsEnabled(utils.LogLevelWarn) {
+		t.Error("debug logger should use LogLevel")
+	}
+
+	quietConfig := &Config{BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", LogLevel: LogLevelWarn}
+	if err := quietConfig.Validate(); err == nil {
+		t.Error("Validate() accepted a log level without debug mode")
+	}
+}
+
+func TestConfig_Validate_SharedDedupAndSessions(t *testing.T) {
+	dedup := &DedupConfig{}
+	sessions := &SessionConfig{}
+
+	config := &Config{BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"}
+	WithDeduplication(dedup)(config)
+	WithSessions(sessions)(config)
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if config.Dedup.TTL != DefaultDedupConfig().TTL || config.Sessions.TTL != DefaultSessionConfig().TTL {
+		t.Errorf("Dedup/Sessions = %+v/%+v, want default TTLs", config.Dedup, config.Sessions)
+	}
+	if *dedup != (DedupConfig{}) || *sessions != (SessionConfig{}) {
+		t.Errorf("Validate() changed the caller's configs: %+v, %+v", dedup, sessions)
+	}
 }
 
 func TestEnvironment_String(t *testing.T) {
@@ -603,6 +754,8 @@ func TestChatActionType_IsValid(t *testing.T) {
 	}{
 		{"typing is valid", ChatActionTyping, true},
 		{"upload_photo is valid", ChatActionUploadPhoto, true},
+		{"upload_document is valid", ChatActionUploadDocument, true},
+		{"find_location is valid", ChatActionFindLocation, true},
 		{"unknown is invalid", ChatActionType("unknown"), false},
 	}
 
@@ -34,6 +34,21 @@ func (e *ZaloBotError) IsRetryable() bool {
 	}
 }
 
+// IsAuthError returns true if the error means the bot token was rejected,
+// either as a typed auth error or as an API error with a 401 or 403 code
+func (e *ZaloBotError) IsAuthError() bool {
+	if e.Type == ErrorTypeAuth {
+		return true
+	}
+	return e.Type == ErrorTypeAPI && (e.Code == 401 || e.Code == 403)
+}
+
+// IsAuthError returns true if err is a ZaloBotError reporting a rejected bot token
+func IsAuthError(err error) bool {
+	zaloBotErr, ok := err.(*ZaloBotError)
+	return ok && zaloBotErr.IsAuthError()
+}
+
 // ErrorType represents the type of error
 type ErrorType string
 
@@ -0,0 +1,350 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables read by LoadConfig
+const EnvPrefix = "ZALO_BOT_"
+
+// Settings represents bot configuration loaded from defaults, a YAML or JSON
+// file and ZALO_BOT_* environment variables
+type Settings struct {
+	BotToken    string          `yaml:"bot_token" json:"bot_token"`
+	BaseURL     string          `yaml:"base_url" json:"base_url"`
+	Timeout     Duration        `yaml:"timeout" json:"timeout"`
+	Environment Environment     `yaml:"environment" json:"environment"`
+	Debug       bool            `yaml:"debug" json:"debug"`
+	LogLevel    LogLevel        `yaml:"log_level" json:"log_level"`
+	Retry       RetrySettings   `yaml:"retry" json:"retry"`
+	Webhook     WebhookSettings `yaml:"webhook" json:"webhook"`
+}
+
+// RetrySettings represents the retry section of Settings
+type RetrySettings struct {
+	MaxRetries    int      `yaml:"max_retries" json:"max_retries"`
+	InitialDelay  Duration `yaml:"initial_delay" json:"initial_delay"`
+	MaxDelay      Duration `yaml:"max_delay" json:"max_delay"`
+	BackoffFactor float64  `yaml:"backoff_factor" json:"backoff_factor"`
+}
+
+// WebhookSettings represents the webhook section of Settings
+type WebhookSettings struct {
+	URL         string `yaml:"url" json:"url"`
+	SecretToken string `yaml:"secret_token" json:"secret_token"`
+}
+
+// Duration is a time.Duration that decodes from either a Go duration string
+// ("30s", "1m30s") or a number of seconds
+type Duration time.Duration
+
+// String returns the string representation of Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string or a number of seconds")
+	}
+	return d.parse(s)
+}
+
+// MarshalYAML implements yaml.Marshaler interface
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return fmt.Errorf("line %d: duration must be a string or a number of seconds", node.Line)
+	}
+	if err := d.parse(s); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return nil
+}
+
+// parse parses a duration string, treating a bare number as seconds
+func (d *Duration) parse(s string) error {
+	s = strings.TrimSpace(s)
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultSettings returns the settings used when neither a file nor the
+// environment provide a value
+func DefaultSettings() *Settings {
+	retry := DefaultRetryConfig()
+	return &Settings{
+		BaseURL:     "https://bot-api.zapps.me",
+		Timeout:     Duration(30 * time.Second),
+		Environment: Production,
+		LogLevel:    LogLevelDebug,
+		Retry: RetrySettings{
+			MaxRetries:    retry.MaxRetries,
+			InitialDelay:  Duration(retry.InitialDelay),
+			MaxDelay:      Duration(retry.MaxDelay),
+			BackoffFactor: retry.BackoffFactor,
+		},
+	}
+}
+
+// LoadConfig builds Settings by merging, in increasing order of precedence,
+// DefaultSettings, the YAML or JSON file at path (skipped when path is empty)
+// and ZALO_BOT_* environment variables. The result is validated before it is
+// returned.
+func LoadConfig(path string) (*Settings, error) {
+	settings := DefaultSettings()
+
+	if path != "" {
+		if err := settings.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := settings.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// loadFile decodes the file at path on top of the current settings. Files
+// ending in .json are decoded as JSON, everything else as YAML.
+func (s *Settings) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return NewValidationError(fmt.Sprintf("failed to read config file: %v", err))
+	}
+
+	// Unknown keys are rejected so a misspelled setting is not silently
+	// replaced by its default
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(s)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(s)
+	}
+	if err != nil && err != io.EOF {
+		return NewValidationError(fmt.Sprintf("failed to parse config file %s: %v", path, err))
+	}
+
+	return nil
+}
+
+// loadEnv applies ZALO_BOT_* environment variables on top of the current
+// settings using lookup to read them
+func (s *Settings) loadEnv(lookup func(string) (string, bool)) error {
+	var problems []string
+
+	str := func(name string, target *string) {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			*target = value
+		}
+	}
+	duration := func(field, name string, target *Duration) {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			if err := target.parse(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s (%s%s): %v", field, EnvPrefix, name, err))
+			}
+		}
+	}
+
+	str("TOKEN", &s.BotToken)
+	str("BASE_URL", &s.BaseURL)
+	duration("timeout", "TIMEOUT", &s.Timeout)
+	duration("retry.initial_delay", "RETRY_INITIAL_DELAY", &s.Retry.InitialDelay)
+	duration("retry.max_delay", "RETRY_MAX_DELAY", &s.Retry.MaxDelay)
+	str("WEBHOOK_URL", &s.Webhook.URL)
+	str("WEBHOOK_SECRET", &s.Webhook.SecretToken)
+
+	if value, ok := lookup(EnvPrefix + "ENVIRONMENT"); ok {
+		s.Environment = Environment(strings.ToLower(strings.TrimSpace(value)))
+	}
+
+	if value, ok := lookup(EnvPrefix + "LOG_LEVEL"); ok {
+		if err := s.LogLevel.UnmarshalText([]byte(value)); err != nil {
+			problems = append(problems, fmt.Sprintf("log_level (%sLOG_LEVEL): %v", EnvPrefix, err))
+		}
+	}
+
+	if value, ok := lookup(EnvPrefix + "DEBUG"); ok {
+		debug, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("debug (%sDEBUG): invalid boolean %q", EnvPrefix, value))
+		} else {
+			s.Debug = debug
+		}
+	}
+
+	if value, ok := lookup(EnvPrefix + "RETRY_MAX_RETRIES"); ok {
+		retries, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("retry.max_retries (%sRETRY_MAX_RETRIES): invalid integer %q", EnvPrefix, value))
+		} else {
+			s.Retry.MaxRetries = retries
+		}
+	}
+
+	if value, ok := lookup(EnvPrefix + "RETRY_BACKOFF_FACTOR"); ok {
+		factor, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("retry.backoff_factor (%sRETRY_BACKOFF_FACTOR): invalid number %q", EnvPrefix, value))
+		} else {
+			s.Retry.BackoffFactor = factor
+		}
+	}
+
+	if len(problems) > 0 {
+		return NewValidationError("invalid config: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// Validate validates the Settings, reporting every invalid field by its path
+// in the configuration file (e.g. "retry.max_delay")
+func (s *Settings) Validate() error {
+	var problems []string
+	add := func(field, format string, args ...interface{}) {
+		problems = append(problems, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.BotToken != "" && !isValidBotTokenFormat(s.BotToken) {
+		add("bot_token", "invalid bot token format")
+	}
+
+	if s.BaseURL == "" {
+		add("base_url", "is required")
+	} else if u, err := url.Parse(s.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("base_url", "must be an absolute http(s) URL")
+	}
+
+	if s.Timeout <= 0 {
+		add("timeout", "must be positive")
+	}
+
+	if !s.Environment.IsValid() {
+		add("environment", "must be %q or %q, got %q", Development, Production, s.Environment)
+	}
+
+	if !s.LogLevel.IsValid() {
+		add("log_level", "must be one of debug, info, warn, error, got %d", int(s.LogLevel))
+	} else if s.LogLevel != LogLevelDebug && !s.Debug {
+		add("log_level", "requires debug to be enabled")
+	}
+
+	if s.Retry.MaxRetries < 0 {
+		add("retry.max_retries", "must not be negative")
+	}
+	if s.Retry.InitialDelay < 0 {
+		add("retry.initial_delay", "must not be negative")
+	}
+	if s.Retry.MaxDelay < s.Retry.InitialDelay {
+		add("retry.max_delay", "must not be less than retry.initial_delay")
+	}
+	if s.Retry.BackoffFactor < 1 {
+		add("retry.backoff_factor", "must be at least 1")
+	}
+
+	if s.Webhook.URL != "" {
+		if u, err := url.Parse(s.Webhook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			add("webhook.url", "must be an absolute HTTPS URL")
+		}
+	}
+	if s.Webhook.SecretToken != "" && s.Webhook.URL == "" {
+		add("webhook.secret_token", "requires webhook.url")
+	}
+
+	if len(problems) > 0 {
+		return NewValidationError("invalid config: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// RetryConfig returns the retry section as a RetryConfig
+func (s *Settings) RetryConfig() *RetryConfig {
+	retry := DefaultRetryConfig()
+	retry.MaxRetries = s.Retry.MaxRetries
+	retry.InitialDelay = time.Duration(s.Retry.InitialDelay)
+	retry.MaxDelay = time.Duration(s.Retry.MaxDelay)
+	retry.BackoffFactor = s.Retry.BackoffFactor
+	return retry
+}
+
+// WebhookConfig returns the webhook section as a WebhookConfig
+func (s *Settings) WebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		URL:         s.Webhook.URL,
+		SecretToken: s.Webhook.SecretToken,
+	}
+}
+
+// Options returns the BotOptions that apply the settings when passed to
+// zalobot.New together with BotToken. The webhook secret token is checked on
+// incoming requests, but the webhook itself is only registered by passing
+// WebhookConfig to SetWebhook.
+func (s *Settings) Options() []BotOption {
+	options := []BotOption{
+		WithBaseURL(s.BaseURL),
+		WithTimeout(time.Duration(s.Timeout)),
+		WithEnvironment(s.Environment),
+		WithRetries(s.Retry.MaxRetries),
+		WithRetryConfig(s.RetryConfig()),
+		WithLogLevel(s.LogLevel),
+	}
+
+	if s.Debug {
+		options = append(options, WithDebug())
+	}
+
+	if s.Webhook.SecretToken != "" {
+		options = append(options, WithWebhookSecretToken(s.Webhook.SecretToken))
+	}
+
+	return options
+}
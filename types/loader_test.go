@@ -0,0 +1,281 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	settings, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	defaults := DefaultSettings()
+	if settings.BaseURL != defaults.BaseURL {
+		t.Errorf("BaseURL = %v, want %v", settings.BaseURL, defaults.BaseURL)
+	}
+	if settings.Timeout != defaults.Timeout {
+		t.Errorf("Timeout = %v, want %v", settings.Timeout, defaults.Timeout)
+	}
+	if settings.Environment != Production {
+		t.Errorf("Environment = %v, want %v", settings.Environment, Production)
+	}
+	if settings.LogLevel != LogLevelDebug {
+		t.Errorf("LogLevel = %v, want %v", settings.LogLevel, LogLevelDebug)
+	}
+}
+
+func TestLoadConfig_YAML(t *testing.T) {
+	path := writeConfigFile(t, "bot.yaml", `
+bot_token: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+base_url: https://dev-bot-api.zapps.me
+timeout: 10s
+environment: development
+debug: true
+log_level: debug
+retry:
+  max_retries: 5
+  initial_delay: 500ms
+  max_delay: 20
+  backoff_factor: 1.5
+webhook:
+  url: https://example.com/webhook
+  secret_token: s3cret
+`)
+
+	settings, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if settings.BotToken != "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11" {
+		t.Errorf("BotToken = %v", settings.BotToken)
+	}
+	if settings.BaseURL != "https://dev-bot-api.zapps.me" {
+		t.Errorf("BaseURL = %v", settings.BaseURL)
+	}
+	if time.Duration(settings.Timeout) != 10*time.Second {
+		t.Errorf("Timeout = %v, want 10s", settings.Timeout)
+	}
+	if settings.Environment != Development || !settings.Debug || settings.LogLevel != LogLevelDebug {
+		t.Errorf("Environment/Debug/LogLevel = %v/%v/%v", settings.Environment, settings.Debug, settings.LogLevel)
+	}
+	if settings.Retry.MaxRetries != 5 ||
+		time.Duration(settings.Retry.InitialDelay) != 500*time.Millisecond ||
+		time.Duration(settings.Retry.MaxDelay) != 20*time.Second ||
+		settings.Retry.BackoffFactor != 1.5 {
+		t.Errorf("Retry = %+v", settings.Retry)
+	}
+
+	webhook := settings.WebhookConfig()
+	if webhook.URL != "https://example.com/webhook" || webhook.SecretToken != "s3cret" {
+		t.Errorf("WebhookConfig() = %+v", webhook)
+	}
+}
+
+func TestLoadConfig_JSON(t *testing.T) {
+	path := writeConfigFile(t, "bot.json", `{
+		"timeout": 15,
+		"retry": {"max_retries": 1, "max_delay": "45s"}
+	}`)
+
+	settings, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if time.Duration(settings.Timeout) != 15*time.Second {
+		t.Errorf("Timeout = %v, want 15s", settings.Timeout)
+	}
+	if settings.Retry.MaxRetries != 1 || time.Duration(settings.Retry.MaxDelay) != 45*time.Second {
+		t.Errorf("Retry = %+v", settings.Retry)
+	}
+
+	// Fields absent from the file keep their defaults
+	if settings.Retry.BackoffFactor != DefaultSettings().Retry.BackoffFactor {
+		t.Errorf("Retry.BackoffFactor = %v, want default", settings.Retry.BackoffFactor)
+	}
+}
+
+func TestLoadConfig_JSONUnknownKey(t *testing.T) {
+	path := writeConfigFile(t, "bot.json", `{"webhook_secert": "s3cret"}`)
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "webhook_secert") {
+		t.Errorf("LoadConfig() error = %v, want it to name the unknown key", err)
+	}
+}
+
+func TestLoadConfig_EnvOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, "bot.yml", "timeout: 10s\nenvironment: development\n")
+
+	t.Setenv("ZALO_BOT_TOKEN", "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+	t.Setenv("ZALO_BOT_TIMEOUT", "1m")
+	t.Setenv("ZALO_BOT_ENVIRONMENT", "Production")
+	t.Setenv("ZALO_BOT_DEBUG", "true")
+	t.Setenv("ZALO_BOT_LOG_LEVEL", "WARN")
+	t.Setenv("ZALO_BOT_RETRY_MAX_RETRIES", "0")
+	t.Setenv("ZALO_BOT_WEBHOOK_URL", "https://example.com/hook")
+	t.Setenv("ZALO_BOT_WEBHOOK_SECRET", "env-secret")
+
+	settings, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if settings.BotToken != "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11" {
+		t.Errorf("BotToken = %v", settings.BotToken)
+	}
+	if time.Duration(settings.Timeout) != time.Minute {
+		t.Errorf("Timeout = %v, want 1m", settings.Timeout)
+	}
+	if settings.Environment != Production {
+		t.Errorf("Environment = %v, want production", settings.Environment)
+	}
+	if !settings.Debug || settings.LogLevel != LogLevelWarn || settings.Retry.MaxRetries != 0 {
+		t.Errorf("Debug/LogLevel/MaxRetries = %v/%v/%v", settings.Debug, settings.LogLevel, settings.Retry.MaxRetries)
+	}
+	if settings.Webhook.URL != "https://example.com/hook" || settings.Webhook.SecretToken != "env-secret" {
+		t.Errorf("Webhook = %+v", settings.Webhook)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		wantErr []string
+	}{
+		{
+			name:    "invalid field values",
+			file:    "timeout: -1s\nenvironment: staging\nretry:\n  backoff_factor: 0.5\n",
+			wantErr: []string{"timeout:", "environment:", "retry.backoff_factor:"},
+		},
+		{
+			name:    "log level without debug",
+			file:    "log_level: warn\n",
+			wantErr: []string{"log_level: requires debug"},
+		},
+		{
+			name:    "invalid log level in file",
+			file:    "log_level: trace\n",
+			wantErr: []string{`invalid log level "trace"`},
+		},
+		{
+			name:    "webhook must be https",
+			file:    "webhook:\n  url: http://example.com/hook\n",
+			wantErr: []string{"webhook.url:"},
+		},
+		{
+			name:    "max delay below initial delay",
+			file:    "retry:\n  initial_delay: 10s\n  max_delay: 1s\n",
+			wantErr: []string{"retry.max_delay:"},
+		},
+		{
+			name:    "unknown key in file",
+			file:    "retires: 5\n",
+			wantErr: []string{"retires"},
+		},
+		{
+			name:    "unknown nested key in file",
+			file:    "webhook:\n  secert_token: s3cret\n",
+			wantErr: []string{"secert_token"},
+		},
+		{
+			name:    "invalid duration in file",
+			file:    "timeout: soon\n",
+			wantErr: []string{"invalid duration"},
+		},
+		{
+			name:    "invalid environment variables",
+			env:     map[string]string{"ZALO_BOT_TIMEOUT": "later", "ZALO_BOT_DEBUG": "maybe", "ZALO_BOT_LOG_LEVEL": "loud"},
+			wantErr: []string{"timeout (ZALO_BOT_TIMEOUT)", "debug (ZALO_BOT_DEBUG)", "log_level (ZALO_BOT_LOG_LEVEL)"},
+		},
+		{
+			name:    "invalid bot token",
+			env:     map[string]string{"ZALO_BOT_TOKEN": "not-a-token"},
+			wantErr: []string{"bot_token:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			path := ""
+			if tt.file != "" {
+				path = writeConfigFile(t, "bot.yaml", tt.file)
+			}
+
+			_, err := LoadConfig(path)
+			if err == nil {
+				t.Fatal("LoadConfig() expected error but got none")
+			}
+
+			zaloBotErr, ok := err.(*ZaloBotError)
+			if !ok || zaloBotErr.Type != ErrorTypeValidation {
+				t.Fatalf("LoadConfig() error = %v, want validation error", err)
+			}
+
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("LoadConfig() error = %q, want it to mention %q", err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig_MissingFile(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadConfig() expected error for missing file")
+	}
+}
+
+func TestSettings_Options(t *testing.T) {
+	settings := DefaultSettings()
+	settings.BotToken = "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	settings.Debug = true
+	settings.Environment = Development
+	settings.LogLevel = LogLevelDebug
+	settings.Retry.MaxRetries = 7
+	settings.Webhook.SecretToken = "loader-secret"
+
+	config := &Config{BotToken: settings.BotToken}
+	for _, opt := range settings.Options() {
+		opt(config)
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Config.Validate() error = %v", err)
+	}
+
+	if config.BaseURL != settings.BaseURL || config.Timeout != time.Duration(settings.Timeout) {
+		t.Errorf("BaseURL/Timeout = %v/%v", config.BaseURL, config.Timeout)
+	}
+	if !config.Debug || config.Environment != Development || config.LogLevel != LogLevelDebug {
+		t.Errorf("Debug/Environment/LogLevel = %v/%v/%v", config.Debug, config.Environment, config.LogLevel)
+	}
+	if config.RetryConfig == nil || config.RetryConfig.MaxRetries != 7 || config.Retries != 7 {
+		t.Errorf("RetryConfig = %+v, Retries = %d", config.RetryConfig, config.Retries)
+	}
+	if config.WebhookSecret != "loader-secret" {
+		t.Errorf("WebhookSecret = %q, want loader-secret", config.WebhookSecret)
+	}
+}
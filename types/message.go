@@ -144,8 +144,15 @@ type Attachment struct {
 type ChatActionType string
 
 const (
-	ChatActionTyping      ChatActionType = "typing"
-	ChatActionUploadPhoto ChatActionType = "upload_photo"
+	ChatActionTyping         ChatActionType = "typing"
+	ChatActionUploadPhoto    ChatActionType = "upload_photo"
+	ChatActionRecordVideo    ChatActionType = "record_video"
+	ChatActionUploadVideo    ChatActionType = "upload_video"
+	ChatActionRecordVoice    ChatActionType = "record_voice"
+	ChatActionUploadVoice    ChatActionType = "upload_voice"
+	ChatActionUploadDocument ChatActionType = "upload_document"
+	ChatActionChooseSticker  ChatActionType = "choose_sticker"
+	ChatActionFindLocation   ChatActionType = "find_location"
 )
 
 // AttachmentType represents the type of attachment
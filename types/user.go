@@ -10,6 +10,30 @@ type User struct {
 	IsBot  bool   `json:"is_bot"`
 }
 
+// BotInfo represents the identity of the bot returned by getMe
+type BotInfo struct {
+	ID            string `json:"id"`
+	AccountName   string `json:"account_name"`
+	AccountType   string `json:"account_type,omitempty"`
+	Name          string `json:"display_name,omitempty"`
+	Avatar        string `json:"avatar,omitempty"`
+	CanJoinGroups bool   `json:"can_join_groups"`
+}
+
+// User returns the bot as a message sender
+func (b *BotInfo) User() *User {
+	name := b.Name
+	if name == "" {
+		name = b.AccountName
+	}
+	return &User{
+		ID:     b.ID,
+		Name:   name,
+		Avatar: b.Avatar,
+		IsBot:  true,
+	}
+}
+
 // Chat represents a chat in Zalo Bot
 type Chat struct {
 	ID   string   `json:"id"`
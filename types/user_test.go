@@ -142,3 +142,17 @@ func TestChat_JSON(t *testing.T) {
 		t.Errorf("Chat.Type = %v, want %v", unmarshaled.Type, chat.Type)
 	}
 }
+
+func TestBotInfo_User(t *testing.T) {
+	info := &BotInfo{ID: "bot1", AccountName: "bot.test", Avatar: "https://example.com/bot.jpg"}
+
+	user := info.User()
+	if user.ID != "bot1" || user.Name != "bot.test" || user.Avatar != info.Avatar || !user.IsBot {
+		t.Errorf("User() = %+v", user)
+	}
+
+	info.Name = "Test Bot"
+	if got := info.User().Name; got != "Test Bot" {
+		t.Errorf("User().Name = %v, want display name", got)
+	}
+}
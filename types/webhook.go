@@ -2,6 +2,7 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -111,3 +112,24 @@ func ParseWebhookPayload(data []byte) (*WebhookPayload, error) {
 	}
 	return &payload, nil
 }
+
+// ParseWebhookUpdate parses a raw webhook request body into an Update
+func ParseWebhookUpdate(data []byte) (*Update, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty webhook payload")
+	}
+
+	payload, err := ParseWebhookPayload(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+
+	if payload.Result.EventName == "" {
+		return nil, fmt.Errorf("webhook payload result is empty or missing event_name")
+	}
+
+	return &Update{
+		EventName: payload.Result.EventName,
+		Message:   payload.Result.Message,
+	}, nil
+}
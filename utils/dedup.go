@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// ExpiringSet is a bounded set of keys that are forgotten after a TTL. When
+// full, the oldest key is evicted. It is safe for concurrent use.
+type ExpiringSet struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu    sync.Mutex
+	order *list.List // of *expiringEntry, oldest first
+	keys  map[string]*list.Element
+}
+
+// expiringEntry is a key with the time it expires
+type expiringEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// NewExpiringSet creates a set remembering keys for ttl, holding at most
+// maxEntries keys (0 means unbounded)
+func NewExpiringSet(maxEntries int, ttl time.Duration) *ExpiringSet {
+	return &ExpiringSet{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		keys:       make(map[string]*list.Element),
+	}
+}
+
+// Add records key and reports whether it was not already in the set
+func (s *ExpiringSet) Add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.expire(now)
+
+	if _, ok := s.keys[key]; ok {
+		return false
+	}
+
+	for s.maxEntries > 0 && s.order.Len() >= s.maxEntries {
+		s.remove(s.order.Front())
+	}
+	s.keys[key] = s.order.PushBack(&expiringEntry{key: key, expiresAt: now.Add(s.ttl)})
+	return true
+}
+
+// Contains reports whether key is in the set
+func (s *ExpiringSet) Contains(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(s.now())
+	_, ok := s.keys[key]
+	return ok
+}
+
+// Remove forgets key
+func (s *ExpiringSet) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.keys[key]; ok {
+		s.remove(element)
+	}
+}
+
+// Len returns the number of keys in the set
+func (s *ExpiringSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(s.now())
+	return s.order.Len()
+}
+
+// expire removes keys that expired at now. Keys share one TTL, so they
+// expire in insertion order.
+func (s *ExpiringSet) expire(now time.Time) {
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		if element.Value.(*expiringEntry).expiresAt.After(now) {
+			return
+		}
+		s.remove(element)
+	}
+}
+
+// remove deletes element from the list and the index
+func (s *ExpiringSet) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.keys, element.Value.(*expiringEntry).key)
+}
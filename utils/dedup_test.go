@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExpiringSet(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	set := NewExpiringSet(3, time.Minute)
+	set.now = func() time.Time { return now }
+
+	if !set.Add("a") {
+		t.Fatal("Add(a) = false, want true for a new key")
+	}
+	if set.Add("a") {
+		t.Fatal("Add(a) = true, want false for a known key")
+	}
+
+	t.Run("expires after TTL", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if set.Contains("a") {
+			t.Error("key should expire after the TTL")
+		}
+		if !set.Add("a") {
+			t.Error("an expired key should be added again")
+		}
+	})
+
+	t.Run("evicts the oldest key when full", func(t *testing.T) {
+		set.Add("b")
+		set.Add("c")
+		set.Add("d")
+		if set.Contains("a") {
+			t.Error("oldest key should be evicted")
+		}
+		if set.Len() != 3 {
+			t.Errorf("Len() = %d, want 3", set.Len())
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		set.Remove("d")
+		if set.Contains("d") || !set.Add("d") {
+			t.Error("a removed key should be added again")
+		}
+	})
+}
+
+func TestExpiringSet_Concurrent(t *testing.T) {
+	set := NewExpiringSet(0, time.Minute)
+
+	var added atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if set.Add(fmt.Sprintf("key-%d", j)) {
+					added.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if added.Load() != 100 {
+		t.Errorf("%d keys added, want each of 100 keys added once", added.Load())
+	}
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// diskQueueHeaderSize is the size of the file header holding the offset of
+// the first unread record
+const diskQueueHeaderSize = 8
+
+// maxDiskQueueRecord bounds the size of one record, so a corrupted length
+// prefix is detected instead of allocating a huge buffer
+const maxDiskQueueRecord = 64 << 20
+
+// DiskQueue is a FIFO queue of byte records stored in a single file, used
+// to spill updates a consumer cannot keep up with. Records are written with
+// a length prefix after an 8-byte header holding the read position, so a
+// reopened queue resumes from the first record not yet popped. Writes are
+// not synced, so records survive a process restart but not a power loss.
+// The file is truncated whenever the queue becomes empty. DiskQueue is safe
+// for concurrent use.
+type DiskQueue struct {
+	mu    sync.Mutex
+	file  *os.File
+	head  int64 // offset of the first unread record
+	tail  int64 // offset after the last complete record
+	count int
+}
+
+// NewDiskQueue opens the queue stored at path, creating the file and its
+// directory if needed
+func NewDiskQueue(path string) (*DiskQueue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &DiskQueue{file: file}
+	if err := q.restore(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("disk queue %s: %w", path, err)
+	}
+	return q, nil
+}
+
+// restore reads the header and counts the records left in the file,
+// discarding a partial record written before a crash
+func (q *DiskQueue) restore() error {
+	info, err := q.file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if size < diskQueueHeaderSize {
+		return q.reset()
+	}
+
+	var header [diskQueueHeaderSize]byte
+	if _, err := q.file.ReadAt(header[:], 0); err != nil {
+		return err
+	}
+	head := int64(binary.BigEndian.Uint64(header[:]))
+	if head < diskQueueHeaderSize || head > size {
+		return fmt.Errorf("invalid read offset %d", head)
+	}
+
+	offset, count := head, 0
+	var prefix [4]byte
+	for offset+4 <= size {
+		if _, err := q.file.ReadAt(prefix[:], offset); err != nil {
+			return err
+		}
+		n := int64(binary.BigEndian.Uint32(prefix[:]))
+		if n > maxDiskQueueRecord || offset+4+n > size {
+			break
+		}
+		offset += 4 + n
+		count++
+	}
+
+	if count == 0 {
+		return q.reset()
+	}
+	if offset < size {
+		if err := q.file.Truncate(offset); err != nil {
+			return err
+		}
+	}
+	q.head, q.tail, q.count = head, offset, count
+	return nil
+}
+
+// reset empties the file
+func (q *DiskQueue) reset() error {
+	if err := q.file.Truncate(0); err != nil {
+		return err
+	}
+	q.head, q.tail, q.count = diskQueueHeaderSize, diskQueueHeaderSize, 0
+	return q.writeHeader()
+}
+
+// writeHeader stores the read position
+func (q *DiskQueue) writeHeader() error {
+	var header [diskQueueHeaderSize]byte
+	binary.BigEndian.PutUint64(header[:], uint64(q.head))
+	_, err := q.file.WriteAt(header[:], 0)
+	return err
+}
+
+// Push appends a record to the queue
+func (q *DiskQueue) Push(data []byte) error {
+	if len(data) > maxDiskQueueRecord {
+		return fmt.Errorf("disk queue record of %d bytes exceeds the %d byte limit", len(data), maxDiskQueueRecord)
+	}
+
+	record := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	copy(record[4:], data)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, err := q.file.WriteAt(record, q.tail); err != nil {
+		return err
+	}
+	q.tail += int64(len(record))
+	q.count++
+	return nil
+}
+
+// Peek returns the first record without removing it. ok is false if the
+// queue is empty.
+func (q *DiskQueue) Peek() (data []byte, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	data, _, err = q.peek()
+	return data, data != nil, err
+}
+
+// Pop removes and returns the first record. ok is false if the queue is
+// empty.
+func (q *DiskQueue) Pop() (data []byte, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data, size, err := q.peek()
+	if data == nil || err != nil {
+		return nil, false, err
+	}
+
+	q.count--
+	if q.count == 0 {
+		return data, true, q.reset()
+	}
+	q.head += size
+	return data, true, q.writeHeader()
+}
+
+// peek reads the first record and its size on disk; data is nil if the
+// queue is empty
+func (q *DiskQueue) peek() ([]byte, int64, error) {
+	if q.count == 0 {
+		return nil, 0, nil
+	}
+
+	var prefix [4]byte
+	if _, err := q.file.ReadAt(prefix[:], q.head); err != nil {
+		return nil, 0, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(prefix[:]))
+	if _, err := q.file.ReadAt(data, q.head+4); err != nil {
+		return nil, 0, err
+	}
+	return data, int64(4 + len(data)), nil
+}
+
+// Len returns the number of records in the queue
+func (q *DiskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.count
+}
+
+// Close closes the queue file, keeping any records for the next open
+func (q *DiskQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.file.Close()
+}
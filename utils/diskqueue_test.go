@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill", "updates.queue")
+	q, err := NewDiskQueue(path)
+	if err != nil {
+		t.Fatalf("NewDiskQueue() error = %v", err)
+	}
+
+	for _, record := range []string{"one", "two", "three"} {
+		if err := q.Push([]byte(record)); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	if data, ok, err := q.Peek(); err != nil || !ok || string(data) != "one" {
+		t.Fatalf("Peek() = %q, %v, %v, want one", data, ok, err)
+	}
+	if data, ok, err := q.Pop(); err != nil || !ok || string(data) != "one" {
+		t.Fatalf("Pop() = %q, %v, %v, want one", data, ok, err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Simulate a crash in the middle of writing a record
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte{0, 0, 0, 9, 'p'})
+	file.Close()
+
+	q, err = NewDiskQueue(path)
+	if err != nil {
+		t.Fatalf("NewDiskQueue() reopen error = %v", err)
+	}
+	defer q.Close()
+	if q.Len() != 2 {
+		t.Fatalf("Len() after reopen = %d, want 2", q.Len())
+	}
+
+	q.Push([]byte("four"))
+	var got []string
+	for {
+		data, ok, err := q.Pop()
+		if err != nil {
+			t.Fatalf("Pop() error = %v", err)
+		}
+		if !ok {
+			break
+		}
+		got = append(got, string(data))
+	}
+	if len(got) != 3 || got[0] != "two" || got[1] != "three" || got[2] != "four" {
+		t.Errorf("popped %q, want [two three four]", got)
+	}
+
+	// A drained queue releases its disk space
+	if info, err := os.Stat(path); err != nil || info.Size() != diskQueueHeaderSize {
+		t.Errorf("drained queue file size = %v, %v, want %d", info.Size(), err, diskQueueHeaderSize)
+	}
+}
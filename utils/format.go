@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vietnameseWeekdays are the weekday names used by FormatDateLongVI
+var vietnameseWeekdays = [...]string{"Chủ Nhật", "Thứ Hai", "Thứ Ba", "Thứ Tư", "Thứ Năm", "Thứ Sáu", "Thứ Bảy"}
+
+// FormatNumberVI formats n with Vietnamese separators, a dot between
+// thousands and a comma before decimals: 1234567.5 with one decimal is
+// "1.234.567,5"
+func FormatNumberVI(n float64, decimals int) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+	s := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	result := groupThousands(intPart)
+	if fracPart != "" {
+		result += "," + fracPart
+	}
+	if n < 0 && strings.Trim(s, "0.") != "" {
+		result = "-" + result
+	}
+	return result
+}
+
+// FormatVND formats an amount of đồng: 1500000 is "1.500.000 ₫"
+func FormatVND(amount int64) string {
+	digits := strconv.FormatInt(amount, 10)
+	if amount < 0 {
+		return "-" + groupThousands(digits[1:]) + " ₫"
+	}
+	return groupThousands(digits) + " ₫"
+}
+
+// groupThousands inserts a dot between every three digits
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// FormatDateVI formats a date the Vietnamese way, day first: "02/01/2006"
+func FormatDateVI(t time.Time) string {
+	return t.Format("02/01/2006")
+}
+
+// FormatDateTimeVI formats a time followed by its date: "15:04 02/01/2006"
+func FormatDateTimeVI(t time.Time) string {
+	return t.Format("15:04 02/01/2006")
+}
+
+// FormatDateLongVI spells out a date in Vietnamese:
+// "Thứ Hai, ngày 2 tháng 1 năm 2006"
+func FormatDateLongVI(t time.Time) string {
+	return fmt.Sprintf("%s, ngày %d tháng %d năm %d", vietnameseWeekdays[t.Weekday()], t.Day(), int(t.Month()), t.Year())
+}
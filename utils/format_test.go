@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatNumberVI(t *testing.T) {
+	tests := []struct {
+		n        float64
+		decimals int
+		want     string
+	}{
+		{0, 0, "0"},
+		{999, 0, "999"},
+		{1000, 0, "1.000"},
+		{1234567.5, 1, "1.234.567,5"},
+		{1234567.456, 2, "1.234.567,46"},
+		{-98765, 0, "-98.765"},
+		{-0.001, 2, "0,00"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatNumberVI(tt.n, tt.decimals); got != tt.want {
+			t.Errorf("FormatNumberVI(%v, %d) = %q, want %q", tt.n, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestFormatVND(t *testing.T) {
+	tests := map[int64]string{
+		0:          "0 ₫",
+		500:        "500 ₫",
+		1500000:    "1.500.000 ₫",
+		-25000:     "-25.000 ₫",
+		1000000000: "1.000.000.000 ₫",
+	}
+
+	for amount, want := range tests {
+		if got := FormatVND(amount); got != want {
+			t.Errorf("FormatVND(%d) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestFormatDateVI(t *testing.T) {
+	date := time.Date(2024, time.September, 2, 8, 5, 0, 0, time.UTC)
+
+	if got := FormatDateVI(date); got != "02/09/2024" {
+		t.Errorf("FormatDateVI() = %q", got)
+	}
+	if got := FormatDateTimeVI(date); got != "08:05 02/09/2024" {
+		t.Errorf("FormatDateTimeVI() = %q", got)
+	}
+	if got := FormatDateLongVI(date); got != "Thứ Hai, ngày 2 tháng 9 năm 2024" {
+		t.Errorf("FormatDateLongVI() = %q", got)
+	}
+}
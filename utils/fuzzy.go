@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Levenshtein returns the number of single-rune insertions, deletions and
+// substitutions needed to turn a into b
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+
+	// Two rows of the distance matrix, sized by the shorter string
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Similarity scores how alike a and b are, from 0 (nothing in common) to 1
+// (equal), as one minus their Levenshtein distance relative to the longer
+// string
+func Similarity(a, b string) float64 {
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(longest)
+}
+
+// PhraseSimilarity scores how well phrase occurs in text, comparing it with
+// the whole text and with every run of words about as long as phrase, so
+// that "cua hang mo cua may gio" scores high against "cho hoi cua hang mo
+// cua may gio a". Both should be normalized with NormalizeForMatch first.
+func PhraseSimilarity(text, phrase string) float64 {
+	best := Similarity(text, phrase)
+
+	words := strings.Fields(text)
+	n := len(strings.Fields(phrase))
+	for size := max(n-1, 1); size <= n+1; size++ {
+		for start := 0; start+size <= len(words); start++ {
+			if score := Similarity(strings.Join(words[start:start+size], " "), phrase); score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
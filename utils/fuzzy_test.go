@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"gio mo cua", "gio mo cua", 0},
+		{"gio mo cua", "gio mo cuar", 1},
+		{"giờ", "gio", 1},
+		{"đơn", "don", 2},
+		{"flaw", "lawn", 2},
+	}
+
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "abc", 1},
+		{"abc", "xyz", 0},
+		{"phi ship", "phi shop", 0.875},
+		{"abcd", "ab", 0.5},
+	}
+
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPhraseSimilarity(t *testing.T) {
+	phrase := "cua hang mo cua may gio"
+
+	tests := []struct {
+		text string
+		min  float64
+		max  float64
+	}{
+		{"cua hang mo cua may gio", 1, 1},
+		{"cho hoi cua hang mo cua may gio a", 1, 1},
+		{"cho hoi cua hag mo cua mays gio", 0.85, 0.99},
+		{"cua hang mo cua luc may gio", 0.8, 0.99},
+		{"phi giao hang bao nhieu", 0, 0.5},
+	}
+
+	for _, tt := range tests {
+		got := PhraseSimilarity(tt.text, phrase)
+		if got < tt.min || got > tt.max {
+			t.Errorf("PhraseSimilarity(%q) = %v, want between %v and %v", tt.text, got, tt.min, tt.max)
+		}
+	}
+}
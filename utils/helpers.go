@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -384,3 +386,13 @@ func SanitizeText(text string) string {
 func IsEmptyOrWhitespace(text string) bool {
 	return strings.TrimSpace(text) == ""
 }
+
+// GenerateSecretToken returns a cryptographically random webhook secret token
+// of 43 URL-safe characters (256 bits)
+func GenerateSecretToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -701,3 +701,26 @@ func TestIsEmptyOrWhitespace(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateSecretToken(t *testing.T) {
+	first, err := GenerateSecretToken()
+	if err != nil {
+		t.Fatalf("GenerateSecretToken() error = %v", err)
+	}
+	second, err := GenerateSecretToken()
+	if err != nil {
+		t.Fatalf("GenerateSecretToken() error = %v", err)
+	}
+
+	if len(first) != 43 {
+		t.Errorf("len(GenerateSecretToken()) = %d, want 43", len(first))
+	}
+	if first == second {
+		t.Error("GenerateSecretToken() returned the same token twice")
+	}
+	for _, r := range first {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			t.Errorf("GenerateSecretToken() contains non URL-safe character %q", r)
+		}
+	}
+}
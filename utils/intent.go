@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// intentModelVersion is the format version of saved intent models
+const intentModelVersion = 1
+
+// minKnownWordShare is the share of a message's words that must have been
+// seen in training for Classify to predict an intent
+const minKnownWordShare = 0.5
+
+// IntentPrediction is an intent with the classifier's confidence in it
+type IntentPrediction struct {
+	Intent     string  `json:"intent"`
+	Confidence float64 `json:"confidence"`
+}
+
+// intentStats holds the training counts of one intent
+type intentStats struct {
+	Examples int            `json:"examples"`
+	Tokens   int            `json:"tokens"`
+	Counts   map[string]int `json:"counts"`
+}
+
+// intentModelFile is the layout of a saved model
+type intentModelFile struct {
+	Version int                     `json:"version"`
+	Intents map[string]*intentStats `json:"intents"`
+}
+
+// IntentClassifier is a multinomial naive Bayes classifier that tells
+// which intent a message expresses, trained from example utterances. It
+// runs in-process and is safe for concurrent use once trained.
+type IntentClassifier struct {
+	intents    map[string]*intentStats
+	names      []string
+	vocabulary map[string]bool
+	examples   int
+}
+
+// IntentTokens splits text into the features the classifier uses: its
+// words, without case, accents or punctuation, and each pair of adjacent
+// words, since Vietnamese words often span two syllables ("đơn hàng")
+func IntentTokens(text string) []string {
+	return intentTokens(strings.Fields(NormalizeForMatch(text)))
+}
+
+// intentTokens returns words and their adjacent pairs
+func intentTokens(words []string) []string {
+	tokens := make([]string, 0, 2*len(words))
+	tokens = append(tokens, words...)
+	for i := 1; i < len(words); i++ {
+		tokens = append(tokens, words[i-1]+"_"+words[i])
+	}
+	return tokens
+}
+
+// ReadIntentExamples reads labeled utterances from YAML, a list of
+// examples per intent:
+//
+//	order_status:
+//	  - đơn hàng của tôi đâu rồi
+//	  - where is my order
+//	greeting:
+//	  - xin chào
+//	  - hello
+func ReadIntentExamples(r io.Reader) (map[string][]string, error) {
+	var examples map[string][]string
+	if err := yaml.NewDecoder(r).Decode(&examples); err != nil {
+		return nil, fmt.Errorf("intent examples: %w", err)
+	}
+	return examples, nil
+}
+
+// LoadIntentExamples reads labeled utterances from a YAML file, as
+// ReadIntentExamples
+func LoadIntentExamples(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	examples, err := ReadIntentExamples(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return examples, nil
+}
+
+// TrainIntentClassifier trains a classifier on example utterances by
+// intent. It needs at least two intents, each with an example that has a
+// word in it.
+func TrainIntentClassifier(examples map[string][]string) (*IntentClassifier, error) {
+	intents := make(map[string]*intentStats, len(examples))
+	for intent, utterances := range examples {
+		if intent == "" {
+			return nil, fmt.Errorf("intent examples have an empty intent name")
+		}
+		stats := &intentStats{Counts: make(map[string]int)}
+		for _, utterance := range utterances {
+			tokens := IntentTokens(utterance)
+			if len(tokens) == 0 {
+				continue
+			}
+			stats.Examples++
+			stats.Tokens += len(tokens)
+			for _, token := range tokens {
+				stats.Counts[token]++
+			}
+		}
+		if stats.Examples == 0 {
+			return nil, fmt.Errorf("intent %s has no usable examples", intent)
+		}
+		intents[intent] = stats
+	}
+	if len(intents) < 2 {
+		return nil, fmt.Errorf("at least two intents are needed, got %d", len(intents))
+	}
+	return newIntentClassifier(intents), nil
+}
+
+// newIntentClassifier builds a classifier from training counts
+func newIntentClassifier(intents map[string]*intentStats) *IntentClassifier {
+	c := &IntentClassifier{intents: intents, vocabulary: make(map[string]bool)}
+	for name, stats := range intents {
+		c.names = append(c.names, name)
+		c.examples += stats.Examples
+		for token := range stats.Counts {
+			c.vocabulary[token] = true
+		}
+	}
+	sort.Strings(c.names)
+	return c
+}
+
+// Intents returns the intents the classifier knows, sorted
+func (c *IntentClassifier) Intents() []string {
+	return append([]string(nil), c.names...)
+}
+
+// Classify returns every intent with its confidence, from most to least
+// likely; the confidences add up to 1. It returns nil if fewer than half
+// of the words in text were seen in training, as a message that only
+// shares a word or two with the examples is most likely about something
+// else.
+func (c *IntentClassifier) Classify(text string) []IntentPrediction {
+	words := strings.Fields(NormalizeForMatch(text))
+	known := 0
+	for _, word := range words {
+		if c.vocabulary[word] {
+			known++
+		}
+	}
+	if known == 0 || float64(known) < minKnownWordShare*float64(len(words)) {
+		return nil
+	}
+
+	var tokens []string
+	for _, token := range intentTokens(words) {
+		if c.vocabulary[token] {
+			tokens = append(tokens, token)
+		}
+	}
+
+	// Log posteriors with add-one smoothing
+	predictions := make([]IntentPrediction, 0, len(c.intents))
+	maxScore := math.Inf(-1)
+	vocabulary := float64(len(c.vocabulary))
+	for _, name := range c.names {
+		stats := c.intents[name]
+		score := math.Log(float64(stats.Examples) / float64(c.examples))
+		for _, token := range tokens {
+			score += math.Log((float64(stats.Counts[token]) + 1) / (float64(stats.Tokens) + vocabulary))
+		}
+		predictions = append(predictions, IntentPrediction{Intent: name, Confidence: score})
+		maxScore = math.Max(maxScore, score)
+	}
+
+	// Normalize into probabilities, shifted to avoid underflow
+	total := 0.0
+	for i := range predictions {
+		predictions[i].Confidence = math.Exp(predictions[i].Confidence - maxScore)
+		total += predictions[i].Confidence
+	}
+	for i := range predictions {
+		predictions[i].Confidence /= total
+	}
+
+	sort.Slice(predictions, func(i, j int) bool {
+		if predictions[i].Confidence != predictions[j].Confidence {
+			return predictions[i].Confidence > predictions[j].Confidence
+		}
+		return predictions[i].Intent < predictions[j].Intent
+	})
+	return predictions
+}
+
+// WriteTo writes the model as JSON
+func (c *IntentClassifier) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(intentModelFile{Version: intentModelVersion, Intents: c.intents})
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// Save writes the model to a file, replacing it atomically so a running
+// bot never loads a partial model
+func (c *IntentClassifier) Save(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := c.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadIntentClassifier reads a model written by WriteTo
+func ReadIntentClassifier(r io.Reader) (*IntentClassifier, error) {
+	var file intentModelFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("intent model: %w", err)
+	}
+	if file.Version != intentModelVersion {
+		return nil, fmt.Errorf("intent model version %d is not supported", file.Version)
+	}
+	if len(file.Intents) < 2 {
+		return nil, fmt.Errorf("intent model has %d intents, at least two are needed", len(file.Intents))
+	}
+	for name, stats := range file.Intents {
+		if stats == nil || stats.Examples <= 0 || stats.Tokens <= 0 {
+			return nil, fmt.Errorf("intent model has no training data for %s", name)
+		}
+	}
+	return newIntentClassifier(file.Intents), nil
+}
+
+// LoadIntentClassifier reads a model saved with Save
+func LoadIntentClassifier(path string) (*IntentClassifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	classifier, err := ReadIntentClassifier(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return classifier, nil
+}
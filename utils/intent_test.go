@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"bytes"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testIntentExamples = `
+order_status:
+  - đơn hàng của tôi đâu rồi
+  - kiểm tra đơn hàng giúp mình
+  - bao giờ đơn hàng được giao
+  - tình trạng đơn hàng
+  - where is my order
+  - check my order status
+  - has my order shipped
+greeting:
+  - xin chào
+  - chào shop
+  - chào bạn nhé
+  - hello
+  - hi there
+  - good morning
+price:
+  - giá bao nhiêu
+  - cái này bao nhiêu tiền
+  - cho mình hỏi giá
+  - how much is this
+  - what is the price
+  - price list please
+`
+
+func trainTestIntents(t *testing.T) *IntentClassifier {
+	t.Helper()
+
+	examples, err := ReadIntentExamples(strings.NewReader(testIntentExamples))
+	if err != nil {
+		t.Fatalf("ReadIntentExamples() error = %v", err)
+	}
+	classifier, err := TrainIntentClassifier(examples)
+	if err != nil {
+		t.Fatalf("TrainIntentClassifier() error = %v", err)
+	}
+	return classifier
+}
+
+func TestIntentTokens(t *testing.T) {
+	got := IntentTokens("Đơn hàng, của TÔI?")
+	want := []string{"don", "hang", "cua", "toi", "don_hang", "hang_cua", "cua_toi"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IntentTokens() = %v, want %v", got, want)
+	}
+	if got := IntentTokens("?!"); len(got) != 0 {
+		t.Errorf("IntentTokens(?!) = %v, want none", got)
+	}
+}
+
+func TestIntentClassifier_Classify(t *testing.T) {
+	classifier := trainTestIntents(t)
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Đơn hàng của mình đâu rồi shop?", "order_status"},
+		{"don hang cua toi dau", "order_status"},
+		{"where is my order??", "order_status"},
+		{"Chào shop ạ", "greeting"},
+		{"hello!", "greeting"},
+		{"Giá cái này bao nhiêu vậy", "price"},
+		{"how much", "price"},
+	}
+
+	for _, tt := range tests {
+		predictions := classifier.Classify(tt.text)
+		if len(predictions) != 3 {
+			t.Fatalf("Classify(%q) = %v, want 3 intents", tt.text, predictions)
+		}
+		if predictions[0].Intent != tt.want {
+			t.Errorf("Classify(%q) = %v, want %s first", tt.text, predictions, tt.want)
+		}
+		if predictions[0].Confidence < 0.5 {
+			t.Errorf("Classify(%q) confidence = %v, want at least 0.5", tt.text, predictions[0].Confidence)
+		}
+
+		total := 0.0
+		for i, prediction := range predictions {
+			total += prediction.Confidence
+			if i > 0 && prediction.Confidence > predictions[i-1].Confidence {
+				t.Errorf("Classify(%q) = %v, not sorted", tt.text, predictions)
+			}
+		}
+		if math.Abs(total-1) > 1e-9 {
+			t.Errorf("Classify(%q) confidences add up to %v", tt.text, total)
+		}
+	}
+
+	for _, text := range []string{"zzz qqq", "I want to order a pizza for dinner tonight", "thời tiết hôm nay thế nào hả shop"} {
+		if predictions := classifier.Classify(text); predictions != nil {
+			t.Errorf("Classify(%q) = %v, want nil for off-topic text", text, predictions)
+		}
+	}
+}
+
+func TestIntentClassifier_SaveLoad(t *testing.T) {
+	classifier := trainTestIntents(t)
+
+	path := filepath.Join(t.TempDir(), "intents.json")
+	if err := classifier.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadIntentClassifier(path)
+	if err != nil {
+		t.Fatalf("LoadIntentClassifier() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded.Intents(), []string{"greeting", "order_status", "price"}) {
+		t.Errorf("Intents() = %v", loaded.Intents())
+	}
+	for _, text := range []string{"đơn hàng đâu", "xin chào", "giá bao nhiêu"} {
+		if got, want := loaded.Classify(text), classifier.Classify(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("loaded Classify(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestIntentClassifier_Errors(t *testing.T) {
+	trainErrors := map[string]map[string][]string{
+		"one intent":       {"greeting": {"hello"}},
+		"no usable sample": {"greeting": {"hello"}, "price": {"?!"}},
+		"empty name":       {"greeting": {"hello"}, "": {"price"}},
+	}
+	for name, examples := range trainErrors {
+		if _, err := TrainIntentClassifier(examples); err == nil {
+			t.Errorf("%s: TrainIntentClassifier() error = nil", name)
+		}
+	}
+
+	readErrors := map[string]string{
+		"not json":      "intents",
+		"wrong version": `{"version":2,"intents":{}}`,
+		"one intent":    `{"version":1,"intents":{"a":{"examples":1,"tokens":1,"counts":{"x":1}}}}`,
+		"no data":       `{"version":1,"intents":{"a":{"examples":1,"tokens":1,"counts":{"x":1}},"b":{}}}`,
+	}
+	for name, data := range readErrors {
+		if _, err := ReadIntentClassifier(bytes.NewReader([]byte(data))); err == nil {
+			t.Errorf("%s: ReadIntentClassifier() error = nil", name)
+		}
+	}
+
+	if _, err := ReadIntentExamples(strings.NewReader("- not a map")); err == nil {
+		t.Error("ReadIntentExamples(list) error = nil")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,6 +25,28 @@ const (
 	LogLevelError
 )
 
+// ParseLogLevel parses a level name case-insensitively; "warning" is accepted
+// as an alias for "warn"
+func ParseLogLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LogLevelDebug, nil
+	case "info":
+		return LogLevelInfo, nil
+	case "warn", "warning":
+		return LogLevelWarn, nil
+	case "error":
+		return LogLevelError, nil
+	default:
+		return LogLevelDebug, fmt.Errorf("invalid log level %q: must be one of debug, info, warn, error", name)
+	}
+}
+
+// IsValid validates the log level
+func (l LogLevel) IsValid() bool {
+	return l >= LogLevelDebug && l <= LogLevelError
+}
+
 // String returns the string representation of LogLevel
 func (l LogLevel) String() string {
 	switch l {
@@ -39,6 +63,26 @@ func (l LogLevel) String() string {
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler, writing the lowercase level
+// name used in configuration files
+func (l LogLevel) MarshalText() ([]byte, error) {
+	if !l.IsValid() {
+		return nil, fmt.Errorf("invalid log level %d", int(l))
+	}
+	return []byte(strings.ToLower(l.String())), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing the level name
+// as ParseLogLevel does
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLogLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
 // LogFormat represents the log output format
 type LogFormat int
 
@@ -200,6 +244,27 @@ func (l *DefaultLogger) formatJSON(timestamp time.Time, level LogLevel, msg stri
 	return string(jsonBytes)
 }
 
+// botTokenPattern matches the bot token path segment of an API endpoint URL
+var botTokenPattern = regexp.MustCompile(`/bot[^/?]+`)
+
+// RedactURL replaces the bot token embedded in an API endpoint URL, as in
+// https://bot-api.zapps.me/bot${BOT_TOKEN}/method, with "***" so the URL is
+// safe to log
+func RedactURL(url string) string {
+	// Only the path may carry the token; skip the scheme and host so a host
+	// such as bot-api.zapps.me is left alone
+	pathStart := 0
+	if i := strings.Index(url, "://"); i >= 0 {
+		pathStart = i + len("://")
+		if j := strings.Index(url[pathStart:], "/"); j >= 0 {
+			pathStart += j
+		} else {
+			return url
+		}
+	}
+	return url[:pathStart] + botTokenPattern.ReplaceAllString(url[pathStart:], "/bot***")
+}
+
 // NoOpLogger is a logger that does nothing (for disabling logging)
 type NoOpLogger struct{}
 
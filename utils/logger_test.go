@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 )
@@ -388,3 +389,75 @@ func TestLogger_ConcurrentSetLevel(t *testing.T) {
 
 	// Should not panic
 }
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    LogLevel
+		wantErr bool
+	}{
+		{"debug", LogLevelDebug, false},
+		{" INFO ", LogLevelInfo, false},
+		{"Warning", LogLevelWarn, false},
+		{"error", LogLevelError, false},
+		{"trace", LogLevelDebug, true},
+		{"", LogLevelDebug, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLogLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLogLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLogLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogLevel_Text(t *testing.T) {
+	var config struct {
+		Level LogLevel `json:"level"`
+	}
+	if err := json.Unmarshal([]byte(`{"level":"WARN"}`), &config); err != nil || config.Level != LogLevelWarn {
+		t.Fatalf("Unmarshal() = %v, %v, want warn", config.Level, err)
+	}
+	data, err := json.Marshal(config)
+	if err != nil || string(data) != `{"level":"warn"}` {
+		t.Errorf("Marshal() = %s, %v", data, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"level":"loud"}`), &config); err == nil {
+		t.Error("Unmarshal(loud) error = nil")
+	}
+	if _, err := LogLevel(7).MarshalText(); err == nil {
+		t.Error("MarshalText(7) error = nil")
+	}
+}
+
+func TestLogger_UnsetLevelLogsEverything(t *testing.T) {
+	logger := NewLogger(LogConfig{Output: &bytes.Buffer{}})
+
+	if !logger.IsEnabled(LogLevelDebug) {
+		t.Error("Logger with no level should enable debug")
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://bot-api.zapps.me/bot123456:ABC-DEF/sendMessage", "https://bot-api.zapps.me/bot***/sendMessage"},
+		{"http://127.0.0.1:8080/bot123:abc/getUpdates?timeout=30", "http://127.0.0.1:8080/bot***/getUpdates?timeout=30"},
+		{"https://example.com/health", "https://example.com/health"},
+	}
+
+	for _, tt := range tests {
+		if got := RedactURL(tt.url); got != tt.want {
+			t.Errorf("RedactURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
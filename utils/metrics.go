@@ -0,0 +1,424 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcome labels reported for API requests and handler runs
+const (
+	// OutcomeSuccess is reported when a request or handler succeeds
+	OutcomeSuccess = "success"
+	// OutcomeError is reported when a handler fails or an error has no more specific type
+	OutcomeError = "error"
+)
+
+// Metrics interface defines the instrumentation hooks called by the SDK
+type Metrics interface {
+	// ObserveAPIRequest records a completed API call, including all retries
+	ObserveAPIRequest(method, outcome string, duration time.Duration)
+	// IncRetry records a retried API call attempt
+	IncRetry(method string)
+	// IncRateLimit records an API response that reported a rate limit
+	IncRateLimit(method string)
+	// IncUpdateReceived records an update received from a webhook or polling
+	IncUpdateReceived(source, eventName string)
+	// ObserveHandler records the execution time of an update handler
+	ObserveHandler(eventName, outcome string, duration time.Duration)
+	// ObservePollingLag records the delay between a message being sent and
+	// the bot receiving it through polling
+	ObservePollingLag(lag time.Duration)
+}
+
+// WebhookMetrics is an optional interface for Metrics implementations that
+// also record webhook HTTP requests per bot, as served by a webhook router
+type WebhookMetrics interface {
+	ObserveWebhookRequest(botKey string, statusCode int, duration time.Duration)
+}
+
+// DedupMetrics is an optional interface for Metrics implementations that
+// also count updates dropped as duplicates
+type DedupMetrics interface {
+	IncDuplicateUpdate(source, eventName string)
+}
+
+// QueueMetrics is an optional interface for Metrics implementations that
+// also report the update buffers of polling consumers
+type QueueMetrics interface {
+	// SetUpdateQueueDepth reports the updates waiting for a consumer,
+	// including any spilled to disk
+	SetUpdateQueueDepth(subscriber string, depth int)
+	// IncDroppedUpdate records an update a consumer did not receive because
+	// its buffer was full, by overflow policy or "spill_error"
+	IncDroppedUpdate(subscriber, reason string)
+}
+
+// NoOpMetrics is a Metrics implementation that does nothing
+type NoOpMetrics struct{}
+
+// NewNoOpMetrics creates a no-op metrics collector
+func NewNoOpMetrics() *NoOpMetrics {
+	return &NoOpMetrics{}
+}
+
+// ObserveAPIRequest does nothing
+func (m *NoOpMetrics) ObserveAPIRequest(method, outcome string, duration time.Duration) {}
+
+// IncRetry does nothing
+func (m *NoOpMetrics) IncRetry(method string) {}
+
+// IncRateLimit does nothing
+func (m *NoOpMetrics) IncRateLimit(method string) {}
+
+// IncUpdateReceived does nothing
+func (m *NoOpMetrics) IncUpdateReceived(source, eventName string) {}
+
+// ObserveHandler does nothing
+func (m *NoOpMetrics) ObserveHandler(eventName, outcome string, duration time.Duration) {}
+
+// ObservePollingLag does nothing
+func (m *NoOpMetrics) ObservePollingLag(lag time.Duration) {}
+
+// DefaultLatencyBuckets are the histogram buckets, in seconds, used for
+// request and handler latencies
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// DefaultLagBuckets are the histogram buckets, in seconds, used for polling lag
+var DefaultLagBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+// PrometheusMetrics is a dependency-free Metrics implementation that renders
+// the Prometheus text exposition format from its ServeHTTP method
+type PrometheusMetrics struct {
+	namespace string
+
+	apiRequests  *counterVec
+	apiDuration  *histogramVec
+	retries      *counterVec
+	rateLimits   *counterVec
+	updates      *counterVec
+	handlerTime  *histogramVec
+	pollingLag   *histogramVec
+	webhooks     *counterVec
+	webhookTime  *histogramVec
+	duplicates   *counterVec
+	queueDepth   *gaugeVec
+	dropped      *counterVec
+	collectorsMu sync.Mutex
+}
+
+// NewPrometheusMetrics creates a Prometheus metrics collector. Metric names
+// are prefixed with namespace (default: "zalobot").
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	if namespace == "" {
+		namespace = "zalobot"
+	}
+
+	return &PrometheusMetrics{
+		namespace:   namespace,
+		apiRequests: newCounterVec(namespace+"_api_requests_total", "Total Zalo Bot API requests by method and outcome.", "method", "outcome"),
+		apiDuration: newHistogramVec(namespace+"_api_request_duration_seconds", "Zalo Bot API request latency including retries.", DefaultLatencyBuckets, "method"),
+		retries:     newCounterVec(namespace+"_api_retries_total", "Total retried Zalo Bot API request attempts.", "method"),
+		rateLimits:  newCounterVec(namespace+"_rate_limit_hits_total", "Total Zalo Bot API responses reporting a rate limit.", "method"),
+		updates:     newCounterVec(namespace+"_updates_received_total", "Total updates received by source and event name.", "source", "event_name"),
+		handlerTime: newHistogramVec(namespace+"_handler_duration_seconds", "Update handler execution time.", DefaultLatencyBuckets, "event_name", "outcome"),
+		pollingLag:  newHistogramVec(namespace+"_polling_lag_seconds", "Delay between a message being sent and being received through polling.", DefaultLagBuckets),
+		webhooks:    newCounterVec(namespace+"_webhook_requests_total", "Total webhook HTTP requests by bot and status code.", "bot", "code"),
+		webhookTime: newHistogramVec(namespace+"_webhook_request_duration_seconds", "Webhook HTTP request handling time by bot.", DefaultLatencyBuckets, "bot"),
+		duplicates:  newCounterVec(namespace+"_duplicate_updates_total", "Total updates dropped as duplicates by source and event name.", "source", "event_name"),
+		queueDepth:  newGaugeVec(namespace+"_update_queue_depth", "Updates waiting for a polling consumer, including spilled updates.", "subscriber"),
+		dropped:     newCounterVec(namespace+"_dropped_updates_total", "Total updates dropped because a consumer's buffer was full.", "subscriber", "reason"),
+	}
+}
+
+// ObserveAPIRequest records a completed API call
+func (m *PrometheusMetrics) ObserveAPIRequest(method, outcome string, duration time.Duration) {
+	m.apiRequests.inc(method, outcome)
+	m.apiDuration.observe(duration.Seconds(), method)
+}
+
+// IncRetry records a retried API call attempt
+func (m *PrometheusMetrics) IncRetry(method string) {
+	m.retries.inc(method)
+}
+
+// IncRateLimit records an API response that reported a rate limit
+func (m *PrometheusMetrics) IncRateLimit(method string) {
+	m.rateLimits.inc(method)
+}
+
+// IncUpdateReceived records an update received from a webhook or polling
+func (m *PrometheusMetrics) IncUpdateReceived(source, eventName string) {
+	m.updates.inc(source, eventName)
+}
+
+// ObserveHandler records the execution time of an update handler
+func (m *PrometheusMetrics) ObserveHandler(eventName, outcome string, duration time.Duration) {
+	m.handlerTime.observe(duration.Seconds(), eventName, outcome)
+}
+
+// ObservePollingLag records polling lag
+func (m *PrometheusMetrics) ObservePollingLag(lag time.Duration) {
+	if lag < 0 {
+		lag = 0
+	}
+	m.pollingLag.observe(lag.Seconds())
+}
+
+// ObserveWebhookRequest records a webhook HTTP request served for a bot
+func (m *PrometheusMetrics) ObserveWebhookRequest(botKey string, statusCode int, duration time.Duration) {
+	m.webhooks.inc(botKey, strconv.Itoa(statusCode))
+	m.webhookTime.observe(duration.Seconds(), botKey)
+}
+
+// IncDuplicateUpdate records an update dropped as a duplicate
+func (m *PrometheusMetrics) IncDuplicateUpdate(source, eventName string) {
+	m.duplicates.inc(source, eventName)
+}
+
+// SetUpdateQueueDepth reports the updates waiting for a consumer
+func (m *PrometheusMetrics) SetUpdateQueueDepth(subscriber string, depth int) {
+	m.queueDepth.set(float64(depth), subscriber)
+}
+
+// IncDroppedUpdate records an update dropped because a buffer was full
+func (m *PrometheusMetrics) IncDroppedUpdate(subscriber, reason string) {
+	m.dropped.inc(subscriber, reason)
+}
+
+// ServeHTTP implements http.Handler, rendering all metrics in the Prometheus
+// text exposition format
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format to w
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.collectorsMu.Lock()
+	defer m.collectorsMu.Unlock()
+
+	var b strings.Builder
+	m.apiRequests.write(&b)
+	m.apiDuration.write(&b)
+	m.retries.write(&b)
+	m.rateLimits.write(&b)
+	m.updates.write(&b)
+	m.handlerTime.write(&b)
+	m.pollingLag.write(&b)
+	m.webhooks.write(&b)
+	m.webhookTime.write(&b)
+	m.duplicates.write(&b)
+	m.queueDepth.write(&b)
+	m.dropped.write(&b)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// counterVec is a counter partitioned by label values
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]float64
+	keys   map[string][]string
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+		keys:   make(map[string][]string),
+	}
+}
+
+func (c *counterVec) inc(labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[key]; !ok {
+		c.keys[key] = labelValues
+	}
+	c.values[key]++
+}
+
+func (c *counterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.keys) {
+		fmt.Fprintf(b, "%s%s %s\n", c.name, formatLabels(c.labels, c.keys[key], "", ""), formatFloat(c.values[key]))
+	}
+}
+
+// gaugeVec is a gauge partitioned by label values
+type gaugeVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]float64
+	keys   map[string][]string
+}
+
+func newGaugeVec(name, help string, labels ...string) *gaugeVec {
+	return &gaugeVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+		keys:   make(map[string][]string),
+	}
+}
+
+func (g *gaugeVec) set(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.keys[key]; !ok {
+		g.keys[key] = labelValues
+	}
+	g.values[key] = value
+}
+
+func (g *gaugeVec) write(b *strings.Builder) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	for _, key := range sortedKeys(g.keys) {
+		fmt.Fprintf(b, "%s%s %s\n", g.name, formatLabels(g.labels, g.keys[key], "", ""), formatFloat(g.values[key]))
+	}
+}
+
+// histogramVec is a histogram partitioned by label values
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+	keys    map[string][]string
+}
+
+// histogram holds cumulative bucket counts for one label combination
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*histogram),
+		keys:    make(map[string][]string),
+	}
+}
+
+func (h *histogramVec) observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+		h.keys[key] = labelValues
+	}
+
+	for i, upper := range h.buckets {
+		if value <= upper {
+			series.counts[i]++
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+func (h *histogramVec) write(b *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.keys) {
+		series := h.series[key]
+		values := h.keys[key]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, "le", formatFloat(upper)), series.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, "le", "+Inf"), series.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", h.name, formatLabels(h.labels, values, "", ""), formatFloat(series.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", h.name, formatLabels(h.labels, values, "", ""), series.count)
+	}
+}
+
+// sortedKeys returns the series keys in a stable order
+func sortedKeys(keys map[string][]string) []string {
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// formatLabels renders a label set, optionally followed by an extra label
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, name+`="`+escapeLabelValue(value)+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+escapeLabelValue(extraValue)+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelEscaper escapes label values as required by the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes backslashes, double quotes and line feeds in a label value
+func escapeLabelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// formatFloat renders a sample value the way Prometheus expects
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
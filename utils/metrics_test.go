@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics_Counters(t *testing.T) {
+	metrics := NewPrometheusMetrics("")
+
+	metrics.ObserveAPIRequest("sendMessage", OutcomeSuccess, 20*time.Millisecond)
+	metrics.ObserveAPIRequest("sendMessage", OutcomeSuccess, 40*time.Millisecond)
+	metrics.ObserveAPIRequest("sendMessage", "rate_limit_error", time.Second)
+	metrics.IncRetry("sendMessage")
+	metrics.IncRateLimit("sendMessage")
+	metrics.IncUpdateReceived("webhook", "message.text.received")
+	metrics.IncDuplicateUpdate("webhook", "message.text.received")
+	metrics.SetUpdateQueueDepth("default", 7)
+	metrics.SetUpdateQueueDepth("default", 3)
+	metrics.IncDroppedUpdate("audit", "drop_oldest")
+
+	var b strings.Builder
+	if _, err := metrics.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	output := b.String()
+
+	expected := []string{
+		"# TYPE zalobot_api_requests_total counter",
+		`zalobot_api_requests_total{method="sendMessage",outcome="success"} 2`,
+		`zalobot_api_requests_total{method="sendMessage",outcome="rate_limit_error"} 1`,
+		`zalobot_api_retries_total{method="sendMessage"} 1`,
+		`zalobot_rate_limit_hits_total{method="sendMessage"} 1`,
+		`zalobot_updates_received_total{source="webhook",event_name="message.text.received"} 1`,
+		`zalobot_duplicate_updates_total{source="webhook",event_name="message.text.received"} 1`,
+		"# TYPE zalobot_update_queue_depth gauge",
+		`zalobot_update_queue_depth{subscriber="default"} 3`,
+		`zalobot_dropped_updates_total{subscriber="audit",reason="drop_oldest"} 1`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q\n%s", want, output)
+		}
+	}
+}
+
+func TestPrometheusMetrics_Histogram(t *testing.T) {
+	metrics := NewPrometheusMetrics("bot")
+
+	metrics.ObserveHandler("message", OutcomeSuccess, 30*time.Millisecond)
+	metrics.ObserveHandler("message", OutcomeSuccess, 3*time.Second)
+	metrics.ObservePollingLag(-time.Second)
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	output := b.String()
+
+	expected := []string{
+		"# TYPE bot_handler_duration_seconds histogram",
+		`bot_handler_duration_seconds_bucket{event_name="message",outcome="success",le="0.025"} 0`,
+		`bot_handler_duration_seconds_bucket{event_name="message",outcome="success",le="0.05"} 1`,
+		`bot_handler_duration_seconds_bucket{event_name="message",outcome="success",le="5"} 2`,
+		`bot_handler_duration_seconds_bucket{event_name="message",outcome="success",le="+Inf"} 2`,
+		`bot_handler_duration_seconds_count{event_name="message",outcome="success"} 2`,
+		`bot_polling_lag_seconds_bucket{le="0.1"} 1`,
+		`bot_polling_lag_seconds_sum 0`,
+		`bot_polling_lag_seconds_count 1`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q\n%s", want, output)
+		}
+	}
+}
+
+func TestPrometheusMetrics_LabelEscaping(t *testing.T) {
+	metrics := NewPrometheusMetrics("")
+	metrics.IncUpdateReceived("webhook", "quote\"back\\slash\nline")
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+
+	want := `event_name="quote\"back\\slash\nline"`
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("output missing %q\n%s", want, b.String())
+	}
+}
+
+func TestPrometheusMetrics_ServeHTTP(t *testing.T) {
+	metrics := NewPrometheusMetrics("")
+	metrics.IncRetry("getUpdates")
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `zalobot_api_retries_total{method="getUpdates"} 1`) {
+		t.Errorf("body missing retry counter\n%s", rec.Body.String())
+	}
+}
+
+func TestNoOpMetrics(t *testing.T) {
+	var metrics Metrics = NewNoOpMetrics()
+
+	// Should not panic
+	metrics.ObserveAPIRequest("sendMessage", OutcomeSuccess, time.Second)
+	metrics.IncRetry("sendMessage")
+	metrics.IncRateLimit("sendMessage")
+	metrics.IncUpdateReceived("polling", "message")
+	metrics.ObserveHandler("message", OutcomeError, time.Second)
+	metrics.ObservePollingLag(time.Second)
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter interface defines how outgoing API requests are throttled.
+// Wait blocks until a request may be sent or ctx is done.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a RateLimiter that allows bursts of up to burst requests and
+// refills at rate requests per second
+type TokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a token bucket limiter that starts full. A burst
+// below 1 is treated as 1; a non-positive rate disables limiting.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		now:    time.Now,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow takes a token if one is available and reports whether it did
+func (tb *TokenBucket) Allow() bool {
+	return tb.reserve() == 0
+}
+
+// Wait blocks until a token is available or ctx is done
+func (tb *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		delay := tb.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long until one is available
+func (tb *TokenBucket) reserve() time.Duration {
+	if tb.rate <= 0 {
+		return 0
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens += elapsed.Seconds() * tb.rate
+		if tb.tokens > tb.burst {
+			tb.tokens = tb.burst
+		}
+	}
+	tb.last = now
+
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return 0
+	}
+
+	delay := time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+	if delay <= 0 {
+		delay = time.Millisecond
+	}
+	return delay
+}
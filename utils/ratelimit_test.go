@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Allow(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := NewTokenBucket(2, 2)
+	bucket.now = func() time.Time { return clock }
+	bucket.last = clock
+
+	if !bucket.Allow() || !bucket.Allow() {
+		t.Fatal("bucket should start full")
+	}
+	if bucket.Allow() {
+		t.Error("Allow() should fail once the burst is used")
+	}
+
+	clock = clock.Add(500 * time.Millisecond)
+	if !bucket.Allow() {
+		t.Error("Allow() should succeed after refilling one token")
+	}
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	bucket := NewTokenBucket(100, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("3 waits at 100/s took %v, want at least ~20ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := NewTokenBucket(0.001, 1)
+	slow.Allow()
+	if err := slow.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestTokenBucket_Unlimited(t *testing.T) {
+	bucket := NewTokenBucket(0, 1)
+	for i := 0; i < 100; i++ {
+		if !bucket.Allow() {
+			t.Fatal("a non-positive rate should not limit")
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedactedValue replaces redacted values in recorded payloads
+const RedactedValue = "***"
+
+// DefaultRedactFields are the payload fields redacted by a Recorder unless
+// RecorderConfig.RedactFields is set: the sender's name and avatar that Zalo
+// sends with every message, and contact details and tokens
+var DefaultRedactFields = []string{"display_name", "name", "avatar", "phone", "phone_number", "email", "address", "access_token", "secret_token"}
+
+// tokenPattern matches bot tokens such as 123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11
+var tokenPattern = regexp.MustCompile(`\b\d{3,}:[A-Za-z0-9_-]{16,}\b`)
+
+// Record is one captured API payload, stored as a line of JSON
+type Record struct {
+	Time time.Time `json:"time"`
+	// Source is "webhook" for a webhook request body or "polling" for a
+	// getUpdates response body
+	Source string `json:"source"`
+	// Payload is the body as received, after redaction
+	Payload json.RawMessage `json:"payload"`
+}
+
+// RecorderConfig represents configuration for a Recorder
+type RecorderConfig struct {
+	// Rotate configures the file records are written to
+	Rotate RotateConfig
+	// Output, if set, receives records instead of a rotating file
+	Output io.Writer
+	// RedactFields are JSON object keys whose values are replaced with
+	// RedactedValue at any depth (default: DefaultRedactFields)
+	RedactFields []string
+	// Secrets are strings, such as the bot token and webhook secret, replaced
+	// with RedactedValue wherever they appear. Bot tokens are always redacted.
+	Secrets []string
+}
+
+// Recorder writes captured payloads as JSONL, redacting personal data and
+// tokens first. It is safe for concurrent use.
+type Recorder struct {
+	config RecorderConfig
+	fields map[string]bool
+	now    func() time.Time
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewRecorder creates a recorder writing to config.Output, or to a rotating
+// file configured by config.Rotate
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	out := config.Output
+	if out == nil {
+		writer, err := NewRotatingFileWriter(config.Rotate)
+		if err != nil {
+			return nil, err
+		}
+		out = writer
+	}
+
+	redactFields := config.RedactFields
+	if redactFields == nil {
+		redactFields = DefaultRedactFields
+	}
+	fields := make(map[string]bool, len(redactFields))
+	for _, field := range redactFields {
+		fields[strings.ToLower(field)] = true
+	}
+
+	return &Recorder{config: config, fields: fields, now: time.Now, out: out}, nil
+}
+
+// Record writes payload received from source as one JSONL record
+func (r *Recorder) Record(source string, payload []byte) error {
+	record := Record{
+		Time:    r.now(),
+		Source:  source,
+		Payload: r.Redact(payload),
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("recorder: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.out.Write(line)
+	return err
+}
+
+// Redact returns payload with the configured fields and secrets redacted.
+// A payload that is not valid JSON is returned as a redacted JSON string.
+func (r *Recorder) Redact(payload []byte) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		encoded, _ := json.Marshal(r.redactString(string(payload)))
+		return encoded
+	}
+
+	encoded, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		encoded, _ = json.Marshal(r.redactString(string(payload)))
+	}
+	return encoded
+}
+
+// redactValue redacts configured keys and secrets in a decoded JSON value
+func (r *Recorder) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = RedactedValue
+			} else {
+				v[key] = r.redactValue(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+		return v
+	case string:
+		return r.redactString(v)
+	default:
+		return v
+	}
+}
+
+// redactString replaces secrets and bot tokens in s
+func (r *Recorder) redactString(s string) string {
+	for _, secret := range r.config.Secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, RedactedValue)
+		}
+	}
+	return tokenPattern.ReplaceAllString(s, RedactedValue)
+}
+
+// Close closes the underlying file if the recorder opened it
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.config.Output == nil {
+		if closer, ok := r.out.(io.Closer); ok {
+			return closer.Close()
+		}
+	}
+	return nil
+}
+
+// ReadRecords calls fn for every record in a JSONL capture, in order
+func ReadRecords(reader io.Reader, fn func(Record) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("record on line %d: %w", line, err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// OpenRecordFile opens a capture file for reading, decompressing files
+// gzipped by a RotatingFileWriter
+func OpenRecordFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gz, file: file}, nil
+}
+
+// gzipFile closes both the gzip reader and the file under it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close closes the gzip reader and the file
+func (f *gzipFile) Close() error {
+	err := f.Reader.Close()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ReadRecordFile calls fn for every record in a capture file
+func ReadRecordFile(path string, fn func(Record) error) error {
+	reader, err := OpenRecordFile(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return ReadRecords(reader, fn)
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Redaction(t *testing.T) {
+	var out bytes.Buffer
+	recorder, err := NewRecorder(RecorderConfig{Output: &out, Secrets: []string{"webhook-secret"}})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	recorder.now = func() time.Time { return time.Unix(1700000000, 0).UTC() }
+
+	payload := `{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"my token is 123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11 and webhook-secret","from":{"id":"u1","phone":"0901234567"},"date":1700000000123}}}`
+	if err := recorder.Record("webhook", []byte(payload)); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := recorder.Record("webhook", []byte("not json 123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	output := out.String()
+	for _, leaked := range []string{"0901234567", "ABC-DEF1234ghIkl", "webhook-secret"} {
+		if strings.Contains(output, leaked) {
+			t.Errorf("output contains %q:\n%s", leaked, output)
+		}
+	}
+
+	var records []Record
+	if err := ReadRecords(&out, func(record Record) error {
+		records = append(records, record)
+		return nil
+	}); err != nil {
+		t.Fatalf("ReadRecords() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("ReadRecords() = %d records, want 2", len(records))
+	}
+	if records[0].Source != "webhook" || !records[0].Time.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("record = %+v", records[0])
+	}
+	// Numbers keep their precision
+	if !strings.Contains(string(records[0].Payload), `"date":1700000000123`) {
+		t.Errorf("payload = %s, want date preserved", records[0].Payload)
+	}
+	if string(records[1].Payload) != `"not json ***"` {
+		t.Errorf("invalid JSON payload = %s, want redacted string", records[1].Payload)
+	}
+}
+
+// recordWebhookSample mirrors the sample payload from https://bot.zapps.me/docs/webhook/
+const recordWebhookSample = `{
+	"ok": true,
+	"result": {
+		"message": {
+			"from": {"id": "user1", "display_name": "Ted", "is_bot": false},
+			"chat": {"id": "chat1", "chat_type": "PRIVATE"},
+			"text": "Xin chao",
+			"message_id": "msg1",
+			"date": 1750316131602
+		},
+		"event_name": "message.text.received"
+	}
+}`
+
+func TestRecorder_RedactsSenderByDefault(t *testing.T) {
+	var out bytes.Buffer
+	recorder, err := NewRecorder(RecorderConfig{Output: &out})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	if err := recorder.Record("webhook", []byte(recordWebhookSample)); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	output := out.String()
+	if strings.Contains(output, "Ted") || !strings.Contains(output, `"display_name":"***"`) {
+		t.Errorf("sender name not redacted:\n%s", output)
+	}
+	// The rest of the update stays usable for replay
+	for _, kept := range []string{`"id":"user1"`, `"text":"Xin chao"`, `"event_name":"message.text.received"`} {
+		if !strings.Contains(output, kept) {
+			t.Errorf("output missing %s:\n%s", kept, output)
+		}
+	}
+}
+
+func TestReadRecordFile_Gzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "updates.jsonl.gz")
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(`{"time":"2024-01-01T00:00:00Z","source":"polling","payload":{"ok":true,"result":[]}}` + "\n"))
+	gz.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	if err := ReadRecordFile(path, func(record Record) error {
+		count++
+		if record.Source != "polling" {
+			t.Errorf("Source = %q, want polling", record.Source)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("ReadRecordFile() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("read %d records, want 1", count)
+	}
+}
@@ -0,0 +1,346 @@
+package utils
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp embedded in rotated file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateConfig represents configuration for a RotatingFileWriter
+type RotateConfig struct {
+	// Filename is the file written to; rotated files are placed next to it
+	// as <name>-<timestamp><ext>, with ".gz" appended when compressed
+	Filename string
+	// MaxSize rotates the file before a write would grow it past this many
+	// bytes (0 disables size-based rotation)
+	MaxSize int64
+	// RotateEvery rotates the file once it has been open this long
+	// (0 disables time-based rotation)
+	RotateEvery time.Duration
+	// Compress gzips rotated files in the background, so writes are not
+	// held up while a large file is compressed
+	Compress bool
+	// MaxBackups is the number of rotated files to keep (0 keeps all)
+	MaxBackups int
+	// MaxAge removes rotated files older than this (0 keeps all)
+	MaxAge time.Duration
+}
+
+// RotatingFileWriter is an io.WriteCloser that writes to a file and rotates
+// it by size and age, optionally gzipping rotated files and pruning old ones.
+// It is safe for concurrent use and can be used as LogConfig.Output:
+//
+//	writer, err := utils.NewRotatingFileWriter(utils.RotateConfig{
+//	    Filename:   "/var/log/bot/bot.log",
+//	    MaxSize:    100 << 20,
+//	    Compress:   true,
+//	    MaxBackups: 7,
+//	})
+//	logger := utils.NewLogger(utils.LogConfig{Level: utils.LogLevelInfo, Output: writer})
+type RotatingFileWriter struct {
+	config RotateConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// Background compression of rotated files, one at a time
+	compressMu  sync.Mutex
+	compressWg  sync.WaitGroup
+	compressErr error
+}
+
+// NewRotatingFileWriter creates a rotating file writer, creating the log
+// directory and opening the file for appending
+func NewRotatingFileWriter(config RotateConfig) (*RotatingFileWriter, error) {
+	if config.Filename == "" {
+		return nil, fmt.Errorf("rotating file writer: filename is required")
+	}
+	if config.MaxSize < 0 || config.RotateEvery < 0 || config.MaxBackups < 0 || config.MaxAge < 0 {
+		return nil, fmt.Errorf("rotating file writer: limits must not be negative")
+	}
+
+	w := &RotatingFileWriter{config: config, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes p to the current file, rotating first if p would exceed
+// MaxSize or the file is older than RotateEvery
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, moves it aside and opens a new one
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Close closes the current file and waits for rotated files to be
+// compressed. It returns the first compression error, if any.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.compressWg.Wait()
+	w.compressMu.Lock()
+	defer w.compressMu.Unlock()
+	if err == nil {
+		err = w.compressErr
+	}
+	return err
+}
+
+// shouldRotate reports whether the file must be rotated before writing n bytes
+func (w *RotatingFileWriter) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.config.MaxSize > 0 && w.size+n > w.config.MaxSize {
+		return true
+	}
+	if w.config.RotateEvery > 0 && w.now().Sub(w.openedAt) >= w.config.RotateEvery {
+		return true
+	}
+	return false
+}
+
+// open opens the log file for appending; the caller must hold the lock
+func (w *RotatingFileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.config.Filename), 0o755); err != nil {
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+
+	file, err := os.OpenFile(w.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.now()
+	return nil
+}
+
+// rotate moves the current file aside and opens a new one, then prunes
+// backups; compressed backups are gzipped and pruned in the background. The
+// caller must hold the lock.
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+	w.file = nil
+
+	now := w.now()
+	backup := w.backupName(now)
+	if err := os.Rename(w.config.Filename, backup); err != nil {
+		// Keep writing to the original file rather than losing output
+		if openErr := w.open(); openErr != nil {
+			return fmt.Errorf("rotating file writer: %w (reopening: %v)", err, openErr)
+		}
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	if !w.config.Compress {
+		return w.prune(now)
+	}
+
+	w.compressWg.Add(1)
+	go func() {
+		defer w.compressWg.Done()
+		w.compressMu.Lock()
+		defer w.compressMu.Unlock()
+
+		// A backup pruned before its turn needs no compressing
+		err := compressFile(backup)
+		if err == nil || errors.Is(err, fs.ErrNotExist) {
+			err = w.prune(now)
+		}
+		if err != nil && w.compressErr == nil {
+			w.compressErr = err
+		}
+	}()
+	return nil
+}
+
+// backupName returns an unused name for a file rotated at t
+func (w *RotatingFileWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.nameParts()
+	base := filepath.Join(dir, prefix+t.Format(backupTimeFormat))
+
+	name := base + ext
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s.%d%s", base, i, ext)
+	}
+	return name
+}
+
+// nameParts splits the file name into directory, backup prefix and extension
+func (w *RotatingFileWriter) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.config.Filename)
+	base := filepath.Base(w.config.Filename)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// backupFile is a rotated file found on disk
+type backupFile struct {
+	path      string
+	timestamp time.Time
+}
+
+// prune removes rotated files beyond MaxBackups or older than MaxAge at now
+func (w *RotatingFileWriter) prune(now time.Time) error {
+	if w.config.MaxBackups == 0 && w.config.MaxAge == 0 {
+		return nil
+	}
+
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-w.config.MaxAge)
+	for i, backup := range backups {
+		expired := w.config.MaxAge > 0 && backup.timestamp.Before(cutoff)
+		excess := w.config.MaxBackups > 0 && i >= w.config.MaxBackups
+		if expired || excess {
+			if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("rotating file writer: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// backups lists rotated files, newest first
+func (w *RotatingFileWriter) backups() ([]backupFile, error) {
+	dir, prefix, ext := w.nameParts()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("rotating file writer: %w", err)
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(name, prefix)
+		stamp = strings.TrimSuffix(stamp, ".gz")
+		stamp = strings.TrimSuffix(stamp, ext)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+
+		timestamp, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), timestamp: timestamp})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].timestamp.Equal(backups[j].timestamp) {
+			return backups[i].path > backups[j].path
+		}
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+	return backups, nil
+}
+
+// compressFile gzips path into path.gz and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+
+	src.Close()
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("rotating file writer: %w", err)
+	}
+	return nil
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rotatedFiles lists the rotated files next to the log file
+func rotatedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "bot-*"))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	return matches
+}
+
+func TestRotatingFileWriter_SizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewRotatingFileWriter(RotateConfig{Filename: filepath.Join(dir, "bot.log"), MaxSize: 10})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter() error = %v", err)
+	}
+	defer writer.Close()
+
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	writer.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n"} {
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if got := len(rotatedFiles(t, dir)); got != 2 {
+		t.Errorf("rotated files = %d, want 2", got)
+	}
+	current, _ := os.ReadFile(filepath.Join(dir, "bot.log"))
+	if string(current) != "cccccc\n" {
+		t.Errorf("current file = %q, want last line", current)
+	}
+}
+
+func TestRotatingFileWriter_TimeRotationCompressAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewRotatingFileWriter(RotateConfig{
+		Filename:    filepath.Join(dir, "bot.log"),
+		RotateEvery: time.Hour,
+		Compress:    true,
+		MaxBackups:  2,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter() error = %v", err)
+	}
+
+	clock := time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)
+	writer.now = func() time.Time { return clock }
+	writer.openedAt = clock
+
+	for i := 0; i < 4; i++ {
+		writer.Write([]byte("entry\n"))
+		clock = clock.Add(time.Hour)
+	}
+	writer.Write([]byte("latest\n"))
+
+	// Close waits for the background compression
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	files := rotatedFiles(t, dir)
+	if len(files) != 2 {
+		t.Fatalf("rotated files = %v, want 2 kept", files)
+	}
+
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".log.gz") {
+			t.Errorf("rotated file %s is not compressed", file)
+			continue
+		}
+		f, _ := os.Open(file)
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("gzip.NewReader() error = %v", err)
+		}
+		data, _ := io.ReadAll(gz)
+		f.Close()
+		if string(data) != "entry\n" {
+			t.Errorf("rotated content = %q, want entry", data)
+		}
+	}
+
+	// The oldest rotation (01:00) was pruned
+	if strings.Contains(strings.Join(files, ","), "2026-01-02T01-00-00") {
+		t.Errorf("oldest backup was not pruned: %v", files)
+	}
+}
+
+func TestRotatingFileWriter_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "bot-2020-01-01T00-00-00.000.log.gz")
+	if err := os.WriteFile(old, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotatingFileWriter(RotateConfig{Filename: filepath.Join(dir, "bot.log"), MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter() error = %v", err)
+	}
+	defer writer.Close()
+
+	writer.Write([]byte("line\n"))
+	if err := writer.Rotate(); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	if fileExists(old) {
+		t.Error("backup older than MaxAge was not removed")
+	}
+	if got := len(rotatedFiles(t, dir)); got != 1 {
+		t.Errorf("rotated files = %d, want 1", got)
+	}
+}
+
+func TestRotatingFileWriter_RenameFailureKeepsWriting(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "bot.log")
+	writer, err := NewRotatingFileWriter(RotateConfig{Filename: filename})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter() error = %v", err)
+	}
+	defer writer.Close()
+
+	writer.Write([]byte("before\n"))
+	// The file is removed behind the writer's back, so it cannot be renamed
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Rotate(); err == nil {
+		t.Fatal("Rotate() expected error when the file is gone")
+	}
+
+	if _, err := writer.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Write() after failed rotation error = %v", err)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "after\n" {
+		t.Errorf("log file = %q, want the line written after the failed rotation", data)
+	}
+}
+
+func TestRotatingFileWriter_AsLoggerOutput(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewRotatingFileWriter(RotateConfig{Filename: filepath.Join(dir, "logs", "bot.log")})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter() error = %v", err)
+	}
+
+	logger := NewLogger(LogConfig{Level: LogLevelInfo, Output: writer})
+	logger.Info("hello")
+	writer.Close()
+
+	data, _ := os.ReadFile(filepath.Join(dir, "logs", "bot.log"))
+	if !strings.Contains(string(data), "[INFO] hello") {
+		t.Errorf("log file = %q", data)
+	}
+	if _, err := writer.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("Write() after Close error = %v, want os.ErrClosed", err)
+	}
+}
+
+func TestNewRotatingFileWriter_Invalid(t *testing.T) {
+	if _, err := NewRotatingFileWriter(RotateConfig{}); err == nil {
+		t.Error("expected error for empty filename")
+	}
+	if _, err := NewRotatingFileWriter(RotateConfig{Filename: filepath.Join(t.TempDir(), "a.log"), MaxSize: -1}); err == nil {
+		t.Error("expected error for negative MaxSize")
+	}
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// SamplingConfig represents configuration for a SampledLogger. Within each
+// Tick, the first Initial entries with the same level and message are logged,
+// then only every Thereafter-th one.
+type SamplingConfig struct {
+	// MaxLevel is the most severe level that is sampled; entries above it are
+	// always logged (default: debug)
+	MaxLevel LogLevel
+	// Initial is the number of identical entries logged per tick before
+	// sampling starts (default: 10)
+	Initial int
+	// Thereafter logs every Thereafter-th entry once Initial is exceeded;
+	// 0 drops the rest of the tick
+	Thereafter int
+	// Tick is the interval after which counters reset (default: 1s)
+	Tick time.Duration
+}
+
+// DefaultSamplingConfig returns a sampling configuration for debug lines
+func DefaultSamplingConfig() SamplingConfig {
+	return SamplingConfig{
+		MaxLevel:   LogLevelDebug,
+		Initial:    10,
+		Thereafter: 100,
+		Tick:       time.Second,
+	}
+}
+
+// SampledLogger wraps a Logger and drops repeated high-volume entries, such
+// as per-request debug lines, according to a SamplingConfig
+type SampledLogger struct {
+	logger Logger
+	config SamplingConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	tickStart time.Time
+	counts    map[string]int
+	dropped   uint64
+}
+
+// NewSampledLogger creates a logger that samples entries before passing them
+// to logger. Zero fields of config take their DefaultSamplingConfig values.
+func NewSampledLogger(logger Logger, config SamplingConfig) *SampledLogger {
+	defaults := DefaultSamplingConfig()
+	if config.Initial <= 0 {
+		config.Initial = defaults.Initial
+	}
+	if config.Thereafter < 0 {
+		config.Thereafter = 0
+	}
+	if config.Tick <= 0 {
+		config.Tick = defaults.Tick
+	}
+	if logger == nil {
+		logger = NewNoOpLogger()
+	}
+
+	return &SampledLogger{
+		logger: logger,
+		config: config,
+		now:    time.Now,
+		counts: make(map[string]int),
+	}
+}
+
+// Debug logs a debug message, subject to sampling
+func (l *SampledLogger) Debug(msg string, fields ...Field) {
+	if l.sample(LogLevelDebug, msg) {
+		l.logger.Debug(msg, fields...)
+	}
+}
+
+// Info logs an info message, subject to sampling
+func (l *SampledLogger) Info(msg string, fields ...Field) {
+	if l.sample(LogLevelInfo, msg) {
+		l.logger.Info(msg, fields...)
+	}
+}
+
+// Warn logs a warning message, subject to sampling
+func (l *SampledLogger) Warn(msg string, fields ...Field) {
+	if l.sample(LogLevelWarn, msg) {
+		l.logger.Warn(msg, fields...)
+	}
+}
+
+// Error logs an error message, subject to sampling
+func (l *SampledLogger) Error(msg string, fields ...Field) {
+	if l.sample(LogLevelError, msg) {
+		l.logger.Error(msg, fields...)
+	}
+}
+
+// SetLevel sets the level of the wrapped logger
+func (l *SampledLogger) SetLevel(level LogLevel) {
+	l.logger.SetLevel(level)
+}
+
+// IsEnabled checks if the wrapped logger emits a level
+func (l *SampledLogger) IsEnabled(level LogLevel) bool {
+	return l.logger.IsEnabled(level)
+}
+
+// Dropped returns the number of entries dropped by sampling
+func (l *SampledLogger) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// sample reports whether an entry should be logged
+func (l *SampledLogger) sample(level LogLevel, msg string) bool {
+	if level > l.config.MaxLevel || !l.logger.IsEnabled(level) {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.tickStart) >= l.config.Tick {
+		l.tickStart = now
+		l.counts = make(map[string]int)
+	}
+
+	key := level.String() + "\xff" + msg
+	l.counts[key]++
+	n := l.counts[key]
+
+	if n <= l.config.Initial {
+		return true
+	}
+	if l.config.Thereafter > 0 && (n-l.config.Initial)%l.config.Thereafter == 0 {
+		return true
+	}
+	l.dropped++
+	return false
+}
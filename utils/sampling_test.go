@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampledLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base := NewLogger(LogConfig{Level: LogLevelDebug, Output: &buf})
+	logger := NewSampledLogger(base, SamplingConfig{Initial: 2, Thereafter: 3, Tick: time.Minute})
+
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time { return clock }
+
+	for i := 0; i < 8; i++ {
+		logger.Debug("request")
+	}
+	logger.Warn("not sampled")
+
+	// Entries 1, 2 and then every third: 5 and 8
+	if got := strings.Count(buf.String(), "[DEBUG] request"); got != 4 {
+		t.Errorf("logged %d debug lines, want 4\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "[WARN] not sampled") {
+		t.Error("warn entry should bypass sampling")
+	}
+	if logger.Dropped() != 4 {
+		t.Errorf("Dropped() = %d, want 4", logger.Dropped())
+	}
+
+	// Counters reset on the next tick
+	buf.Reset()
+	clock = clock.Add(time.Minute)
+	logger.Debug("request")
+	if !strings.Contains(buf.String(), "request") {
+		t.Error("first entry of a new tick should be logged")
+	}
+}
+
+func TestSampledLogger_DropAfterInitial(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSampledLogger(NewLogger(LogConfig{Level: LogLevelDebug, Output: &buf}), SamplingConfig{Initial: 1})
+
+	logger.Debug("a")
+	logger.Debug("a")
+	logger.Debug("b")
+
+	if got := strings.Count(buf.String(), "\n"); got != 2 {
+		t.Errorf("logged %d lines, want 2 (one per message)\n%s", got, buf.String())
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sessionSweepInterval is how often a MemorySessionStore removes expired
+// sessions while saving
+const sessionSweepInterval = time.Minute
+
+// MemorySessionStore keeps session data in memory. Sessions are lost on
+// restart and not shared between processes. It is safe for concurrent use.
+type MemorySessionStore struct {
+	mu        sync.Mutex
+	sessions  map[string]memorySession
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// memorySession is one stored session
+type memorySession struct {
+	data    map[string]interface{}
+	expires time.Time
+}
+
+// NewMemorySessionStore creates an empty in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]memorySession),
+		now:      time.Now,
+	}
+}
+
+// Load returns a copy of the data stored for key, or nil if there is none
+// or it expired
+func (s *MemorySessionStore) Load(ctx context.Context, key string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[key]
+	if !ok {
+		return nil, nil
+	}
+	if !s.now().Before(session.expires) {
+		delete(s.sessions, key)
+		return nil, nil
+	}
+	return copySessionData(session.data), nil
+}
+
+// Save stores a copy of data for key, expiring it after ttl
+func (s *MemorySessionStore) Save(ctx context.Context, key string, data map[string]interface{}, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sessionSweepInterval {
+		for k, session := range s.sessions {
+			if !now.Before(session.expires) {
+				delete(s.sessions, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	s.sessions[key] = memorySession{data: copySessionData(data), expires: now.Add(ttl)}
+	return nil
+}
+
+// Delete removes the data stored for key
+func (s *MemorySessionStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+	return nil
+}
+
+// Len returns the number of stored sessions, including expired sessions not
+// yet removed
+func (s *MemorySessionStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// copySessionData returns a shallow copy of data
+func copySessionData(data map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		copied[k] = v
+	}
+	return copied
+}
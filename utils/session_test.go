@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemorySessionStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySessionStore()
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	data := map[string]interface{}{"step": "address"}
+	if err := store.Save(ctx, "chat-1", data, time.Minute); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// The store keeps its own copy
+	data["step"] = "changed"
+
+	loaded, err := store.Load(ctx, "chat-1")
+	if err != nil || loaded["step"] != "address" {
+		t.Fatalf("Load() = %v, %v, want step=address", loaded, err)
+	}
+	if loaded, _ := store.Load(ctx, "chat-2"); loaded != nil {
+		t.Errorf("Load() of unknown key = %v, want nil", loaded)
+	}
+
+	now = now.Add(time.Minute)
+	if loaded, _ := store.Load(ctx, "chat-1"); loaded != nil {
+		t.Errorf("Load() after TTL = %v, want nil", loaded)
+	}
+
+	store.Save(ctx, "chat-3", data, time.Minute)
+	store.Delete(ctx, "chat-3")
+	if store.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", store.Len())
+	}
+}
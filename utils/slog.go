@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// SlogHandler is a slog.Handler that writes records through a utils.Logger,
+// such as DefaultLogger. Attributes added with WithAttrs and groups opened
+// with WithGroup become fields with dotted keys.
+type SlogHandler struct {
+	logger Logger
+	attrs  []Field
+	groups []string
+}
+
+// NewSlogHandler creates a slog.Handler backed by logger
+//
+//	handler := utils.NewSlogHandler(utils.NewDefaultLogger())
+//	slog.SetDefault(slog.New(handler))
+func NewSlogHandler(logger Logger) *SlogHandler {
+	if logger == nil {
+		logger = NewNoOpLogger()
+	}
+	return &SlogHandler{logger: logger}
+}
+
+// Enabled reports whether the underlying logger emits records at level
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger.IsEnabled(FromSlogLevel(level))
+}
+
+// Handle converts the record into fields and writes it to the underlying logger
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make([]Field, 0, len(h.attrs)+record.NumAttrs())
+	fields = append(fields, h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, h.groups, attr)
+		return true
+	})
+
+	switch FromSlogLevel(record.Level) {
+	case LogLevelDebug:
+		h.logger.Debug(record.Message, fields...)
+	case LogLevelInfo:
+		h.logger.Info(record.Message, fields...)
+	case LogLevelWarn:
+		h.logger.Warn(record.Message, fields...)
+	default:
+		h.logger.Error(record.Message, fields...)
+	}
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	clone := h.clone()
+	for _, attr := range attrs {
+		clone.attrs = appendAttr(clone.attrs, h.groups, attr)
+	}
+	return clone
+}
+
+// WithGroup returns a handler that prefixes the keys of later attributes with name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := h.clone()
+	clone.groups = append(clone.groups, name)
+	return clone
+}
+
+// clone copies the handler so derived handlers do not share slices
+func (h *SlogHandler) clone() *SlogHandler {
+	return &SlogHandler{
+		logger: h.logger,
+		attrs:  append([]Field(nil), h.attrs...),
+		groups: append([]string(nil), h.groups...),
+	}
+}
+
+// appendAttr flattens attr into fields, joining group names with dots
+func appendAttr(fields []Field, groups []string, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupAttrs := attr.Value.Group()
+		if len(groupAttrs) == 0 {
+			return fields
+		}
+		// An unnamed group is inlined into the current one
+		nested := groups
+		if attr.Key != "" {
+			nested = append(append([]string(nil), groups...), attr.Key)
+		}
+		for _, groupAttr := range groupAttrs {
+			fields = appendAttr(fields, nested, groupAttr)
+		}
+		return fields
+	}
+
+	key := attr.Key
+	for i := len(groups) - 1; i >= 0; i-- {
+		key = groups[i] + "." + key
+	}
+	return append(fields, Field{Key: key, Value: attr.Value.Any()})
+}
+
+// SlogLogger is a utils.Logger that writes through a *slog.Logger. SetLevel
+// adds a minimum level on top of whatever the slog handler already filters.
+type SlogLogger struct {
+	logger *slog.Logger
+	level  LogLevel
+	mu     sync.RWMutex
+}
+
+// NewSlogLogger creates a Logger backed by logger (default: slog.Default())
+//
+//	bot, err := zalobot.New(botToken, types.WithLogger(utils.NewSlogLogger(slog.Default())))
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger, level: LogLevelDebug}
+}
+
+// Debug logs a debug message
+func (l *SlogLogger) Debug(msg string, fields ...Field) {
+	l.log(LogLevelDebug, msg, fields)
+}
+
+// Info logs an info message
+func (l *SlogLogger) Info(msg string, fields ...Field) {
+	l.log(LogLevelInfo, msg, fields)
+}
+
+// Warn logs a warning message
+func (l *SlogLogger) Warn(msg string, fields ...Field) {
+	l.log(LogLevelWarn, msg, fields)
+}
+
+// Error logs an error message
+func (l *SlogLogger) Error(msg string, fields ...Field) {
+	l.log(LogLevelError, msg, fields)
+}
+
+// SetLevel sets the minimum level passed on to the slog logger
+func (l *SlogLogger) SetLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// IsEnabled checks both the minimum level and the slog handler
+func (l *SlogLogger) IsEnabled(level LogLevel) bool {
+	l.mu.RLock()
+	minLevel := l.level
+	l.mu.RUnlock()
+
+	if level < minLevel {
+		return false
+	}
+	return l.logger.Enabled(context.Background(), ToSlogLevel(level))
+}
+
+// log converts fields to slog attributes and emits the record
+func (l *SlogLogger) log(level LogLevel, msg string, fields []Field) {
+	if !l.IsEnabled(level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, len(fields))
+	for i, field := range fields {
+		attrs[i] = slog.Any(field.Key, field.Value)
+	}
+	l.logger.LogAttrs(context.Background(), ToSlogLevel(level), msg, attrs...)
+}
+
+// ToSlogLevel converts a LogLevel to the matching slog.Level
+func ToSlogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// FromSlogLevel converts a slog.Level to the nearest LogLevel at or below it
+func FromSlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level >= slog.LevelError:
+		return LogLevelError
+	case level >= slog.LevelWarn:
+		return LogLevelWarn
+	case level >= slog.LevelInfo:
+		return LogLevelInfo
+	default:
+		return LogLevelDebug
+	}
+}
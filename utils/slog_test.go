@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	base := NewLogger(LogConfig{Level: LogLevelInfo, Output: &buf, Format: LogFormatJSON})
+	logger := slog.New(NewSlogHandler(base))
+
+	logger.Debug("hidden")
+	logger.With("bot", "b1").WithGroup("req").Info("sent", "method", "sendMessage", slog.Group("http", "status", 200))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1:\n%s", len(lines), buf.String())
+	}
+
+	var entry struct {
+		Level   string                 `json:"level"`
+		Message string                 `json:"message"`
+		Fields  map[string]interface{} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("invalid JSON log line: %v", err)
+	}
+
+	if entry.Level != "INFO" || entry.Message != "sent" {
+		t.Errorf("entry = %+v, want INFO sent", entry)
+	}
+	want := map[string]interface{}{"bot": "b1", "req.method": "sendMessage", "req.http.status": float64(200)}
+	for key, value := range want {
+		if entry.Fields[key] != value {
+			t.Errorf("field %s = %v, want %v", key, entry.Fields[key], value)
+		}
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	logger := NewSlogLogger(slog.New(handler))
+
+	if logger.IsEnabled(LogLevelDebug) {
+		t.Error("IsEnabled(debug) = true, want false from handler level")
+	}
+
+	logger.Debug("hidden")
+	logger.Info("request", Field{Key: "method", Value: "getMe"})
+
+	logger.SetLevel(LogLevelError)
+	logger.Warn("hidden too")
+	logger.Error("failed", Field{Key: "status", Value: 500})
+
+	output := buf.String()
+	for _, want := range []string{"level=INFO msg=request method=getMe", "level=ERROR msg=failed status=500"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "hidden") {
+		t.Errorf("output contains filtered entries\n%s", output)
+	}
+}
+
+func TestSlogLevelConversion(t *testing.T) {
+	for _, level := range []LogLevel{LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError} {
+		if got := FromSlogLevel(ToSlogLevel(level)); got != level {
+			t.Errorf("FromSlogLevel(ToSlogLevel(%v)) = %v", level, got)
+		}
+	}
+	if got := FromSlogLevel(slog.LevelWarn + 2); got != LogLevelWarn {
+		t.Errorf("FromSlogLevel(WARN+2) = %v, want warn", got)
+	}
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// IsValid returns true if the span context identifies a span
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.SpanID != ""
+}
+
+// Span interface defines a unit of traced work
+type Span interface {
+	SetAttributes(fields ...Field)
+	RecordError(err error)
+	End()
+	SpanContext() SpanContext
+}
+
+// Tracer interface defines how spans are started. Start returns a context
+// carrying the new span so work done with it, such as a reply sent from a
+// handler, becomes a child of that span.
+type Tracer interface {
+	Start(ctx context.Context, name string, fields ...Field) (context.Context, Span)
+}
+
+// spanContextKey is the context key under which the active span is stored
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span as the active span
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the active span carried by ctx, or nil
+func SpanFromContext(ctx context.Context) Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(Span)
+	return span
+}
+
+// NoOpTracer is a tracer that does nothing (for disabling tracing)
+type NoOpTracer struct{}
+
+// NewNoOpTracer creates a no-op tracer
+func NewNoOpTracer() *NoOpTracer {
+	return &NoOpTracer{}
+}
+
+// Start returns ctx unchanged and a span that does nothing
+func (t *NoOpTracer) Start(ctx context.Context, name string, fields ...Field) (context.Context, Span) {
+	return ctx, noOpSpan{}
+}
+
+// noOpSpan is the span returned by NoOpTracer
+type noOpSpan struct{}
+
+func (noOpSpan) SetAttributes(fields ...Field) {}
+func (noOpSpan) RecordError(err error)         {}
+func (noOpSpan) End()                          {}
+func (noOpSpan) SpanContext() SpanContext      { return SpanContext{} }
+
+// RecordedSpan is a finished or in-progress span captured by RecordingTracer
+type RecordedSpan struct {
+	Name       string
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	Attributes map[string]interface{}
+	Errors     []error
+	StartTime  time.Time
+	EndTime    time.Time
+	Ended      bool
+}
+
+// RecordingTracer is an in-memory tracer for tests. It records every span in
+// start order and links child spans to the span found in the parent context.
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+// NewRecordingTracer creates an in-memory recording tracer
+func NewRecordingTracer() *RecordingTracer {
+	return &RecordingTracer{}
+}
+
+// Start starts a span as a child of the span carried by ctx, if any
+func (t *RecordingTracer) Start(ctx context.Context, name string, fields ...Field) (context.Context, Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	span := &recordingSpan{
+		tracer: t,
+		data: RecordedSpan{
+			Name:       name,
+			SpanID:     randomHex(8),
+			Attributes: make(map[string]interface{}),
+			StartTime:  time.Now(),
+		},
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil && parent.SpanContext().IsValid() {
+		span.data.TraceID = parent.SpanContext().TraceID
+		span.data.ParentID = parent.SpanContext().SpanID
+	} else {
+		span.data.TraceID = randomHex(16)
+	}
+
+	for _, field := range fields {
+		span.data.Attributes[field.Key] = field.Value
+	}
+
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Spans returns a copy of all recorded spans in start order
+func (t *RecordingTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := make([]RecordedSpan, len(t.spans))
+	for i, span := range t.spans {
+		spans[i] = span.snapshot()
+	}
+	return spans
+}
+
+// FindSpan returns the first recorded span with the given name
+func (t *RecordingTracer) FindSpan(name string) (RecordedSpan, bool) {
+	for _, span := range t.Spans() {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return RecordedSpan{}, false
+}
+
+// Reset discards all recorded spans
+func (t *RecordingTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+// recordingSpan is the span returned by RecordingTracer
+type recordingSpan struct {
+	tracer *RecordingTracer
+	data   RecordedSpan
+}
+
+// SetAttributes adds attributes to the span
+func (s *recordingSpan) SetAttributes(fields ...Field) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, field := range fields {
+		s.data.Attributes[field.Key] = field.Value
+	}
+}
+
+// RecordError records an error on the span
+func (s *recordingSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.data.Errors = append(s.data.Errors, err)
+}
+
+// End marks the span as finished; only the first call has an effect
+func (s *recordingSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if s.data.Ended {
+		return
+	}
+	s.data.Ended = true
+	s.data.EndTime = time.Now()
+}
+
+// SpanContext returns the span's trace and span IDs
+func (s *recordingSpan) SpanContext() SpanContext {
+	return SpanContext{TraceID: s.data.TraceID, SpanID: s.data.SpanID}
+}
+
+// snapshot copies the span data; the caller must hold the tracer lock
+func (s *recordingSpan) snapshot() RecordedSpan {
+	data := s.data
+	data.Attributes = make(map[string]interface{}, len(s.data.Attributes))
+	for key, value := range s.data.Attributes {
+		data.Attributes[key] = value
+	}
+	data.Errors = append([]error(nil), s.data.Errors...)
+	return data
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
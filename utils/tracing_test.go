@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRecordingTracer_ParentChild(t *testing.T) {
+	tracer := NewRecordingTracer()
+
+	ctx, parent := tracer.Start(context.Background(), "parent", Field{Key: "a", Value: 1})
+	_, child := tracer.Start(ctx, "child")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	parent.End()
+
+	spans := tracer.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("Spans() len = %d, want 2", len(spans))
+	}
+
+	p, c := spans[0], spans[1]
+	if p.ParentID != "" {
+		t.Errorf("parent ParentID = %q, want empty", p.ParentID)
+	}
+	if c.TraceID != p.TraceID {
+		t.Errorf("child TraceID = %q, want %q", c.TraceID, p.TraceID)
+	}
+	if c.ParentID != p.SpanID {
+		t.Errorf("child ParentID = %q, want %q", c.ParentID, p.SpanID)
+	}
+	if p.Attributes["a"] != 1 {
+		t.Errorf("parent attribute a = %v, want 1", p.Attributes["a"])
+	}
+	if len(c.Errors) != 1 || !c.Ended || !p.Ended {
+		t.Errorf("child = %+v, want one error and both spans ended", c)
+	}
+
+	if span, ok := tracer.FindSpan("child"); !ok || span.SpanID != c.SpanID {
+		t.Errorf("FindSpan(child) = %+v, %v", span, ok)
+	}
+
+	tracer.Reset()
+	if len(tracer.Spans()) != 0 {
+		t.Error("Reset() did not discard spans")
+	}
+}
+
+func TestRecordingTracer_SeparateTraces(t *testing.T) {
+	tracer := NewRecordingTracer()
+
+	_, a := tracer.Start(context.Background(), "a")
+	_, b := tracer.Start(context.Background(), "b")
+
+	if a.SpanContext().TraceID == b.SpanContext().TraceID {
+		t.Error("root spans share a trace ID")
+	}
+}
+
+func TestNoOpTracer(t *testing.T) {
+	var tracer Tracer = NewNoOpTracer()
+
+	ctx := context.Background()
+	spanCtx, span := tracer.Start(ctx, "noop")
+	if spanCtx != ctx {
+		t.Error("NoOpTracer.Start() should return ctx unchanged")
+	}
+
+	// Should not panic
+	span.SetAttributes(Field{Key: "k", Value: "v"})
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	if span.SpanContext().IsValid() {
+		t.Error("no-op span context should be invalid")
+	}
+	if SpanFromContext(spanCtx) != nil {
+		t.Error("SpanFromContext() should return nil for a no-op span")
+	}
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModulePath is the import path of the SDK module
+const ModulePath = "github.com/vkhangstack/go-zalo-bot"
+
+// versionYAML is version.yml as of the build, so version information is
+// available in binaries deployed without the source tree
+//
+//go:embed version.yml
+var versionYAML []byte
+
+// VersionInfo represents the version information structure from version.yml,
+// completed with the module build information of the running binary
+type VersionInfo struct {
+	Version struct {
+		Major      int    `yaml:"major"`
+		Minor      int    `yaml:"minor"`
+		Patch      int    `yaml:"patch"`
+		PreRelease string `yaml:"prerelease"`
+	} `yaml:"version"`
+	SDK struct {
+		Name        string `yaml:"name"`
+		FullVersion string `yaml:"full_version"`
+		UserAgent   string `yaml:"user_agent"`
+	} `yaml:"sdk"`
+	Release struct {
+		Date   string `yaml:"date"`
+		Branch string `yaml:"branch"`
+	} `yaml:"release"`
+	Build struct {
+		// ModuleVersion is the SDK module version recorded by the Go
+		// toolchain, such as "v0.0.5" or a pseudo-version ("" if unknown)
+		ModuleVersion string `yaml:"-"`
+		// GoVersion is the Go version the binary was built with
+		GoVersion string `yaml:"-"`
+	} `yaml:"-"`
+}
+
+var (
+	versionOnce  sync.Once
+	versionMu    sync.RWMutex
+	versionInfo  *VersionInfo
+	fallbackName = "Go-Zalo-Bot-SDK"
+
+	// releaseVersionPattern matches tagged module versions; pseudo-versions
+	// such as v0.0.6-0.20260101120000-abcdef123456 do not match
+	releaseVersionPattern = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?$`)
+	pseudoVersionPattern  = regexp.MustCompile(`\d{14}-[0-9a-f]{12}$`)
+)
+
+// ParseVersionInfo parses version.yml data and completes it with build
+// information. A tagged module version in info takes precedence over the
+// version in data; info may be nil.
+func ParseVersionInfo(data []byte, info *debug.BuildInfo) (*VersionInfo, error) {
+	var v VersionInfo
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse version.yml: %w", err)
+	}
+	if v.SDK.Name == "" {
+		v.SDK.Name = fallbackName
+	}
+
+	if info != nil {
+		v.Build.GoVersion = info.GoVersion
+		v.Build.ModuleVersion = moduleVersion(info)
+	}
+
+	if m := releaseVersionPattern.FindStringSubmatch(v.Build.ModuleVersion); m != nil && !pseudoVersionPattern.MatchString(v.Build.ModuleVersion) {
+		v.Version.Major, _ = strconv.Atoi(m[1])
+		v.Version.Minor, _ = strconv.Atoi(m[2])
+		v.Version.Patch, _ = strconv.Atoi(m[3])
+		v.Version.PreRelease = m[4]
+		v.SDK.FullVersion = strings.TrimPrefix(v.Build.ModuleVersion, "v")
+		v.SDK.UserAgent = ""
+	}
+
+	if v.SDK.FullVersion == "" {
+		v.SDK.FullVersion = fmt.Sprintf("%d.%d.%d", v.Version.Major, v.Version.Minor, v.Version.Patch)
+		if v.Version.PreRelease != "" {
+			v.SDK.FullVersion += "-" + v.Version.PreRelease
+		}
+	}
+	if v.SDK.UserAgent == "" {
+		v.SDK.UserAgent = v.SDK.Name + "/" + v.SDK.FullVersion
+	}
+
+	return &v, nil
+}
+
+// moduleVersion returns the SDK module version recorded in info
+func moduleVersion(info *debug.BuildInfo) string {
+	if info.Main.Path == ModulePath {
+		return validModuleVersion(info.Main.Version)
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != ModulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			return validModuleVersion(dep.Replace.Version)
+		}
+		return validModuleVersion(dep.Version)
+	}
+	return ""
+}
+
+// validModuleVersion drops the placeholder version of local builds
+func validModuleVersion(version string) string {
+	if version == "(devel)" {
+		return ""
+	}
+	return version
+}
+
+// GetVersionInfo returns the SDK version information, parsed once from the
+// embedded version.yml and the binary's build information
+func GetVersionInfo() *VersionInfo {
+	versionOnce.Do(func() {
+		if err := ReloadVersionInfo(); err != nil {
+			info := &VersionInfo{}
+			info.SDK.Name = fallbackName
+			info.SDK.FullVersion = "0.0.0"
+			info.SDK.UserAgent = fallbackName + "/0.0.0"
+
+			versionMu.Lock()
+			versionInfo = info
+			versionMu.Unlock()
+		}
+	})
+
+	versionMu.RLock()
+	defer versionMu.RUnlock()
+	return versionInfo
+}
+
+// ReloadVersionInfo parses the embedded version.yml and build information again
+func ReloadVersionInfo() error {
+	buildInfo, _ := debug.ReadBuildInfo()
+	info, err := ParseVersionInfo(versionYAML, buildInfo)
+	if err != nil {
+		return err
+	}
+
+	versionMu.Lock()
+	versionInfo = info
+	versionMu.Unlock()
+	return nil
+}
+
+// UserAgent returns the User-Agent sent with API requests. A non-empty
+// appIdentifier, such as "shop-bot/2.1", is appended after the SDK product.
+func UserAgent(appIdentifier string) string {
+	userAgent := GetVersionInfo().SDK.UserAgent
+	if appIdentifier = strings.TrimSpace(appIdentifier); appIdentifier != "" {
+		userAgent += " " + appIdentifier
+	}
+	return userAgent
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+const testVersionYAML = `
+version:
+  major: 0
+  minor: 0
+  patch: 5
+  prerelease: ""
+sdk:
+  name: "Go-Zalo-Bot-SDK"
+  full_version: "0.0.5"
+  user_agent: "Go-Zalo-Bot-SDK/0.0.5"
+release:
+  date: "2026-07-19"
+  branch: "master"
+`
+
+func TestParseVersionInfo(t *testing.T) {
+	dependency := func(version string) *debug.BuildInfo {
+		return &debug.BuildInfo{
+			GoVersion: "go1.22.0",
+			Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+			Deps:      []*debug.Module{{Path: ModulePath, Version: version}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		info          *debug.BuildInfo
+		wantVersion   string
+		wantUserAgent string
+		wantModule    string
+	}{
+		{
+			name:          "no build info",
+			wantVersion:   "0.0.5",
+			wantUserAgent: "Go-Zalo-Bot-SDK/0.0.5",
+		},
+		{
+			name:          "local build",
+			info:          &debug.BuildInfo{Main: debug.Module{Path: ModulePath, Version: "(devel)"}},
+			wantVersion:   "0.0.5",
+			wantUserAgent: "Go-Zalo-Bot-SDK/0.0.5",
+		},
+		{
+			name:          "tagged dependency",
+			info:          dependency("v0.1.0-rc.1"),
+			wantVersion:   "0.1.0-rc.1",
+			wantUserAgent: "Go-Zalo-Bot-SDK/0.1.0-rc.1",
+			wantModule:    "v0.1.0-rc.1",
+		},
+		{
+			name:          "pseudo-version dependency",
+			info:          dependency("v0.0.6-0.20260801120000-abcdef123456"),
+			wantVersion:   "0.0.5",
+			wantUserAgent: "Go-Zalo-Bot-SDK/0.0.5",
+			wantModule:    "v0.0.6-0.20260801120000-abcdef123456",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseVersionInfo([]byte(testVersionYAML), tt.info)
+			if err != nil {
+				t.Fatalf("ParseVersionInfo() error = %v", err)
+			}
+			if info.SDK.FullVersion != tt.wantVersion {
+				t.Errorf("FullVersion = %q, want %q", info.SDK.FullVersion, tt.wantVersion)
+			}
+			if info.SDK.UserAgent != tt.wantUserAgent {
+				t.Errorf("UserAgent = %q, want %q", info.SDK.UserAgent, tt.wantUserAgent)
+			}
+			if info.Build.ModuleVersion != tt.wantModule {
+				t.Errorf("ModuleVersion = %q, want %q", info.Build.ModuleVersion, tt.wantModule)
+			}
+		})
+	}
+
+	if _, err := ParseVersionInfo([]byte("version: ["), nil); err == nil {
+		t.Error("ParseVersionInfo() expected error for invalid YAML")
+	}
+}
+
+func TestGetVersionInfo_Embedded(t *testing.T) {
+	info := GetVersionInfo()
+	if info.SDK.Name != "Go-Zalo-Bot-SDK" || info.SDK.FullVersion == "" || info.Release.Date == "" {
+		t.Errorf("GetVersionInfo() = %+v, want data from the embedded version.yml", info)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	base := GetVersionInfo().SDK.UserAgent
+
+	if got := UserAgent(""); got != base {
+		t.Errorf("UserAgent(\"\") = %q, want %q", got, base)
+	}
+	if got := UserAgent(" shop-bot/2.1 "); got != base+" shop-bot/2.1" {
+		t.Errorf("UserAgent(app) = %q, want %q", got, base+" shop-bot/2.1")
+	}
+}
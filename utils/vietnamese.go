@@ -0,0 +1,576 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tone marks of Vietnamese, in the order of the rows of vietnameseVowelRows
+const (
+	toneNone = iota
+	toneGrave
+	toneAcute
+	toneHook
+	toneTilde
+	toneDot
+)
+
+// Combining marks used by Vietnamese letters
+const (
+	markGrave      = '̀'
+	markAcute      = '́'
+	markCircumflex = '̂'
+	markTilde      = '̃'
+	markBreve      = '̆'
+	markHook       = '̉'
+	markHorn       = '̛'
+	markDot        = '̣'
+)
+
+// toneMarks maps a tone to its combining mark
+var toneMarks = [...]rune{toneNone: 0, toneGrave: markGrave, toneAcute: markAcute, toneHook: markHook, toneTilde: markTilde, toneDot: markDot}
+
+// vietnameseVowelRows lists every lowercase Vietnamese vowel by base letter
+// and vowel mark, with the tones in the order none, grave, acute, hook,
+// tilde, dot below
+var vietnameseVowelRows = []struct {
+	letters string
+	base    rune
+	mark    rune
+}{
+	{"aàáảãạ", 'a', 0},
+	{"ăằắẳẵặ", 'a', markBreve},
+	{"âầấẩẫậ", 'a', markCircumflex},
+	{"eèéẻẽẹ", 'e', 0},
+	{"êềếểễệ", 'e', markCircumflex},
+	{"iìíỉĩị", 'i', 0},
+	{"oòóỏõọ", 'o', 0},
+	{"ôồốổỗộ", 'o', markCircumflex},
+	{"ơờớởỡợ", 'o', markHorn},
+	{"uùúủũụ", 'u', 0},
+	{"ưừứửữự", 'u', markHorn},
+	{"yỳýỷỹỵ", 'y', 0},
+}
+
+// vowel describes a Vietnamese vowel as base letter, vowel mark and tone
+type vowel struct {
+	base rune
+	mark rune
+	tone int
+}
+
+var (
+	// vowelsByRune decomposes precomposed vowels, in both cases
+	vowelsByRune = make(map[rune]vowel)
+	// runesByVowel composes vowels, in both cases
+	runesByVowel = make(map[vowel]rune)
+)
+
+func init() {
+	for _, row := range vietnameseVowelRows {
+		for tone, r := range []rune(row.letters) {
+			lower := vowel{base: row.base, mark: row.mark, tone: tone}
+			upper := vowel{base: unicode.ToUpper(row.base), mark: row.mark, tone: tone}
+			vowelsByRune[r] = lower
+			vowelsByRune[unicode.ToUpper(r)] = upper
+			runesByVowel[lower] = r
+			runesByVowel[upper] = unicode.ToUpper(r)
+		}
+	}
+}
+
+// toneOfMark returns the tone of a combining tone mark, or toneNone
+func toneOfMark(r rune) int {
+	for tone, mark := range toneMarks {
+		if tone != toneNone && mark == r {
+			return tone
+		}
+	}
+	return toneNone
+}
+
+// isVowelMark reports whether r is a combining mark that changes a vowel
+func isVowelMark(r rune) bool {
+	return r == markBreve || r == markCircumflex || r == markHorn
+}
+
+// NormalizeNFC composes Vietnamese letters typed as a base letter followed
+// by combining marks, in any order, into single precomposed characters, as
+// Unicode NFC does. Other text is returned unchanged.
+func NormalizeNFC(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(runes); i++ {
+		v, ok := vowelsByRune[runes[i]]
+		if !ok {
+			b.WriteRune(runes[i])
+			continue
+		}
+
+		// Absorb the marks following the vowel while the result still
+		// exists as a precomposed letter
+		j := i + 1
+		for ; j < len(runes); j++ {
+			next := v
+			switch {
+			case isVowelMark(runes[j]) && v.mark == 0:
+				next.mark = runes[j]
+			case toneOfMark(runes[j]) != toneNone && v.tone == toneNone:
+				next.tone = toneOfMark(runes[j])
+			default:
+				next.base = 0
+			}
+			if _, ok := runesByVowel[next]; !ok {
+				break
+			}
+			v = next
+		}
+
+		b.WriteRune(runesByVowel[v])
+		i = j - 1
+	}
+	return b.String()
+}
+
+// NormalizeNFD decomposes precomposed Vietnamese letters into a base letter
+// followed by combining marks in canonical order, as Unicode NFD does.
+// Other text is returned unchanged.
+func NormalizeNFD(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+
+	for _, r := range NormalizeNFC(s) {
+		v, ok := vowelsByRune[r]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		// Canonical order sorts marks by combining class: horn (216), dot
+		// below (220), then the other marks (230) in their original order
+		b.WriteRune(v.base)
+		if v.mark == markHorn {
+			b.WriteRune(markHorn)
+		}
+		if v.tone == toneDot {
+			b.WriteRune(markDot)
+		}
+		if v.mark != 0 && v.mark != markHorn {
+			b.WriteRune(v.mark)
+		}
+		if v.tone != toneNone && v.tone != toneDot {
+			b.WriteRune(toneMarks[v.tone])
+		}
+	}
+	return b.String()
+}
+
+// RemoveDiacritics strips tone and vowel marks from Vietnamese text and
+// replaces đ and Đ with d and D: "Đường phố" becomes "Duong pho"
+func RemoveDiacritics(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for _, r := range s {
+		switch {
+		case r == 'đ':
+			b.WriteRune('d')
+		case r == 'Đ':
+			b.WriteRune('D')
+		case r >= '̀' && r <= 'ͯ':
+			// Combining marks of decomposed text
+		default:
+			if v, ok := vowelsByRune[r]; ok {
+				b.WriteRune(v.base)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// FoldCase returns s composed and in lower case, so that differently typed
+// or capitalized Vietnamese compares equal: "XIN CHÀO" and "xin chào"
+// (with combining marks) both become "xin chào"
+func FoldCase(s string) string {
+	return strings.ToLower(NormalizeNFC(s))
+}
+
+// NormalizeForMatch returns the form of s used for accent-insensitive
+// matching: case folded, without diacritics, with punctuation removed and
+// whitespace collapsed. "  Xin CHÀO, bạn!" becomes "xin chao ban".
+func NormalizeForMatch(s string) string {
+	folded := RemoveDiacritics(FoldCase(s))
+
+	var b strings.Builder
+	b.Grow(len(folded))
+	space := false
+	for _, r := range folded {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Spelling of Vietnamese syllables: initial consonant, vowels without tone,
+// and final consonant
+var (
+	vietnameseInitials = wordSet("b c ch d đ g gh gi h k kh l m n ng ngh nh p ph qu r s t th tr v x")
+	vietnameseNuclei   = wordSet("a ă â e ê i o ô ơ u ư y " +
+		"ai ao au ay âu ây eo êu ia iê iu oa oă oe oi ôi ơi oo ua uâ uê ui uô uơ ưa ưi ươ ưu uy yê " +
+		"iêu oai oao oay oeo uây uôi ươi ươu uya uyê uyu yêu")
+	vietnameseFinals = wordSet("c ch m n ng nh p t")
+)
+
+// wordSet returns the space-separated words of s as a set
+func wordSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(s) {
+		set[word] = true
+	}
+	return set
+}
+
+// IsVietnameseSyllable reports whether word is spelled like a Vietnamese
+// syllable: an optional initial consonant, a vowel sequence Vietnamese uses
+// with at most one tone mark, and an optional final consonant. Syllables
+// ending in c, ch, p or t only take the acute or dot below tone. It is a
+// spelling check, so "chào" and "nghiêng" pass while "start", "free" and
+// "cảt" do not.
+func IsVietnameseSyllable(word string) bool {
+	runes := []rune(strings.ToLower(NormalizeNFC(word)))
+	i := 0
+
+	var initial strings.Builder
+	for i < len(runes) && !isVowelRune(runes[i]) {
+		initial.WriteRune(runes[i])
+		i++
+	}
+	// The u of qu, and the i of gi before another vowel, belong to the
+	// consonant
+	switch {
+	case initial.String() == "q" && i < len(runes) && runes[i] == 'u':
+		initial.WriteRune('u')
+		i++
+	case initial.String() == "g" && i+1 < len(runes) && runes[i] == 'i' && isVowelRune(runes[i+1]):
+		initial.WriteRune('i')
+		i++
+	}
+	if initial.Len() > 0 && !vietnameseInitials[initial.String()] {
+		return false
+	}
+
+	var nucleus strings.Builder
+	tone := toneNone
+	for i < len(runes) && isVowelRune(runes[i]) {
+		v := vowelsByRune[runes[i]]
+		if v.tone != toneNone {
+			if tone != toneNone {
+				return false
+			}
+			tone = v.tone
+		}
+		nucleus.WriteRune(runesByVowel[vowel{base: v.base, mark: v.mark}])
+		i++
+	}
+	if nucleus.Len() == 0 || !vietnameseNuclei[nucleus.String()] {
+		return false
+	}
+
+	final := string(runes[i:])
+	if final == "" {
+		return true
+	}
+	if !vietnameseFinals[final] {
+		return false
+	}
+	switch final {
+	case "c", "ch", "p", "t":
+		return tone == toneNone || tone == toneAcute || tone == toneDot
+	}
+	return true
+}
+
+// isVowelRune reports whether r is a Vietnamese vowel, with or without marks
+func isVowelRune(r rune) bool {
+	_, ok := vowelsByRune[r]
+	return ok
+}
+
+// typedLetter is a letter being decoded from Telex or VNI input
+type typedLetter struct {
+	r    rune // the letter without vowel mark or tone, or đ
+	mark rune // vowel mark, if any
+}
+
+// lowerIs reports whether the letter is c in either case, without a mark
+func (l typedLetter) lowerIs(c rune) bool {
+	return unicode.ToLower(l.r) == c && l.mark == 0
+}
+
+// isTypedVowel reports whether r is a vowel letter without marks
+func isTypedVowel(r rune) bool {
+	switch unicode.ToLower(r) {
+	case 'a', 'e', 'i', 'o', 'u', 'y':
+		return true
+	}
+	return false
+}
+
+// DecodeTelex converts text typed in the Telex input method without an IME
+// into Vietnamese: "xin chaof" becomes "xin chào" and "dduwowngf" becomes
+// "đường". Vowel marks are typed as aa, aw, ee, oo, ow, uw (or w) and dd,
+// and a tone key (s, f, r, x, j, or z for none) anywhere after the vowel,
+// as in "vieejt" or "vieetj". Words without Telex keys are unchanged.
+func DecodeTelex(s string) string {
+	return decodeWords(s, unicode.IsLetter, decodeTelexWord)
+}
+
+// decodeTelexWord decodes one Telex word
+func decodeTelexWord(word []rune) string {
+	var letters []typedLetter
+	tone := toneNone
+
+	for _, r := range word {
+		lower := unicode.ToLower(r)
+		last := len(letters) - 1
+
+		switch {
+		case lower == 'd' && last >= 0 && letters[last].lowerIs('d'):
+			letters[last].r = withCase('đ', letters[last].r)
+			continue
+		case (lower == 'a' || lower == 'e' || lower == 'o') && last >= 0 && letters[last].lowerIs(lower):
+			letters[last].mark = markCircumflex
+			continue
+		case lower == 'w':
+			if applyHorn(letters, 'a', markBreve) || applyHorn(letters, 'o', markHorn) || applyHorn(letters, 'u', markHorn) {
+				continue
+			}
+			letters = append(letters, typedLetter{r: withCase('u', r), mark: markHorn})
+			continue
+		case hasTypedVowel(letters):
+			// No Vietnamese syllable ends in these letters, so after a
+			// vowel they can only be tone keys
+			if t, ok := telexTones[lower]; ok {
+				tone = t
+				continue
+			}
+		}
+		letters = append(letters, typedLetter{r: r})
+	}
+
+	return composeTyped(letters, tone)
+}
+
+// telexTones maps Telex tone keys to tones
+var telexTones = map[rune]int{'s': toneAcute, 'f': toneGrave, 'r': toneHook, 'x': toneTilde, 'j': toneDot, 'z': toneNone}
+
+// applyHorn adds mark to a trailing vowel base typed before w. Typing w
+// after "uo" marks both letters, as in "ươ".
+func applyHorn(letters []typedLetter, base, mark rune) bool {
+	last := len(letters) - 1
+	if last < 0 || !letters[last].lowerIs(base) {
+		return false
+	}
+	letters[last].mark = mark
+	if base == 'o' && last > 0 && letters[last-1].lowerIs('u') {
+		letters[last-1].mark = markHorn
+	}
+	return true
+}
+
+// DecodeVNI converts text typed in the VNI input method without an IME
+// into Vietnamese: "xin chao2" becomes "xin chào" and "d9u7o7ng2" becomes
+// "đường". Digits 1 to 5 are tones (0 removes it), 6 is the circumflex, 7
+// the horn, 8 the breve and 9 turns d into đ. Numbers are unchanged.
+func DecodeVNI(s string) string {
+	return decodeWords(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }, decodeVNIWord)
+}
+
+// decodeVNIWord decodes one VNI word
+func decodeVNIWord(word []rune) string {
+	var letters []typedLetter
+	tone := toneNone
+
+	for _, r := range word {
+		if r >= '0' && r <= '9' && len(letters) > 0 && hasTypedLetter(letters) {
+			if applyVNIDigit(letters, r, &tone) {
+				continue
+			}
+		}
+		letters = append(letters, typedLetter{r: r})
+	}
+
+	return composeTyped(letters, tone)
+}
+
+// applyVNIDigit applies a VNI key to the letters typed so far
+func applyVNIDigit(letters []typedLetter, digit rune, tone *int) bool {
+	mark := func(bases string, m rune) bool {
+		for i := len(letters) - 1; i >= 0; i-- {
+			if strings.ContainsRune(bases, unicode.ToLower(letters[i].r)) && letters[i].mark == 0 {
+				letters[i].mark = m
+				if m == markHorn && unicode.ToLower(letters[i].r) == 'o' && i > 0 && letters[i-1].lowerIs('u') {
+					letters[i-1].mark = markHorn
+				}
+				return true
+			}
+		}
+		return false
+	}
+
+	switch digit {
+	case '1', '2', '3', '4', '5', '0':
+		if !hasTypedVowel(letters) {
+			return false
+		}
+		*tone = map[rune]int{'0': toneNone, '1': toneAcute, '2': toneGrave, '3': toneHook, '4': toneTilde, '5': toneDot}[digit]
+		return true
+	case '6':
+		return mark("aeo", markCircumflex)
+	case '7':
+		return mark("ou", markHorn)
+	case '8':
+		return mark("a", markBreve)
+	case '9':
+		for i := len(letters) - 1; i >= 0; i-- {
+			if letters[i].lowerIs('d') {
+				letters[i].r = withCase('đ', letters[i].r)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeWords applies decode to every run of runes accepted by inWord
+func decodeWords(s string, inWord func(rune) bool, decode func([]rune) string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			b.WriteString(decode(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range s {
+		if inWord(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// hasTypedVowel reports whether any letter is a vowel
+func hasTypedVowel(letters []typedLetter) bool {
+	for _, l := range letters {
+		if isTypedVowel(l.r) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTypedLetter reports whether any typed rune is a letter
+func hasTypedLetter(letters []typedLetter) bool {
+	for _, l := range letters {
+		if unicode.IsLetter(l.r) {
+			return true
+		}
+	}
+	return false
+}
+
+// withCase returns r in the case of like
+func withCase(r, like rune) rune {
+	if unicode.IsUpper(like) {
+		return unicode.ToUpper(r)
+	}
+	return r
+}
+
+// composeTyped places tone on the right vowel and composes the letters
+func composeTyped(letters []typedLetter, tone int) string {
+	toneAt := -1
+	if tone != toneNone {
+		toneAt = toneVowel(letters)
+	}
+
+	var b strings.Builder
+	for i, l := range letters {
+		v := vowel{base: l.r, mark: l.mark}
+		if i == toneAt {
+			v.tone = tone
+		}
+		if r, ok := runesByVowel[v]; ok {
+			b.WriteRune(r)
+			continue
+		}
+		// Marks that do not apply to the letter are dropped
+		b.WriteRune(l.r)
+	}
+	return b.String()
+}
+
+// toneVowel returns the index of the vowel that carries the tone of a
+// syllable, using the traditional placement ("hòa", "thúy"), or -1
+func toneVowel(letters []typedLetter) int {
+	start := 0
+	// The u of qu and the i of gi belong to the consonant
+	if len(letters) > 2 && letters[0].lowerIs('q') && letters[1].lowerIs('u') {
+		start = 2
+	}
+	if len(letters) > 2 && letters[0].lowerIs('g') && letters[1].lowerIs('i') && isTypedVowel(letters[2].r) {
+		start = 2
+	}
+
+	first := -1
+	for i := start; i < len(letters); i++ {
+		if isTypedVowel(letters[i].r) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return -1
+	}
+	end := first
+	for end+1 < len(letters) && isTypedVowel(letters[end+1].r) {
+		end++
+	}
+
+	// A vowel with a mark takes the tone, the last one in "ươ"
+	for i := end; i >= first; i-- {
+		if letters[i].mark != 0 {
+			return i
+		}
+	}
+
+	switch {
+	case first == end:
+		return first
+	case end < len(letters)-1:
+		// Followed by a final consonant: "hoàng", "thuận"
+		return end
+	case end-first >= 2:
+		return first + 1
+	default:
+		return first
+	}
+}
@@ -0,0 +1,402 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// unicodeNFD is the canonical decomposition of every Vietnamese vowel, as
+// produced by a reference Unicode implementation
+var unicodeNFD = map[string]string{
+	"a": "a",
+	"à": "a\u0300",
+	"á": "a\u0301",
+	"ả": "a\u0309",
+	"ã": "a\u0303",
+	"ạ": "a\u0323",
+	"ă": "a\u0306",
+	"ằ": "a\u0306\u0300",
+	"ắ": "a\u0306\u0301",
+	"ẳ": "a\u0306\u0309",
+	"ẵ": "a\u0306\u0303",
+	"ặ": "a\u0323\u0306",
+	"â": "a\u0302",
+	"ầ": "a\u0302\u0300",
+	"ấ": "a\u0302\u0301",
+	"ẩ": "a\u0302\u0309",
+	"ẫ": "a\u0302\u0303",
+	"ậ": "a\u0323\u0302",
+	"e": "e",
+	"è": "e\u0300",
+	"é": "e\u0301",
+	"ẻ": "e\u0309",
+	"ẽ": "e\u0303",
+	"ẹ": "e\u0323",
+	"ê": "e\u0302",
+	"ề": "e\u0302\u0300",
+	"ế": "e\u0302\u0301",
+	"ể": "e\u0302\u0309",
+	"ễ": "e\u0302\u0303",
+	"ệ": "e\u0323\u0302",
+	"i": "i",
+	"ì": "i\u0300",
+	"í": "i\u0301",
+	"ỉ": "i\u0309",
+	"ĩ": "i\u0303",
+	"ị": "i\u0323",
+	"o": "o",
+	"ò": "o\u0300",
+	"ó": "o\u0301",
+	"ỏ": "o\u0309",
+	"õ": "o\u0303",
+	"ọ": "o\u0323",
+	"ô": "o\u0302",
+	"ồ": "o\u0302\u0300",
+	"ố": "o\u0302\u0301",
+	"ổ": "o\u0302\u0309",
+	"ỗ": "o\u0302\u0303",
+	"ộ": "o\u0323\u0302",
+	"ơ": "o\u031b",
+	"ờ": "o\u031b\u0300",
+	"ớ": "o\u031b\u0301",
+	"ở": "o\u031b\u0309",
+	"ỡ": "o\u031b\u0303",
+	"ợ": "o\u031b\u0323",
+	"u": "u",
+	"ù": "u\u0300",
+	"ú": "u\u0301",
+	"ủ": "u\u0309",
+	"ũ": "u\u0303",
+	"ụ": "u\u0323",
+	"ư": "u\u031b",
+	"ừ": "u\u031b\u0300",
+	"ứ": "u\u031b\u0301",
+	"ử": "u\u031b\u0309",
+	"ữ": "u\u031b\u0303",
+	"ự": "u\u031b\u0323",
+	"y": "y",
+	"ỳ": "y\u0300",
+	"ý": "y\u0301",
+	"ỷ": "y\u0309",
+	"ỹ": "y\u0303",
+	"ỵ": "y\u0323",
+	"A": "A",
+	"À": "A\u0300",
+	"Á": "A\u0301",
+	"Ả": "A\u0309",
+	"Ã": "A\u0303",
+	"Ạ": "A\u0323",
+	"Ă": "A\u0306",
+	"Ằ": "A\u0306\u0300",
+	"Ắ": "A\u0306\u0301",
+	"Ẳ": "A\u0306\u0309",
+	"Ẵ": "A\u0306\u0303",
+	"Ặ": "A\u0323\u0306",
+	"Â": "A\u0302",
+	"Ầ": "A\u0302\u0300",
+	"Ấ": "A\u0302\u0301",
+	"Ẩ": "A\u0302\u0309",
+	"Ẫ": "A\u0302\u0303",
+	"Ậ": "A\u0323\u0302",
+	"E": "E",
+	"È": "E\u0300",
+	"É": "E\u0301",
+	"Ẻ": "E\u0309",
+	"Ẽ": "E\u0303",
+	"Ẹ": "E\u0323",
+	"Ê": "E\u0302",
+	"Ề": "E\u0302\u0300",
+	"Ế": "E\u0302\u0301",
+	"Ể": "E\u0302\u0309",
+	"Ễ": "E\u0302\u0303",
+	"Ệ": "E\u0323\u0302",
+	"I": "I",
+	"Ì": "I\u0300",
+	"Í": "I\u0301",
+	"Ỉ": "I\u0309",
+	"Ĩ": "I\u0303",
+	"Ị": "I\u0323",
+	"O": "O",
+	"Ò": "O\u0300",
+	"Ó": "O\u0301",
+	"Ỏ": "O\u0309",
+	"Õ": "O\u0303",
+	"Ọ": "O\u0323",
+	"Ô": "O\u0302",
+	"Ồ": "O\u0302\u0300",
+	"Ố": "O\u0302\u0301",
+	"Ổ": "O\u0302\u0309",
+	"Ỗ": "O\u0302\u0303",
+	"Ộ": "O\u0323\u0302",
+	"Ơ": "O\u031b",
+	"Ờ": "O\u031b\u0300",
+	"Ớ": "O\u031b\u0301",
+	"Ở": "O\u031b\u0309",
+	"Ỡ": "O\u031b\u0303",
+	"Ợ": "O\u031b\u0323",
+	"U": "U",
+	"Ù": "U\u0300",
+	"Ú": "U\u0301",
+	"Ủ": "U\u0309",
+	"Ũ": "U\u0303",
+	"Ụ": "U\u0323",
+	"Ư": "U\u031b",
+	"Ừ": "U\u031b\u0300",
+	"Ứ": "U\u031b\u0301",
+	"Ử": "U\u031b\u0309",
+	"Ữ": "U\u031b\u0303",
+	"Ự": "U\u031b\u0323",
+	"Y": "Y",
+	"Ỳ": "Y\u0300",
+	"Ý": "Y\u0301",
+	"Ỷ": "Y\u0309",
+	"Ỹ": "Y\u0303",
+	"Ỵ": "Y\u0323",
+}
+
+func TestNormalizeNFD_MatchesUnicode(t *testing.T) {
+	for composed, decomposed := range unicodeNFD {
+		if got := NormalizeNFD(composed); got != decomposed {
+			t.Errorf("NormalizeNFD(%q) = %q, want %q", composed, got, decomposed)
+		}
+		if got := NormalizeNFC(decomposed); got != composed {
+			t.Errorf("NormalizeNFC(%q) = %q, want %q", decomposed, got, composed)
+		}
+	}
+}
+
+func TestNormalizeNFC(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Vie\u0323\u0302t Nam", "Việt Nam"},
+		// Marks in non-canonical order, as some keyboards type them
+		{"Vie\u0302\u0323t Nam", "Việt Nam"},
+		// Partially composed letters
+		{"Vi\u00ea\u0323t", "Việt"},
+		{"tr\u01b0\u01a1\u0300ng", "trường"},
+		{"ngu\u031bo\u031b\u0300i", "người"},
+		{"Đà Nẵng", "Đà Nẵng"},
+		// Marks that do not form a Vietnamese letter are kept
+		{"e\u0306", "e\u0306"},
+		{"a\u0300\u0301", "à\u0301"},
+		{"n\u0303", "n\u0303"},
+		{"hello, world", "hello, world"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeNFC(tt.input); got != tt.want {
+			t.Errorf("NormalizeNFC(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeNFD_RoundTrip(t *testing.T) {
+	texts := []string{
+		"Tiếng Việt có dấu",
+		"Cộng hòa Xã hội Chủ nghĩa Việt Nam",
+		"ĐƯỜNG PHỐ HÀ NỘI",
+		"Ủy ban nhân dân quận Hoàn Kiếm",
+		"Mặt trời mọc ở đằng đông",
+	}
+
+	for _, text := range texts {
+		decomposed := NormalizeNFD(text)
+		if decomposed == text {
+			t.Errorf("NormalizeNFD(%q) did not decompose", text)
+		}
+		if got := NormalizeNFC(decomposed); got != text {
+			t.Errorf("NormalizeNFC(NormalizeNFD(%q)) = %q", text, got)
+		}
+	}
+}
+
+func TestRemoveDiacritics(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Tiếng Việt", "Tieng Viet"},
+		{"Đường phố", "Duong pho"},
+		{"đồng hồ", "dong ho"},
+		{"ĐẶNG THỊ HƯƠNG", "DANG THI HUONG"},
+		{"Cảm ơn bạn nhiều", "Cam on ban nhieu"},
+		{"Phở bò tái", "Pho bo tai"},
+		{"Nguyễn Thị Minh Khai", "Nguyen Thi Minh Khai"},
+		{"khuỷu tay", "khuyu tay"},
+		{"Vie\u0323\u0302t Nam", "Viet Nam"},
+		{"giá 100.000đ", "gia 100.000d"},
+		{"hello world", "hello world"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := RemoveDiacritics(tt.input); got != tt.want {
+			t.Errorf("RemoveDiacritics(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	// Every vowel loses its marks, in both cases
+	for composed, decomposed := range unicodeNFD {
+		want := decomposed[:1]
+		if got := RemoveDiacritics(composed); got != want {
+			t.Errorf("RemoveDiacritics(%q) = %q, want %q", composed, got, want)
+		}
+	}
+}
+
+func TestFoldCase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"XIN CHÀO", "xin chào"},
+		{"Xin Chào", "xin chào"},
+		{"xin cha\u0300o", "xin chào"},
+		{"ĐƯỜNG", "đường"},
+		{"ỦY BAN", "ủy ban"},
+	}
+
+	for _, tt := range tests {
+		if got := FoldCase(tt.input); got != tt.want {
+			t.Errorf("FoldCase(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeForMatch(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"  Xin CHÀO, bạn!  ", "xin chao ban"},
+		{"xin chao ban", "xin chao ban"},
+		{"Xin cha\u0300o ba\u0323n", "xin chao ban"},
+		{"Đơn hàng #1234 đâu rồi???", "don hang 1234 dau roi"},
+		{"giá\tbao\nnhiêu", "gia bao nhieu"},
+		{"!!!", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeForMatch(tt.input); got != tt.want {
+			t.Errorf("NormalizeForMatch(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestIsVietnameseSyllable(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"chào", true},
+		{"Nghiêng", true},
+		{"quốc", true},
+		{"người", true},
+		{"khuya", true},
+		{"giờ", true},
+		{"giếng", true},
+		{"cha\u0300o", true},
+		{"a", true},
+		{"start", false},
+		{"free", false},
+		{"cảt", false},
+		{"yé", false},
+		{"tea", false},
+		{"shop", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsVietnameseSyllable(tt.word); got != tt.want {
+			t.Errorf("IsVietnameseSyllable(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeTelex(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"xin chaof", "xin chào"},
+		{"Vieejt Nam", "Việt Nam"},
+		{"vieetj", "việt"},
+		{"tieesng", "tiếng"},
+		{"dduwowngf", "đường"},
+		{"DDUWOWNGF", "ĐƯỜNG"},
+		{"nguowif", "người"},
+		{"cuwus", "cứu"},
+		{"camr own", "cảm ơn"},
+		{"nawng", "năng"},
+		{"hoaf", "hòa"},
+		{"khoer", "khỏe"},
+		{"thuys", "thúy"},
+		{"hoangf", "hoàng"},
+		{"ngoaif", "ngoài"},
+		{"quas", "quá"},
+		{"quyeenf", "quyền"},
+		{"giaf", "già"},
+		{"gif", "gì"},
+		{"tw", "tư"},
+		{"ddi ddaau", "đi đâu"},
+		{"xin chào", "xin chào"},
+		{"ok, 123", "ok, 123"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DecodeTelex(tt.input); got != tt.want {
+			t.Errorf("DecodeTelex(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeVNI(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"xin chao2", "xin chào"},
+		{"Vie65t Nam", "Việt Nam"},
+		{"Vie6t5 Nam", "Việt Nam"},
+		{"d9u7o7ng2", "đường"},
+		{"D9U7O7NG2", "ĐƯỜNG"},
+		{"nguoi72", "người"},
+		{"ca3m o7n", "cảm ơn"},
+		{"na8ng", "năng"},
+		{"hoa2", "hòa"},
+		{"qua1", "quá"},
+		{"gia2", "già"},
+		{"d9i d9a6u", "đi đâu"},
+		{"ma0", "ma"},
+		{"nam 2024", "nam 2024"},
+		{"phong 101", "phong 101"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DecodeVNI(tt.input); got != tt.want {
+			t.Errorf("DecodeVNI(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDecodedInputMatchesTypedText(t *testing.T) {
+	typed := "Cảm ơn, đường này đi đâu?"
+	telex := "Camr own, dduwowngf nayf ddi ddaau?"
+	vni := "Ca3m o7n, d9u7o7ng2 na2y d9i d9a6u?"
+
+	if got := DecodeTelex(telex); got != typed {
+		t.Errorf("DecodeTelex(%q) = %q, want %q", telex, got, typed)
+	}
+	if got := DecodeVNI(vni); got != typed {
+		t.Errorf("DecodeVNI(%q) = %q, want %q", vni, got, typed)
+	}
+	if key := NormalizeForMatch(typed); !strings.HasPrefix(key, "cam on duong") {
+		t.Errorf("NormalizeForMatch(%q) = %q", typed, key)
+	}
+}
@@ -0,0 +1,399 @@
+package zalobot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// DefaultWatchdogInterval is the default time between webhook checks
+const DefaultWatchdogInterval = time.Minute
+
+// WatchdogConfig represents configuration for a WebhookWatchdog
+type WatchdogConfig struct {
+	// Webhook is the desired webhook registration. Its URL is compared with
+	// the registered one, and it is registered again when they differ.
+	Webhook types.WebhookConfig
+	// Interval is the time between checks (default: DefaultWatchdogInterval)
+	Interval time.Duration
+	// MaxPendingUpdates raises a backlog event and marks the webhook
+	// unhealthy once PendingUpdateCount exceeds it (0 disables the check)
+	MaxPendingUpdates int
+	// DisableRepair only reports drift instead of registering the webhook again
+	DisableRepair bool
+	// OnEvent, if set, is called for every event, in order, from a goroutine
+	// of its own, so it may call Check or Stop. Events still queued when the
+	// watchdog stops are dropped.
+	OnEvent func(WatchdogEvent)
+}
+
+// WatchdogEventType identifies what a WatchdogEvent reports
+type WatchdogEventType string
+
+// Watchdog event types
+const (
+	// WatchdogEventCheckFailed: getWebhookInfo failed
+	WatchdogEventCheckFailed WatchdogEventType = "check_failed"
+	// WatchdogEventDrift: the registered URL differs from the desired one
+	WatchdogEventDrift WatchdogEventType = "drift"
+	// WatchdogEventRepaired: the webhook was registered again after drift
+	WatchdogEventRepaired WatchdogEventType = "repaired"
+	// WatchdogEventRepairFailed: registering the webhook again failed
+	WatchdogEventRepairFailed WatchdogEventType = "repair_failed"
+	// WatchdogEventDeliveryError: Zalo reported a new delivery error
+	WatchdogEventDeliveryError WatchdogEventType = "delivery_error"
+	// WatchdogEventBacklog: pending updates passed MaxPendingUpdates
+	WatchdogEventBacklog WatchdogEventType = "backlog"
+	// WatchdogEventBacklogCleared: pending updates are back under MaxPendingUpdates
+	WatchdogEventBacklogCleared WatchdogEventType = "backlog_cleared"
+)
+
+// WatchdogEvent is a change noticed by a WebhookWatchdog
+type WatchdogEvent struct {
+	Type    WatchdogEventType
+	Time    time.Time
+	Message string
+	// Info is the webhook information of the check, nil if it failed
+	Info *types.WebhookInfo
+	// Err is set for check_failed and repair_failed events
+	Err error
+}
+
+// WatchdogStatus is a snapshot of a WebhookWatchdog for health checks
+type WatchdogStatus struct {
+	Running             bool       `json:"running"`
+	Healthy             bool       `json:"healthy"`
+	URL                 string     `json:"url"`
+	RegisteredURL       string     `json:"registered_url,omitempty"`
+	LastCheckAt         *time.Time `json:"last_check_at,omitempty"`
+	LastCheckError      string     `json:"last_check_error,omitempty"`
+	PendingUpdateCount  int        `json:"pending_update_count"`
+	LastDeliveryError   string     `json:"last_delivery_error,omitempty"`
+	LastDeliveryErrorAt *time.Time `json:"last_delivery_error_at,omitempty"`
+	Repairs             int64      `json:"repairs"`
+	Reasons             []string   `json:"reasons,omitempty"`
+}
+
+// WebhookWatchdog periodically checks the webhook registration with
+// getWebhookInfo, registers the webhook again when it drifted away from the
+// desired configuration, and reports delivery errors and update backlogs.
+type WebhookWatchdog struct {
+	bot    *BotAPI
+	config WatchdogConfig
+
+	// checkMu serializes checks so only one of them repairs the webhook
+	checkMu sync.Mutex
+
+	mu                sync.Mutex
+	status            WatchdogStatus
+	lastDeliveryError time.Time
+	backlogged        bool
+
+	// events queues events for OnEvent; notify wakes the delivery goroutine
+	eventMu sync.Mutex
+	events  []WatchdogEvent
+	notify  chan struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartWebhookWatchdog starts a watchdog for the bot's webhook, replacing a
+// running one. The first check runs immediately. The watchdog stops with
+// Stop or when the bot is closed, and its status is included in Health.
+func (b *BotAPI) StartWebhookWatchdog(config WatchdogConfig) (*WebhookWatchdog, error) {
+	if err := validateWebhookURL(config.Webhook.URL); err != nil {
+		return nil, types.NewValidationError(err.Error())
+	}
+	if err := config.Webhook.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultWatchdogInterval
+	}
+	if config.MaxPendingUpdates < 0 {
+		return nil, types.NewValidationError("max pending updates cannot be negative")
+	}
+
+	ctx, cancel := context.WithCancel(b.ctx)
+	w := &WebhookWatchdog{
+		bot:    b,
+		config: config,
+		status: WatchdogStatus{Running: true, Healthy: true, URL: config.Webhook.URL},
+		notify: make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	previous := b.watchdog
+	b.watchdog = w
+	b.mu.Unlock()
+	if previous != nil {
+		previous.Stop()
+	}
+
+	if config.OnEvent != nil {
+		go w.deliver(ctx)
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+// WebhookWatchdog returns the running watchdog, or nil if none was started
+func (b *BotAPI) WebhookWatchdog() *WebhookWatchdog {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.watchdog
+}
+
+// Stop stops the watchdog and waits for a check in progress to finish. It
+// may be called from OnEvent.
+func (w *WebhookWatchdog) Stop() {
+	w.cancel()
+	<-w.done
+
+	w.bot.mu.Lock()
+	if w.bot.watchdog == w {
+		w.bot.watchdog = nil
+	}
+	w.bot.mu.Unlock()
+}
+
+// Status returns a snapshot of the watchdog state
+func (w *WebhookWatchdog) Status() WatchdogStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := w.status
+	status.Reasons = append([]string(nil), w.status.Reasons...)
+	return status
+}
+
+// run checks the webhook every interval until ctx is cancelled
+func (w *WebhookWatchdog) run(ctx context.Context) {
+	defer close(w.done)
+	defer func() {
+		w.mu.Lock()
+		w.status.Running = false
+		w.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check runs one check immediately, outside the regular interval. A check
+// already in progress finishes first. ctx cancels the API requests.
+func (w *WebhookWatchdog) Check(ctx context.Context) {
+	w.checkMu.Lock()
+	defer w.checkMu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	info, err := w.bot.GetWebhookInfoWithContext(ctx)
+	if err != nil {
+		w.update(func(s *WatchdogStatus) {
+			s.LastCheckAt = &now
+			s.LastCheckError = err.Error()
+		})
+		w.emit(WatchdogEvent{Type: WatchdogEventCheckFailed, Time: now, Message: "failed to get webhook info", Err: err})
+		return
+	}
+	if info == nil {
+		info = &types.WebhookInfo{}
+	}
+
+	w.update(func(s *WatchdogStatus) {
+		s.LastCheckAt = &now
+		s.LastCheckError = ""
+		s.RegisteredURL = info.URL
+		s.PendingUpdateCount = info.PendingUpdateCount
+	})
+
+	w.checkDeliveryError(now, info)
+	w.checkBacklog(now, info)
+	w.checkDrift(ctx, now, info)
+}
+
+// checkDeliveryError reports a delivery error newer than the last one seen
+func (w *WebhookWatchdog) checkDeliveryError(now time.Time, info *types.WebhookInfo) {
+	if info.LastErrorMessage == "" && info.LastErrorDate.IsZero() {
+		return
+	}
+
+	w.mu.Lock()
+	seen := !info.LastErrorDate.After(w.lastDeliveryError) && w.status.LastDeliveryError == info.LastErrorMessage
+	if !seen {
+		w.lastDeliveryError = info.LastErrorDate
+		w.status.LastDeliveryError = info.LastErrorMessage
+		if !info.LastErrorDate.IsZero() {
+			at := info.LastErrorDate
+			w.status.LastDeliveryErrorAt = &at
+		}
+	}
+	w.mu.Unlock()
+
+	if !seen {
+		w.emit(WatchdogEvent{Type: WatchdogEventDeliveryError, Time: now, Message: info.LastErrorMessage, Info: info})
+	}
+}
+
+// checkBacklog reports pending updates passing or falling back under the threshold
+func (w *WebhookWatchdog) checkBacklog(now time.Time, info *types.WebhookInfo) {
+	if w.config.MaxPendingUpdates <= 0 {
+		return
+	}
+
+	backlogged := info.PendingUpdateCount > w.config.MaxPendingUpdates
+	w.mu.Lock()
+	changed := backlogged != w.backlogged
+	w.backlogged = backlogged
+	w.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if backlogged {
+		w.emit(WatchdogEvent{Type: WatchdogEventBacklog, Time: now, Info: info,
+			Message: fmt.Sprintf("%d pending updates exceed %d", info.PendingUpdateCount, w.config.MaxPendingUpdates)})
+	} else {
+		w.emit(WatchdogEvent{Type: WatchdogEventBacklogCleared, Time: now, Info: info,
+			Message: fmt.Sprintf("%d pending updates", info.PendingUpdateCount)})
+	}
+}
+
+// checkDrift registers the webhook again when the registered URL differs
+// from the desired one
+func (w *WebhookWatchdog) checkDrift(ctx context.Context, now time.Time, info *types.WebhookInfo) {
+	if info.URL == w.config.Webhook.URL {
+		return
+	}
+
+	message := fmt.Sprintf("registered webhook URL %q differs from %q", info.URL, w.config.Webhook.URL)
+	if info.URL == "" {
+		message = "webhook is not registered"
+	}
+	w.emit(WatchdogEvent{Type: WatchdogEventDrift, Time: now, Message: message, Info: info})
+
+	if w.config.DisableRepair {
+		return
+	}
+
+	if err := w.repair(ctx); err != nil {
+		w.emit(WatchdogEvent{Type: WatchdogEventRepairFailed, Time: time.Now(), Message: "failed to register webhook", Info: info, Err: err})
+		return
+	}
+
+	w.update(func(s *WatchdogStatus) {
+		s.RegisteredURL = w.config.Webhook.URL
+		s.Repairs++
+	})
+	w.emit(WatchdogEvent{Type: WatchdogEventRepaired, Time: time.Now(), Message: "webhook registered again", Info: info})
+}
+
+// repair registers the desired webhook with the bot's current secret token,
+// so a secret rotated since the watchdog started is kept. Pending updates
+// are never dropped by a repair.
+func (w *WebhookWatchdog) repair(ctx context.Context) error {
+	w.bot.reconfigureMu.Lock()
+	defer w.bot.reconfigureMu.Unlock()
+
+	config := w.config.Webhook
+	if secretToken := w.bot.webhookService.GetSecretToken(); secretToken != "" {
+		config.SecretToken = secretToken
+	}
+	config.DropPendingUpdates = false
+
+	return w.bot.SetWebhookWithContext(ctx, config)
+}
+
+// update changes the status under the lock and re-evaluates Healthy
+func (w *WebhookWatchdog) update(change func(*WatchdogStatus)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	change(&w.status)
+
+	var reasons []string
+	if w.status.LastCheckError != "" {
+		reasons = append(reasons, "webhook check failed: "+w.status.LastCheckError)
+	}
+	if w.status.LastCheckAt != nil && w.status.LastCheckError == "" && w.status.RegisteredURL != w.status.URL {
+		reasons = append(reasons, "webhook URL drifted")
+	}
+	if w.config.MaxPendingUpdates > 0 && w.status.PendingUpdateCount > w.config.MaxPendingUpdates {
+		reasons = append(reasons, fmt.Sprintf("%d pending webhook updates", w.status.PendingUpdateCount))
+	}
+	w.status.Reasons = reasons
+	w.status.Healthy = len(reasons) == 0
+}
+
+// emit logs event and queues it for OnEvent
+func (w *WebhookWatchdog) emit(event WatchdogEvent) {
+	fields := []utils.Field{
+		{Key: "event", Value: string(event.Type)},
+		{Key: "message", Value: event.Message},
+	}
+	if event.Err != nil {
+		fields = append(fields, utils.Field{Key: "error", Value: event.Err.Error()})
+	}
+
+	switch event.Type {
+	case WatchdogEventRepaired, WatchdogEventBacklogCleared:
+		w.bot.logger().Info("Webhook watchdog", fields...)
+	default:
+		w.bot.logger().Warn("Webhook watchdog", fields...)
+	}
+
+	if w.config.OnEvent != nil {
+		w.eventMu.Lock()
+		w.events = append(w.events, event)
+		w.eventMu.Unlock()
+
+		select {
+		case w.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// deliver passes queued events to OnEvent until ctx is cancelled
+func (w *WebhookWatchdog) deliver(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.notify:
+		}
+
+		w.eventMu.Lock()
+		events := w.events
+		w.events = nil
+		w.eventMu.Unlock()
+
+		for _, event := range events {
+			if ctx.Err() != nil {
+				return
+			}
+			w.config.OnEvent(event)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package zalobot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+)
+
+// fakeWebhookServer serves getWebhookInfo and setWebhook from mutable state
+type fakeWebhookServer struct {
+	mu      sync.Mutex
+	info    types.WebhookInfo
+	secrets []string
+	failSet bool
+}
+
+func (f *fakeWebhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/getWebhookInfo"):
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": f.info})
+	case strings.HasSuffix(r.URL.Path, "/setWebhook"):
+		if f.failSet {
+			w.Write([]byte(`{"ok":false,"error_code":500,"description":"Internal Error"}`))
+			return
+		}
+		var body struct {
+			URL         string `json:"url"`
+			SecretToken string `json:"secret_token"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.info.URL = body.URL
+		f.secrets = append(f.secrets, body.SecretToken)
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+func (f *fakeWebhookServer) set(change func(*fakeWebhookServer)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	change(f)
+}
+
+func TestWebhookWatchdog(t *testing.T) {
+	fake := &fakeWebhookServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	events := make(chan WatchdogEvent, 16)
+	watchdog, err := bot.StartWebhookWatchdog(WatchdogConfig{
+		Webhook:           types.WebhookConfig{URL: "https://example.com/webhook", SecretToken: "initial-secret"},
+		Interval:          time.Hour,
+		MaxPendingUpdates: 10,
+		OnEvent:           func(event WatchdogEvent) { events <- event },
+	})
+	if err != nil {
+		t.Fatalf("StartWebhookWatchdog() error = %v", err)
+	}
+	defer watchdog.Stop()
+
+	expect := func(want ...WatchdogEventType) {
+		t.Helper()
+		for _, eventType := range want {
+			select {
+			case event := <-events:
+				if event.Type != eventType {
+					t.Fatalf("event = %s (%s), want %s", event.Type, event.Message, eventType)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %s event", eventType)
+			}
+		}
+	}
+
+	t.Run("registers a missing webhook", func(t *testing.T) {
+		expect(WatchdogEventDrift, WatchdogEventRepaired)
+
+		status := watchdog.Status()
+		if !status.Running || !status.Healthy || status.Repairs != 1 {
+			t.Errorf("Status() = %+v, want running, healthy, 1 repair", status)
+		}
+		if status.RegisteredURL != "https://example.com/webhook" {
+			t.Errorf("RegisteredURL = %q", status.RegisteredURL)
+		}
+	})
+
+	t.Run("repairs with the current secret", func(t *testing.T) {
+		bot.SetWebhookSecretToken("rotated-secret")
+		fake.set(func(f *fakeWebhookServer) { f.info.URL = "https://other.example.com/hook" })
+
+		watchdog.Check(context.Background())
+		expect(WatchdogEventDrift, WatchdogEventRepaired)
+
+		fake.set(func(f *fakeWebhookServer) {
+			if got := f.secrets[len(f.secrets)-1]; got != "rotated-secret" {
+				t.Errorf("registered secret = %q, want rotated-secret", got)
+			}
+		})
+	})
+
+	t.Run("reports new delivery errors once", func(t *testing.T) {
+		fake.set(func(f *fakeWebhookServer) {
+			f.info.LastErrorDate = time.Now().Truncate(time.Second)
+			f.info.LastErrorMessage = "Connection timed out"
+		})
+
+		watchdog.Check(context.Background())
+		expect(WatchdogEventDeliveryError)
+		watchdog.Check(context.Background())
+
+		select {
+		case event := <-events:
+			t.Errorf("unexpected %s event for a delivery error already reported", event.Type)
+		default:
+		}
+		if got := watchdog.Status().LastDeliveryError; got != "Connection timed out" {
+			t.Errorf("LastDeliveryError = %q", got)
+		}
+	})
+
+	t.Run("backlog marks the bot not ready", func(t *testing.T) {
+		fake.set(func(f *fakeWebhookServer) { f.info.PendingUpdateCount = 25 })
+		watchdog.Check(context.Background())
+		expect(WatchdogEventBacklog)
+
+		health := bot.Health(context.Background())
+		if health.Ready || health.Webhook == nil || health.Webhook.PendingUpdateCount != 25 {
+			t.Errorf("Health() = %+v, want not ready with 25 pending webhook updates", health)
+		}
+
+		fake.set(func(f *fakeWebhookServer) { f.info.PendingUpdateCount = 0 })
+		watchdog.Check(context.Background())
+		expect(WatchdogEventBacklogCleared)
+
+		if health := bot.Health(context.Background()); !health.Ready {
+			t.Errorf("Health() reasons = %v, want ready", health.Reasons)
+		}
+	})
+
+	t.Run("failed repair stays unhealthy", func(t *testing.T) {
+		fake.set(func(f *fakeWebhookServer) {
+			f.info.URL = ""
+			f.failSet = true
+		})
+		watchdog.Check(context.Background())
+		expect(WatchdogEventDrift, WatchdogEventRepairFailed)
+
+		if status := watchdog.Status(); status.Healthy {
+			t.Errorf("Status() = %+v, want unhealthy", status)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		watchdog.Stop()
+		if bot.WebhookWatchdog() != nil {
+			t.Error("WebhookWatchdog() should be nil after Stop")
+		}
+		if watchdog.Status().Running {
+			t.Error("Status().Running should be false after Stop")
+		}
+	})
+}
+
+func TestWebhookWatchdog_ConcurrentChecks(t *testing.T) {
+	fake := &fakeWebhookServer{info: types.WebhookInfo{URL: "https://example.com/webhook"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	watchdog, err := bot.StartWebhookWatchdog(WatchdogConfig{
+		Webhook:  types.WebhookConfig{URL: "https://example.com/webhook"},
+		Interval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("StartWebhookWatchdog() error = %v", err)
+	}
+	defer watchdog.Stop()
+
+	// Wait for the initial check, which finds nothing to repair
+	deadline := time.Now().Add(5 * time.Second)
+	for watchdog.Status().LastCheckAt == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the initial check")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	fake.set(func(f *fakeWebhookServer) { f.info.URL = "" })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watchdog.Check(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	fake.set(func(f *fakeWebhookServer) {
+		if len(f.secrets) != 1 {
+			t.Errorf("setWebhook called %d times, want 1", len(f.secrets))
+		}
+	})
+	if status := watchdog.Status(); status.Repairs != 1 || !status.Healthy {
+		t.Errorf("Status() = %+v, want healthy with 1 repair", status)
+	}
+}
+
+func TestWebhookWatchdog_StopFromOnEvent(t *testing.T) {
+	fake := &fakeWebhookServer{failSet: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	// Give up after the first failed repair
+	started := make(chan *WebhookWatchdog, 1)
+	stopped := make(chan struct{})
+	watchdog, err := bot.StartWebhookWatchdog(WatchdogConfig{
+		Webhook:  types.WebhookConfig{URL: "https://example.com/webhook"},
+		Interval: time.Hour,
+		OnEvent: func(event WatchdogEvent) {
+			if event.Type != WatchdogEventRepairFailed {
+				return
+			}
+			w := <-started
+			w.Check(context.Background())
+			w.Stop()
+			close(stopped)
+		},
+	})
+	if err != nil {
+		t.Fatalf("StartWebhookWatchdog() error = %v", err)
+	}
+	started <- watchdog
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() called from OnEvent did not return")
+	}
+	if watchdog.Status().Running {
+		t.Error("Status().Running = true after Stop()")
+	}
+	if bot.WebhookWatchdog() != nil {
+		t.Error("WebhookWatchdog() still returns the stopped watchdog")
+	}
+}
+
+func TestWebhookWatchdog_CheckCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", types.WithBaseURL(server.URL), types.WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	watchdog, err := bot.StartWebhookWatchdog(WatchdogConfig{
+		Webhook:  types.WebhookConfig{URL: "https://example.com/webhook"},
+		Interval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("StartWebhookWatchdog() error = %v", err)
+	}
+
+	// The initial check is stuck on the server; Stop cancels it
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	watchdog.Stop()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Stop() took %v waiting for a hung check", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	watchdog.Check(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Check() took %v after its context expired", elapsed)
+	}
+	if status := watchdog.Status(); status.LastCheckError == "" {
+		t.Errorf("Status() = %+v, want a check error", status)
+	}
+}
+
+func TestBotAPI_StartWebhookWatchdog_Validation(t *testing.T) {
+	bot, err := New("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	for _, config := range []WatchdogConfig{
+		{Webhook: types.WebhookConfig{URL: "http://example.com/webhook"}},
+		{Webhook: types.WebhookConfig{URL: "https://example.com/webhook"}, MaxPendingUpdates: -1},
+	} {
+		if _, err := bot.StartWebhookWatchdog(config); err == nil {
+			t.Errorf("StartWebhookWatchdog(%+v) expected error", config)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package zalobot
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+// RouterConfig represents configuration for a WebhookRouter
+type RouterConfig struct {
+	// BaseURL is the public URL the router is reachable at, such as
+	// https://bots.example.com; webhook URLs are BaseURL + PathPrefix + key
+	BaseURL string
+	// PathPrefix is the path under which bots are routed (default: "/webhook/")
+	PathPrefix string
+	// Manager resolves bots registered without an explicit BotAPI, so routes
+	// follow token swaps made through the manager
+	Manager *BotManager
+	// Metrics receives per-bot request metrics when it implements
+	// utils.WebhookMetrics (default: each bot's own metrics collector)
+	Metrics utils.Metrics
+}
+
+// RouteConfig represents the per-bot settings of a webhook route
+type RouteConfig struct {
+	// Handler receives every update delivered to the bot
+	Handler UpdateHandler
+	// SecretToken, if set, replaces the bot's webhook secret token
+	SecretToken string
+	// MaxBodyBytes rejects larger request bodies with 413
+	// (default: DefaultMaxWebhookBodyBytes)
+	MaxBodyBytes int64
+	// MaxConcurrent rejects requests beyond this many in flight with 429
+	// (0 means unlimited)
+	MaxConcurrent int
+}
+
+// RouteStats is a snapshot of the requests served for one bot
+type RouteStats struct {
+	Requests    int64     `json:"requests"`
+	Accepted    int64     `json:"accepted"`
+	Rejected    int64     `json:"rejected"`
+	Failed      int64     `json:"failed"`
+	InFlight    int64     `json:"in_flight"`
+	LastRequest time.Time `json:"last_request,omitempty"`
+}
+
+// ReconcileResult reports the outcome of reconciling one bot's webhook
+type ReconcileResult struct {
+	BotKey  string
+	URL     string
+	Changed bool
+	Err     error
+}
+
+// WebhookRouter is an http.Handler that serves webhooks for many bots on one
+// server, routing /webhook/{botKey} to that bot's secret validation and
+// handler. Routes can be added and removed while the server is running.
+type WebhookRouter struct {
+	config RouterConfig
+
+	mu     sync.RWMutex
+	routes map[string]*webhookRoute
+}
+
+// webhookRoute is a registered bot with its limits and counters
+type webhookRoute struct {
+	bot    *BotAPI
+	config RouteConfig
+	slots  chan struct{}
+
+	requests    atomic.Int64
+	accepted    atomic.Int64
+	rejected    atomic.Int64
+	failed      atomic.Int64
+	inFlight    atomic.Int64
+	lastRequest atomic.Int64
+}
+
+// NewWebhookRouter creates a webhook router with no routes
+func NewWebhookRouter(config RouterConfig) *WebhookRouter {
+	if config.PathPrefix == "" {
+		config.PathPrefix = "/webhook/"
+	}
+	if !strings.HasPrefix(config.PathPrefix, "/") {
+		config.PathPrefix = "/" + config.PathPrefix
+	}
+	if !strings.HasSuffix(config.PathPrefix, "/") {
+		config.PathPrefix += "/"
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &WebhookRouter{
+		config: config,
+		routes: make(map[string]*webhookRoute),
+	}
+}
+
+// Handle registers or replaces the route for key. bot may be nil when the
+// router has a Manager, in which case the bot is looked up per request.
+func (r *WebhookRouter) Handle(key string, bot *BotAPI, config RouteConfig) error {
+	if key == "" || strings.Contains(key, "/") {
+		return types.NewValidationError("bot key must be non-empty and must not contain '/'")
+	}
+	if bot == nil && r.config.Manager == nil {
+		return types.NewValidationError("bot is required when the router has no manager")
+	}
+	if config.Handler == nil {
+		return types.NewValidationError("route handler is required")
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = DefaultMaxWebhookBodyBytes
+	}
+
+	route := &webhookRoute{bot: bot, config: config}
+	if config.MaxConcurrent > 0 {
+		route.slots = make(chan struct{}, config.MaxConcurrent)
+	}
+
+	if config.SecretToken != "" {
+		if target, ok := r.resolve(route, key); ok {
+			target.SetWebhookSecretToken(config.SecretToken)
+		}
+	}
+
+	r.mu.Lock()
+	r.routes[key] = route
+	r.mu.Unlock()
+	return nil
+}
+
+// Remove unregisters the route for key; later requests for it receive 404
+func (r *WebhookRouter) Remove(key string) {
+	r.mu.Lock()
+	delete(r.routes, key)
+	r.mu.Unlock()
+}
+
+// Keys returns the keys of all routes in sorted order
+func (r *WebhookRouter) Keys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]string, 0, len(r.routes))
+	for key := range r.routes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WebhookURL returns the public webhook URL of the bot registered under key
+func (r *WebhookRouter) WebhookURL(key string) string {
+	return r.config.BaseURL + r.config.PathPrefix + url.PathEscape(key)
+}
+
+// Stats returns request counters for every route
+func (r *WebhookRouter) Stats() map[string]RouteStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[string]RouteStats, len(r.routes))
+	for key, route := range r.routes {
+		stats[key] = route.stats()
+	}
+	return stats
+}
+
+// ServeHTTP routes a webhook request to the bot named in the path
+func (r *WebhookRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	key, ok := r.routeKey(req.URL.Path)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	r.mu.RLock()
+	route, ok := r.routes[key]
+	r.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	bot, ok := r.resolve(route, key)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	status := r.serve(w, req, key, bot, route)
+	route.record(status, start)
+	r.observe(bot, key, status, time.Since(start))
+}
+
+// serve handles a request for a resolved route and returns the response status
+func (r *WebhookRouter) serve(w http.ResponseWriter, req *http.Request, key string, bot *BotAPI, route *webhookRoute) int {
+	if route.slots != nil {
+		select {
+		case route.slots <- struct{}{}:
+			defer func() { <-route.slots }()
+		default:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Too many concurrent requests", http.StatusTooManyRequests)
+			return http.StatusTooManyRequests
+		}
+	}
+
+	route.inFlight.Add(1)
+	defer route.inFlight.Add(-1)
+
+	req = req.WithContext(ContextWithBotKey(req.Context(), key))
+	return bot.serveWebhook(w, req, route.config.Handler, route.config.MaxBodyBytes, utils.Field{Key: "bot", Value: key})
+}
+
+// Reconcile compares every routed bot's registered webhook URL with the URL
+// the router serves it at, and calls SetWebhook for bots that differ. The
+// bot's current secret token is sent along with the new URL. ctx cancels
+// the API requests.
+func (r *WebhookRouter) Reconcile(ctx context.Context) []ReconcileResult {
+	keys := r.Keys()
+	results := make([]ReconcileResult, 0, len(keys))
+
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			results = append(results, ReconcileResult{BotKey: key, Err: ctx.Err()})
+			continue
+		}
+		results = append(results, r.reconcile(ctx, key))
+	}
+	return results
+}
+
+// reconcile brings one bot's webhook registration in line with the router
+func (r *WebhookRouter) reconcile(ctx context.Context, key string) ReconcileResult {
+	result := ReconcileResult{BotKey: key, URL: r.WebhookURL(key)}
+
+	r.mu.RLock()
+	route, ok := r.routes[key]
+	r.mu.RUnlock()
+	if !ok {
+		result.Err = types.NewValidationError("route removed during reconcile")
+		return result
+	}
+
+	bot, ok := r.resolve(route, key)
+	if !ok {
+		result.Err = types.NewValidationError("bot not found")
+		return result
+	}
+
+	// Hold the bot's reconfigure lock so a concurrent secret rotation cannot
+	// be undone by registering the secret read here
+	bot.reconfigureMu.Lock()
+	defer bot.reconfigureMu.Unlock()
+
+	info, err := bot.GetWebhookInfoWithContext(ctx)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if info != nil && info.URL == result.URL {
+		return result
+	}
+
+	result.Err = bot.SetWebhookWithContext(ctx, types.WebhookConfig{
+		URL:         result.URL,
+		SecretToken: bot.GetWebhookService().GetSecretToken(),
+	})
+	result.Changed = result.Err == nil
+	return result
+}
+
+// routeKey extracts the bot key from a request path
+func (r *WebhookRouter) routeKey(path string) (string, bool) {
+	if !strings.HasPrefix(path, r.config.PathPrefix) {
+		return "", false
+	}
+	key := strings.TrimPrefix(path, r.config.PathPrefix)
+	if key == "" || strings.Contains(key, "/") {
+		return "", false
+	}
+	return key, true
+}
+
+// resolve returns the bot serving a route
+func (r *WebhookRouter) resolve(route *webhookRoute, key string) (*BotAPI, bool) {
+	if route.bot != nil {
+		return route.bot, true
+	}
+	if r.config.Manager != nil {
+		return r.config.Manager.Get(key)
+	}
+	return nil, false
+}
+
+// observe reports a served request to the configured webhook metrics
+func (r *WebhookRouter) observe(bot *BotAPI, key string, status int, duration time.Duration) {
+	metrics := r.config.Metrics
+	if metrics == nil {
+		metrics = bot.metrics()
+	}
+	if webhookMetrics, ok := metrics.(utils.WebhookMetrics); ok {
+		webhookMetrics.ObserveWebhookRequest(key, status, duration)
+	}
+}
+
+// record updates the route counters for a served request
+func (route *webhookRoute) record(status int, start time.Time) {
+	route.requests.Add(1)
+	route.lastRequest.Store(start.UnixNano())
+
+	switch {
+	case status >= 500:
+		route.failed.Add(1)
+	case status >= 400:
+		route.rejected.Add(1)
+	default:
+		route.accepted.Add(1)
+	}
+}
+
+// stats returns a snapshot of the route counters
+func (route *webhookRoute) stats() RouteStats {
+	stats := RouteStats{
+		Requests: route.requests.Load(),
+		Accepted: route.accepted.Load(),
+		Rejected: route.rejected.Load(),
+		Failed:   route.failed.Load(),
+		InFlight: route.inFlight.Load(),
+	}
+	if last := route.lastRequest.Load(); last != 0 {
+		stats.LastRequest = time.Unix(0, last)
+	}
+	return stats
+}
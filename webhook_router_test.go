@@ -0,0 +1,235 @@
+package zalobot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vkhangstack/go-zalo-bot/types"
+	"github.com/vkhangstack/go-zalo-bot/utils"
+)
+
+const routerTestPayload = `{"ok":true,"result":{"event_name":"message.text.received","message":{"message_id":"m1","text":"hi"}}}`
+
+// routerRequest sends a webhook request through router and returns the status
+func routerRequest(t *testing.T, router http.Handler, path, secret, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("X-Bot-Api-Secret-Token", secret)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookRouter_Routing(t *testing.T) {
+	metrics := utils.NewPrometheusMetrics("")
+	router := NewWebhookRouter(RouterConfig{BaseURL: "https://bots.example.com/", Metrics: metrics})
+
+	botA, _ := New(managerTokenA)
+	botB, _ := New(managerTokenB)
+	defer botA.Close()
+	defer botB.Close()
+
+	var mu sync.Mutex
+	handled := make(map[string]string)
+	handlerFor := func(name string) UpdateHandler {
+		return func(ctx context.Context, update types.Update) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled[name] = BotKeyFromContext(ctx)
+			return nil
+		}
+	}
+
+	if err := router.Handle("a", botA, RouteConfig{Handler: handlerFor("a"), SecretToken: "secret-a"}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if err := router.Handle("b", botB, RouteConfig{Handler: handlerFor("b"), SecretToken: "secret-b"}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		secret string
+		want   int
+	}{
+		{"bot a", "/webhook/a", "secret-a", http.StatusOK},
+		{"bot b", "/webhook/b", "secret-b", http.StatusOK},
+		{"secret of another bot", "/webhook/a", "secret-b", http.StatusForbidden},
+		{"unknown bot", "/webhook/c", "secret-a", http.StatusNotFound},
+		{"nested path", "/webhook/a/extra", "secret-a", http.StatusNotFound},
+		{"outside prefix", "/other/a", "secret-a", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routerRequest(t, router, tt.path, tt.secret, routerTestPayload); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if handled["a"] != "a" || handled["b"] != "b" {
+		t.Errorf("handlers saw bot keys %v", handled)
+	}
+
+	stats := router.Stats()
+	if stats["a"].Requests != 2 || stats["a"].Accepted != 1 || stats["a"].Rejected != 1 {
+		t.Errorf("stats[a] = %+v, want 2 requests, 1 accepted, 1 rejected", stats["a"])
+	}
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	for _, want := range []string{
+		`zalobot_webhook_requests_total{bot="a",code="200"} 1`,
+		`zalobot_webhook_requests_total{bot="a",code="403"} 1`,
+		`zalobot_webhook_request_duration_seconds_count{bot="b"} 1`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %q\n%s", want, b.String())
+		}
+	}
+
+	router.Remove("a")
+	if got := routerRequest(t, router, "/webhook/a", "secret-a", routerTestPayload); got != http.StatusNotFound {
+		t.Errorf("status after Remove() = %d, want 404", got)
+	}
+
+	if got := router.WebhookURL("b"); got != "https://bots.example.com/webhook/b" {
+		t.Errorf("WebhookURL() = %q", got)
+	}
+}
+
+func TestWebhookRouter_Limits(t *testing.T) {
+	router := NewWebhookRouter(RouterConfig{})
+	bot, _ := New(managerTokenA)
+	defer bot.Close()
+
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	handler := func(ctx context.Context, update types.Update) error {
+		entered <- struct{}{}
+		<-release
+		return nil
+	}
+	router.Handle("a", bot, RouteConfig{Handler: handler, SecretToken: "s", MaxBodyBytes: 200, MaxConcurrent: 1})
+
+	if got := routerRequest(t, router, "/webhook/a", "s", strings.Repeat("x", 201)); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body status = %d, want 413", got)
+	}
+
+	done := make(chan int)
+	go func() { done <- routerRequest(t, router, "/webhook/a", "s", routerTestPayload) }()
+	<-entered
+
+	if got := routerRequest(t, router, "/webhook/a", "s", routerTestPayload); got != http.StatusTooManyRequests {
+		t.Errorf("concurrent request status = %d, want 429", got)
+	}
+	if inFlight := router.Stats()["a"].InFlight; inFlight != 1 {
+		t.Errorf("InFlight = %d, want 1", inFlight)
+	}
+
+	close(release)
+	if got := <-done; got != http.StatusOK {
+		t.Errorf("first request status = %d, want 200", got)
+	}
+}
+
+func TestWebhookRouter_ManagerAndReconcile(t *testing.T) {
+	var mu sync.Mutex
+	registered := make(map[string]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.Split(strings.TrimPrefix(r.URL.Path, "/bot"), "/")[0]
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getWebhookInfo"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": map[string]string{"url": registered[token]}})
+		case strings.HasSuffix(r.URL.Path, "/setWebhook"):
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			registered[token] = body["url"]
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	defer server.Close()
+
+	manager := NewBotManager(ManagerConfig{Options: []types.BotOption{types.WithBaseURL(server.URL)}})
+	defer manager.Close()
+	manager.Add("a", managerTokenA)
+	manager.Add("b", managerTokenB)
+
+	router := NewWebhookRouter(RouterConfig{BaseURL: "https://bots.example.com", Manager: manager})
+	noop := func(ctx context.Context, update types.Update) error { return nil }
+	router.Handle("a", nil, RouteConfig{Handler: noop, SecretToken: "sa"})
+	router.Handle("b", nil, RouteConfig{Handler: noop, SecretToken: "sb"})
+
+	registered[managerTokenB] = "https://bots.example.com/webhook/b"
+
+	results := router.Reconcile(context.Background())
+	if len(results) != 2 {
+		t.Fatalf("Reconcile() returned %d results, want 2", len(results))
+	}
+	if results[0].BotKey != "a" || !results[0].Changed || results[0].Err != nil {
+		t.Errorf("result[a] = %+v, want changed", results[0])
+	}
+	if results[1].BotKey != "b" || results[1].Changed || results[1].Err != nil {
+		t.Errorf("result[b] = %+v, want unchanged", results[1])
+	}
+	if registered[managerTokenA] != "https://bots.example.com/webhook/a" {
+		t.Errorf("bot a registered %q", registered[managerTokenA])
+	}
+
+	// Routes backed by the manager follow token swaps
+	swapped, err := manager.SwapToken("a", "333333:CCC-DEF1234ghIkl-zyx57W2v1u123ew11")
+	if err != nil {
+		t.Fatalf("SwapToken() error = %v", err)
+	}
+	if swapped.ValidateWebhookSecretToken("sa") != nil {
+		t.Fatal("swapped bot lost the route secret")
+	}
+	if got := routerRequest(t, router, "/webhook/a", "sa", routerTestPayload); got != http.StatusOK {
+		t.Errorf("status after swap = %d, want 200", got)
+	}
+}
+
+func TestWebhookRouter_ReconcileCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	bot, err := New(managerTokenA, types.WithBaseURL(server.URL), types.WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer bot.Close()
+
+	router := NewWebhookRouter(RouterConfig{BaseURL: "https://bots.example.com"})
+	router.Handle("a", bot, RouteConfig{Handler: func(ctx context.Context, update types.Update) error { return nil }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	results := router.Reconcile(ctx)
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("Reconcile() = %+v, want an error", results)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Reconcile() took %v after its context was cancelled", elapsed)
+	}
+}